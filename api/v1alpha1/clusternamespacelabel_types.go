@@ -0,0 +1,111 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterNamespaceLabelSpec defines the labels stamped on every selected Namespace
+type ClusterNamespaceLabelSpec struct {
+	// Labels to be added to the selected Namespaces. Values may be Go templates rendered for each Namespace,
+	// e.g. {{ .Namespace.Name }}, {{ .Namespace.Annotations "owner" }} or {{ now "2006-01" }}
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=object
+	Labels map[string]string `json:"labels,omitempty"`
+	// NamespaceSelector selects the Namespaces to label
+	NamespaceSelector ClusterNamespaceSelector `json:"namespaceSelector"`
+	// Clusters lists member clusters whose Namespaces also receive the labels, reached through kubeconfigs
+	// stored in Secrets. Requires the controller's --enable-multi-cluster.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=name
+	Clusters []MemberCluster `json:"clusters,omitempty"`
+	// Suspend stops the controller from applying or removing labels until it is cleared. Deleting a suspended
+	// ClusterNamespaceLabel leaves its labels on the Namespaces.
+	// +kubebuilder:validation:Optional
+	Suspend bool `json:"suspend,omitempty"`
+	// CreateNamespace creates the Namespaces namespaceSelector.names lists that do not exist yet, marking them
+	// with the namespacelabel.dana.io/created-by annotation
+	// +kubebuilder:validation:Optional
+	CreateNamespace bool `json:"createNamespace,omitempty"`
+	// DeleteCreatedNamespaces deletes the Namespaces the ClusterNamespaceLabel created when it is deleted,
+	// together with everything in them. Namespaces it did not create only lose its labels.
+	// +kubebuilder:validation:Optional
+	DeleteCreatedNamespaces bool `json:"deleteCreatedNamespaces,omitempty"`
+}
+
+// ClusterNamespaceSelector selects Namespaces by label and/or name. A Namespace is selected when it matches
+// either; an empty selector selects no Namespaces.
+type ClusterNamespaceSelector struct {
+	// LabelSelector selects Namespaces by their labels
+	// +kubebuilder:validation:Optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// Names lists Namespaces selected by name. Names may be Go templates rendered against the
+	// ClusterNamespaceLabel, e.g. {{ .ClusterNamespaceLabel.Name }}-dev or {{ .ClusterNamespaceLabel.Labels "tenant" }}
+	// +kubebuilder:validation:Optional
+	// +listType=set
+	Names []string `json:"names,omitempty"`
+}
+
+// ClusterNamespaceLabelStatus defines the observed state of ClusterNamespaceLabel
+type ClusterNamespaceLabelStatus struct {
+	// AppliedLabels shows the labels that have been successfully applied
+	// +kubebuilder:validation:Optional
+	AppliedLabels map[string]string `json:"appliedLabels,omitempty"`
+	// LabeledNamespaces are the Namespaces the labels were last applied to
+	// +kubebuilder:validation:Optional
+	LabeledNamespaces []string `json:"labeledNamespaces,omitempty"`
+	// Clusters reports the labels synced to each member cluster, sorted by name
+	// +kubebuilder:validation:Optional
+	Clusters []MemberClusterStatus `json:"clusters,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was last computed for
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions represents the latest available observations of an object's state
+	// +kubebuilder:validation:Optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:shortName=cnsl
+// +kubebuilder:printcolumn:name="Labels",type="string",JSONPath=".spec.labels",description="Labels applied to the selected Namespaces"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the labels are applied"
+
+// ClusterNamespaceLabel is the Schema for the clusternamespacelabels API
+type ClusterNamespaceLabel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterNamespaceLabelSpec   `json:"spec,omitempty"`
+	Status ClusterNamespaceLabelStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterNamespaceLabelList contains a list of ClusterNamespaceLabel
+type ClusterNamespaceLabelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterNamespaceLabel `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterNamespaceLabel{}, &ClusterNamespaceLabelList{})
+}
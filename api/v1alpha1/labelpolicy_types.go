@@ -0,0 +1,136 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LabelPolicySpec defines the rules enforced on NamespaceLabels in the selected namespaces
+type LabelPolicySpec struct {
+	// NamespaceSelector selects the namespaces this policy applies to. An empty selector matches all namespaces.
+	// +kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// AllowedKeyPrefixes restricts the label keys that may be set in the selected namespaces to those
+	// starting with one of the prefixes. When unset, any key not denied may be set.
+	// +kubebuilder:validation:Optional
+	AllowedKeyPrefixes []string `json:"allowedKeyPrefixes,omitempty"`
+	// DeniedKeys lists label keys that may not be set in the selected namespaces
+	// +kubebuilder:validation:Optional
+	DeniedKeys []string `json:"deniedKeys,omitempty"`
+	// DeniedValues lists label values that may not be set in the selected namespaces
+	// +kubebuilder:validation:Optional
+	DeniedValues []DeniedValueRule `json:"deniedValues,omitempty"`
+	// ValueConstraints restrict the values of label keys to regular expressions
+	// +kubebuilder:validation:Optional
+	ValueConstraints []ValueConstraint `json:"valueConstraints,omitempty"`
+	// Rules are CEL expressions the labels set in the selected namespaces must satisfy, e.g.
+	// "!('environment' in labels) || labels.environment in ['dev', 'staging', 'prod']"
+	// +kubebuilder:validation:Optional
+	Rules []CELRule `json:"rules,omitempty"`
+	// AllowedPrincipals restricts who may create or update NamespaceLabels in the selected namespaces.
+	// When unset, any principal permitted by RBAC may change NamespaceLabels.
+	// +kubebuilder:validation:Optional
+	AllowedPrincipals *PrincipalList `json:"allowedPrincipals,omitempty"`
+	// MaxLabels caps how many labels a NamespaceLabel in the selected namespaces may declare
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	MaxLabels *int32 `json:"maxLabels,omitempty"`
+	// MaxManagedLabels caps how many labels NamespaceLabels and ClusterNamespaceLabels may manage on each
+	// selected namespace in total
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	MaxManagedLabels *int32 `json:"maxManagedLabels,omitempty"`
+}
+
+// DeniedValueRule forbids a set of values for a single label key
+type DeniedValueRule struct {
+	// Key is the label key the rule applies to
+	Key string `json:"key"`
+	// Values that may not be set for Key
+	// +kubebuilder:validation:MinItems=1
+	Values []string `json:"values"`
+	// Reason is returned to the user when the rule denies a request
+	// +kubebuilder:validation:Optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// ValueConstraint requires the value of a single label key to match a regular expression
+type ValueConstraint struct {
+	// Key is the label key the constraint applies to
+	Key string `json:"key"`
+	// Pattern is the regular expression the whole value must match
+	// +kubebuilder:validation:MinLength=1
+	Pattern string `json:"pattern"`
+	// Reason is returned to the user when the constraint denies a request
+	// +kubebuilder:validation:Optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// CELRule is a CEL expression that must evaluate to true for the labels set in a namespace to be allowed
+type CELRule struct {
+	// Expression is evaluated with the variable labels, a map of the label keys set to their values
+	// +kubebuilder:validation:MinLength=1
+	Expression string `json:"expression"`
+	// Reason is returned to the user when the rule denies a request
+	// +kubebuilder:validation:Optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// PrincipalList identifies the users, groups and service accounts allowed by a policy
+type PrincipalList struct {
+	// Users is a list of allowed user names
+	// +kubebuilder:validation:Optional
+	Users []string `json:"users,omitempty"`
+	// Groups is a list of allowed groups; a principal in any of them is allowed
+	// +kubebuilder:validation:Optional
+	Groups []string `json:"groups,omitempty"`
+	// ServiceAccounts is a list of allowed service accounts in "namespace/name" form
+	// +kubebuilder:validation:Optional
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+}
+
+// LabelPolicyStatus defines the observed state of LabelPolicy
+type LabelPolicyStatus struct {
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:shortName=lp
+
+// LabelPolicy is the Schema for the labelpolicies API
+type LabelPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LabelPolicySpec   `json:"spec,omitempty"`
+	Status LabelPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// LabelPolicyList contains a list of LabelPolicy
+type LabelPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LabelPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LabelPolicy{}, &LabelPolicyList{})
+}
@@ -22,26 +22,340 @@ import (
 
 // NamespaceLabelSpec defines the desired state of NamespaceLabel
 type NamespaceLabelSpec struct {
-	// Labels to be added to the Namespace
+	// Labels to be added to the Namespace. Values may be Go templates rendered against the Namespace,
+	// e.g. {{ .Namespace.Name }}, {{ .Namespace.Annotations "owner" }} or {{ now "2006-01" }}
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Type=object
+	// +kubebuilder:validation:MaxProperties=256
+	// +kubebuilder:validation:XValidation:rule="self.all(key, key.matches('^([a-z0-9]([-a-z0-9]*[a-z0-9])?([.][a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$'))",message="label keys must be qualified names with an optional DNS subdomain prefix"
 	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations to be added to the Namespace. They follow the lifecycle of labels: they are removed when
+	// dropped from the spec or when the NamespaceLabel is deleted, and protected annotations may not be set.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:validation:MaxProperties=256
+	// +kubebuilder:validation:XValidation:rule="self.all(key, !key.startsWith('namespacelabel.dana.io/'))",message="annotations with the namespacelabel.dana.io/ prefix are reserved for the controller"
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// LabelsFrom lists ConfigMaps and Secrets in the NamespaceLabel's namespace whose data keys become labels.
+	// Later sources override earlier ones, and labels override them all.
+	// +kubebuilder:validation:Optional
+	LabelsFrom []LabelsFromSource `json:"labelsFrom,omitempty"`
+	// LabelRules are labels applied until they expire, e.g. for incident freezes and maintenance windows.
+	// Their keys may not also be declared in labels.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=key
+	LabelRules []LabelRule `json:"labelRules,omitempty"`
+	// ResyncInterval overrides how often the labels are re-applied to the Namespace
+	// even when nothing changed, e.g. "5m"
+	// +kubebuilder:validation:Optional
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+	// TargetNamespaces lists the Namespaces labeled instead of the NamespaceLabel's own namespace.
+	// Only NamespaceLabels in one of the controller's admin namespaces may set it.
+	// +kubebuilder:validation:Optional
+	// +listType=set
+	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
+	// Priority decides which NamespaceLabel's value is applied when several in the namespace declare the
+	// same key. The highest priority wins; within equal priority the most recently created one wins.
+	// +kubebuilder:validation:Optional
+	Priority int32 `json:"priority,omitempty"`
+	// Propagate applies the labels to workloads in the Namespace and their pod templates as well. Changing
+	// pod template labels rolls out new pods. Not supported with targetNamespaces.
+	// +kubebuilder:validation:Optional
+	Propagate *PropagateSpec `json:"propagate,omitempty"`
+	// Suspend stops the controller from applying or removing labels until it is cleared. Deleting a suspended
+	// NamespaceLabel leaves its labels on the Namespace.
+	// +kubebuilder:validation:Optional
+	Suspend bool `json:"suspend,omitempty"`
+	// DryRun computes the label changes the NamespaceLabel would make and reports them in status.pendingChanges
+	// and an Event instead of applying them. Deleting a dry-run NamespaceLabel leaves the Namespace untouched.
+	// +kubebuilder:validation:Optional
+	DryRun bool `json:"dryRun,omitempty"`
+	// IgnoreDriftKeys lists declared label keys applied once and then left alone: their changes and removal
+	// by other managers are recorded as drift instead of reverted, on top of the controller's --ignore-drift-keys
+	// +kubebuilder:validation:Optional
+	// +listType=set
+	IgnoreDriftKeys []string `json:"ignoreDriftKeys,omitempty"`
+	// EnforceKeys lists declared label keys whose changes by other managers are always reverted, even where the
+	// controller's --ignore-drift-keys or --ignore-drift-namespaces would record them as drift. A key may not be
+	// listed in both ignoreDriftKeys and enforceKeys.
+	// +kubebuilder:validation:Optional
+	// +listType=set
+	EnforceKeys []string `json:"enforceKeys,omitempty"`
+	// Clusters lists member clusters whose Namespaces also receive the labels applied to the Namespace,
+	// reached through kubeconfigs stored in Secrets. Requires the controller's --enable-multi-cluster.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=name
+	Clusters []MemberCluster `json:"clusters,omitempty"`
+	// DependsOn lists NamespaceLabels whose labels are applied first: the labels of this NamespaceLabel are
+	// applied only once every one of them reports Ready, and a WaitingForDependency condition is reported until then
+	// +kubebuilder:validation:Optional
+	DependsOn []NamespaceLabelReference `json:"dependsOn,omitempty"`
+}
+
+// PropagateSpec selects the workloads that receive the labels of a NamespaceLabel
+type PropagateSpec struct {
+	// Kinds are the workload kinds labeled along with their pod templates
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Kinds []PropagateKind `json:"kinds"`
+	// Selector restricts propagation to workloads whose labels match it; if not set all workloads of the
+	// kinds are labeled
+	// +kubebuilder:validation:Optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// LabelOperation is how a label change modifies a Namespace label
+// +kubebuilder:validation:Enum=Add;Update;Remove
+type LabelOperation string
+
+const (
+	LabelAdd    LabelOperation = "Add"
+	LabelUpdate LabelOperation = "Update"
+	LabelRemove LabelOperation = "Remove"
+)
+
+// PendingLabelChange is a Namespace label change a dry-run NamespaceLabel would make
+type PendingLabelChange struct {
+	// Namespace is the Namespace whose label would change
+	Namespace string `json:"namespace"`
+	// Key of the label
+	Key string `json:"key"`
+	// Operation is whether the label would be added, updated or removed
+	Operation LabelOperation `json:"operation"`
+	// Value is the value the label would be set to; empty when it would be removed
+	// +kubebuilder:validation:Optional
+	Value string `json:"value,omitempty"`
+	// Previous is the current value of the label; empty when it would be added
+	// +kubebuilder:validation:Optional
+	Previous string `json:"previous,omitempty"`
+}
+
+// PropagateKind is a workload kind labels can be propagated to
+// +kubebuilder:validation:Enum=Deployment;StatefulSet
+type PropagateKind string
+
+const (
+	PropagateDeployment  PropagateKind = "Deployment"
+	PropagateStatefulSet PropagateKind = "StatefulSet"
+)
+
+// LabelRule is a label removed from the Namespace once it expires, or applied only during the windows of its
+// schedule. At most one of expiresAfter, expiresAt and schedule may be set; a rule setting none never expires.
+type LabelRule struct {
+	// Key of the label
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+	// Value of the label
+	// +kubebuilder:validation:Optional
+	Value string `json:"value,omitempty"`
+	// ExpiresAfter removes the label this long after the rule is first applied, e.g. "72h"
+	// +kubebuilder:validation:Optional
+	ExpiresAfter *metav1.Duration `json:"expiresAfter,omitempty"`
+	// ExpiresAt removes the label at this time
+	// +kubebuilder:validation:Optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// Schedule sets the label at the times of its apply schedule and clears it at the times of its remove
+	// schedule, e.g. for recurring maintenance windows
+	// +kubebuilder:validation:Optional
+	Schedule *LabelSchedule `json:"schedule,omitempty"`
+}
+
+// LabelSchedule defines the recurring windows of a scheduled label rule. A window opens at a time of the apply
+// schedule and closes at the next time of the remove schedule.
+type LabelSchedule struct {
+	// Apply is the cron expression of the times the label is set, e.g. "0 22 * * 6"
+	// +kubebuilder:validation:MinLength=1
+	Apply string `json:"apply"`
+	// Remove is the cron expression of the times the label is cleared, e.g. "0 6 * * 0"
+	// +kubebuilder:validation:MinLength=1
+	Remove string `json:"remove"`
+	// TimeZone is the IANA time zone the schedules are evaluated in, e.g. "Europe/Berlin"; defaults to UTC
+	// +kubebuilder:validation:Optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// LabelWindow is the state of a scheduled label rule
+type LabelWindow struct {
+	// Active is true while the window is open and the label is set
+	Active bool `json:"active"`
+	// NextTransition is when the window next opens, or closes while it is active
+	NextTransition metav1.Time `json:"nextTransition"`
+}
+
+// LabelsFromSource selects a ConfigMap or Secret whose data keys become labels. Exactly one of configMapRef
+// and secretRef must be set.
+type LabelsFromSource struct {
+	// Prefix is prepended to every data key, e.g. "inventory.dana.io/"
+	// +kubebuilder:validation:Optional
+	Prefix string `json:"prefix,omitempty"`
+	// ConfigMapRef selects a ConfigMap
+	// +kubebuilder:validation:Optional
+	ConfigMapRef *LabelsSourceReference `json:"configMapRef,omitempty"`
+	// SecretRef selects a Secret
+	// +kubebuilder:validation:Optional
+	SecretRef *LabelsSourceReference `json:"secretRef,omitempty"`
+}
+
+// NamespaceLabelReference names a NamespaceLabel
+type NamespaceLabelReference struct {
+	// Namespace of the NamespaceLabel; defaults to the namespace of the referencing NamespaceLabel
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the NamespaceLabel
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// MemberCluster is a remote cluster whose Namespaces receive the labels as well
+type MemberCluster struct {
+	// Name identifies the cluster in the status
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// KubeconfigSecretRef references the Secret holding the kubeconfig the controller reaches the cluster with
+	KubeconfigSecretRef KubeconfigSecretReference `json:"kubeconfigSecretRef"`
+	// NamespaceSelector selects the Namespaces labeled in the cluster; if not set the Namespaces with the same
+	// names as the ones labeled locally are labeled, when they exist
+	// +kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// KubeconfigSecretReference names the key of a Secret holding a kubeconfig
+type KubeconfigSecretReference struct {
+	// Namespace of the Secret. NamespaceLabels may only reference Secrets in their own namespace, the default;
+	// ClusterNamespaceLabels must set it.
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the Secret
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Key of the kubeconfig in the Secret's data; defaults to "kubeconfig"
+	// +kubebuilder:validation:Optional
+	Key string `json:"key,omitempty"`
+}
+
+// MemberClusterStatus reports the labels synced to a member cluster
+type MemberClusterStatus struct {
+	// Name of the cluster
+	Name string `json:"name"`
+	// KubeconfigSecretRef is the Secret the cluster was last reached with, kept to clean up the cluster once
+	// it is dropped from the spec
+	KubeconfigSecretRef KubeconfigSecretReference `json:"kubeconfigSecretRef"`
+	// LabeledNamespaces are the Namespaces of the cluster the labels were last applied to
+	// +kubebuilder:validation:Optional
+	LabeledNamespaces []string `json:"labeledNamespaces,omitempty"`
+	// Synced is whether the labels were applied to every targeted Namespace of the cluster
+	Synced bool `json:"synced"`
+	// Message explains why the cluster is not synced
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+	// LastSyncTime is the last time the labels were successfully synced to the cluster
+	// +kubebuilder:validation:Optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// LabelsSourceReference names a ConfigMap or Secret in the NamespaceLabel's namespace
+type LabelsSourceReference struct {
+	// Name of the referenced object
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Optional allows the referenced object to be missing
+	// +kubebuilder:validation:Optional
+	Optional bool `json:"optional,omitempty"`
 }
 
+// SyncResult is the outcome of a reconciliation attempt of a NamespaceLabel
+// +kubebuilder:validation:Enum=Succeeded;Progressing;Failed;Stalled
+type SyncResult string
+
+const (
+	// SyncSucceeded is an attempt that applied the labels of the current generation
+	SyncSucceeded SyncResult = "Succeeded"
+	// SyncProgressing is an attempt that has not applied the labels yet but has not failed either, e.g. one
+	// waiting for a dependency
+	SyncProgressing SyncResult = "Progressing"
+	// SyncFailed is an attempt that failed and is retried
+	SyncFailed SyncResult = "Failed"
+	// SyncStalled is an attempt that failed and is not retried until the spec changes
+	SyncStalled SyncResult = "Stalled"
+)
+
 // NamespaceLabelStatus defines the observed state of NamespaceLabel
 type NamespaceLabelStatus struct {
-	// AppliedLabels shows the labels that have been successfully applied
+	// AppliedLabels shows the labels that have been successfully applied. Only these keys are removed
+	// from the Namespace when they disappear from the spec.
 	AppliedLabels map[string]string `json:"appliedLabels,omitempty"`
-	// Conditions represents the latest available observations of an object's state
+	// AppliedAnnotations shows the annotations that have been successfully applied. Only these keys are
+	// removed from the Namespace when they disappear from the spec.
+	// +kubebuilder:validation:Optional
+	AppliedAnnotations map[string]string `json:"appliedAnnotations,omitempty"`
+	// DriftedLabels holds the values of labels changed by an external manager that the controller
+	// is configured not to revert; labels removed by an external manager have an empty value
+	// +kubebuilder:validation:Optional
+	DriftedLabels map[string]string `json:"driftedLabels,omitempty"`
+	// OverriddenLabels holds the values labels had on the Namespace before the NamespaceLabel overwrote
+	// them. They are restored when the label is dropped from the spec or the NamespaceLabel is deleted.
+	// Not recorded for targetNamespaces.
+	// +kubebuilder:validation:Optional
+	OverriddenLabels map[string]string `json:"overriddenLabels,omitempty"`
+	// LabeledNamespaces are the target Namespaces the labels were last applied to
+	// +kubebuilder:validation:Optional
+	LabeledNamespaces []string `json:"labeledNamespaces,omitempty"`
+	// LabelExpirations are the times the expiring label rules expire at. The expiry of an expiresAfter rule
+	// is fixed when the rule is first applied.
+	// +kubebuilder:validation:Optional
+	LabelExpirations map[string]metav1.Time `json:"labelExpirations,omitempty"`
+	// ExpiredLabels are the keys of the label rules that expired and were removed from the Namespace
+	// +kubebuilder:validation:Optional
+	// +listType=set
+	ExpiredLabels []string `json:"expiredLabels,omitempty"`
+	// LabelWindows are the windows of the scheduled label rules
+	// +kubebuilder:validation:Optional
+	LabelWindows map[string]LabelWindow `json:"labelWindows,omitempty"`
+	// PropagatedKinds are the workload kinds the labels were last propagated to, cleaned up when
+	// propagation stops
+	// +kubebuilder:validation:Optional
+	PropagatedKinds []PropagateKind `json:"propagatedKinds,omitempty"`
+	// PendingChanges are the label changes a dry-run NamespaceLabel would make, sorted by Namespace and key
+	// +kubebuilder:validation:Optional
+	PendingChanges []PendingLabelChange `json:"pendingChanges,omitempty"`
+	// Clusters reports the labels synced to each member cluster, sorted by name
+	// +kubebuilder:validation:Optional
+	Clusters []MemberClusterStatus `json:"clusters,omitempty"`
+	// LastAppliedTime is the last time the labels were successfully applied to the Namespace
+	// +kubebuilder:validation:Optional
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+	// PendingSince is the time reconciliation of the current spec (or resync) started and has not yet
+	// succeeded; it is cleared once the labels are applied
+	// +kubebuilder:validation:Optional
+	PendingSince *metav1.Time `json:"pendingSince,omitempty"`
+	// LastSyncTime is the last time the controller attempted to reconcile the NamespaceLabel
+	// +kubebuilder:validation:Optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// LastSyncResult is the outcome of the last reconciliation attempt
+	// +kubebuilder:validation:Optional
+	LastSyncResult SyncResult `json:"lastSyncResult,omitempty"`
+	// RetryCount is the number of times the controller requeued the NamespaceLabel with exponential backoff
+	// since it last reconciled it successfully; zero while it is not backing off
+	// +kubebuilder:validation:Optional
+	RetryCount int32 `json:"retryCount,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was last computed for
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions represents the latest available observations of an object's state. Ready, Reconciling
+	// and Stalled summarize the detailed conditions following the kstatus conventions.
 	// +kubebuilder:validation:Optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 // +kubebuilder:resource:scope=Namespaced
 // +kubebuilder:shortName=nsl
 // +kubebuilder:printcolumn:name="Labels",type="string",JSONPath=".spec.labels",description="Labels applied to the Namespace"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the labels are applied"
 
 // NamespaceLabel is the Schema for the namespacelabels API
 type NamespaceLabel struct {
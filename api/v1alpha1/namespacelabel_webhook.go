@@ -36,7 +36,7 @@ func (r *NamespaceLabel) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 // TODO(user): EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 
-// +kubebuilder:webhook:path=/mutate-dana-dana-io-v1alpha1-namespacelabel,mutating=true,failurePolicy=fail,sideEffects=None,groups=dana.dana.io,resources=namespacelabels,verbs=create;update,versions=v1alpha1,name=mnamespacelabel.kb.io,admissionReviewVersions=v1
+// NOTE: defaulting is served by the NamespaceLabelDefaulter registered in pkg/webhook.
 
 var _ webhook.Defaulter = &NamespaceLabel{}
 
@@ -47,11 +47,7 @@ func (r *NamespaceLabel) Default() {
 	// TODO(user): fill in your defaulting logic.
 }
 
-// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
-// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
-// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
-// +kubebuilder:webhook:path=/validate-dana-dana-io-v1alpha1-namespacelabel,mutating=false,failurePolicy=fail,sideEffects=None,groups=dana.dana.io,resources=namespacelabels,verbs=create;update,versions=v1alpha1,name=vnamespacelabel.kb.io,admissionReviewVersions=v1
-
+// NOTE: validation is served by the NamespaceLabelValidator registered in pkg/webhook.
 var _ webhook.Validator = &NamespaceLabel{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
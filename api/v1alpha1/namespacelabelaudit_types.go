@@ -0,0 +1,46 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:shortName=nsla
+
+// NamespaceLabelAudit is the object the audit Events of a namespace's label changes are recorded on, so
+// `kubectl describe namespacelabelaudit` shows who changed which label and when. The controller creates one
+// per audited namespace.
+type NamespaceLabelAudit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NamespaceLabelAuditList contains a list of NamespaceLabelAudit
+type NamespaceLabelAuditList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceLabelAudit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceLabelAudit{}, &NamespaceLabelAuditList{})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReportState is the health of the labels a NamespaceLabel manages on a Namespace
+type ReportState string
+
+const (
+	// ReportInSync is a Namespace whose labels are applied as declared
+	ReportInSync ReportState = "InSync"
+	// ReportDrifted is a Namespace with labels changed by another manager and left unreverted
+	ReportDrifted ReportState = "Drifted"
+	// ReportFailing is a Namespace whose labels are not applied, or are invalid, stale or degraded
+	ReportFailing ReportState = "Failing"
+)
+
+// ReportSummary counts the labeled Namespaces of the cluster by state
+type ReportSummary struct {
+	// Namespaces is the number of labeled Namespaces
+	Namespaces int32 `json:"namespaces"`
+	// InSync is the number of Namespaces whose labels are applied as declared
+	InSync int32 `json:"inSync"`
+	// Drifted is the number of Namespaces with drifted labels
+	Drifted int32 `json:"drifted"`
+	// Failing is the number of Namespaces whose labels are not applied
+	Failing int32 `json:"failing"`
+}
+
+// ReportEntry is the state of the labels a NamespaceLabel manages on one Namespace
+type ReportEntry struct {
+	// Namespace is the labeled Namespace
+	Namespace string `json:"namespace"`
+	// NamespaceLabel is the namespace/name of the NamespaceLabel managing the labels
+	NamespaceLabel string `json:"namespaceLabel"`
+	// State is the health of the labels
+	State ReportState `json:"state"`
+	// Reason explains a Drifted or Failing state, e.g. "Invalid"
+	// +kubebuilder:validation:Optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// NamespaceLabelReportStatus is one page of the cluster-wide label health report
+type NamespaceLabelReportStatus struct {
+	// Summary counts every labeled Namespace of the cluster, on every page
+	// +kubebuilder:validation:Optional
+	Summary ReportSummary `json:"summary,omitempty"`
+	// Page is the number of this page, starting at 1
+	// +kubebuilder:validation:Optional
+	Page int32 `json:"page,omitempty"`
+	// Pages is the number of pages of the report
+	// +kubebuilder:validation:Optional
+	Pages int32 `json:"pages,omitempty"`
+	// Next is the name of the NamespaceLabelReport holding the next page, if any
+	// +kubebuilder:validation:Optional
+	Next string `json:"next,omitempty"`
+	// Omitted is the number of entries left out of the report because it reached its page limit
+	// +kubebuilder:validation:Optional
+	Omitted int32 `json:"omitted,omitempty"`
+	// Entries lists the labeled Namespaces of this page, failing ones first, then drifted and in sync ones
+	// +kubebuilder:validation:Optional
+	Entries []ReportEntry `json:"entries,omitempty"`
+	// GeneratedAt is when the report was last written
+	// +kubebuilder:validation:Optional
+	GeneratedAt *metav1.Time `json:"generatedAt,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:shortName=nslr
+// +kubebuilder:printcolumn:name="Namespaces",type="integer",JSONPath=".status.summary.namespaces",description="Labeled Namespaces"
+// +kubebuilder:printcolumn:name="In Sync",type="integer",JSONPath=".status.summary.inSync",description="Namespaces whose labels are applied as declared"
+// +kubebuilder:printcolumn:name="Drifted",type="integer",JSONPath=".status.summary.drifted",description="Namespaces with drifted labels"
+// +kubebuilder:printcolumn:name="Failing",type="integer",JSONPath=".status.summary.failing",description="Namespaces whose labels are not applied"
+// +kubebuilder:printcolumn:name="Page",type="integer",JSONPath=".status.page",description="Page of the report"
+
+// NamespaceLabelReport summarizes the label health of every Namespace labeled through NamespaceLabels, so the
+// whole fleet can be checked with one object. The controller maintains it; entries beyond the first page are
+// written to further reports linked through status.next.
+type NamespaceLabelReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status NamespaceLabelReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NamespaceLabelReportList contains a list of NamespaceLabelReport
+type NamespaceLabelReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceLabelReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceLabelReport{}, &NamespaceLabelReportList{})
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// DriftKeysAnnotation keeps the enforceKeys and ignoreDriftKeys of the v1alpha1 hub naming no label entry, such
+// as keys of labels sourced from labelsFrom, so they survive a round trip through v1beta1
+const DriftKeysAnnotation = "namespacelabel.dana.io/drift-keys"
+
+// driftKeys is the value of the drift keys annotation
+type driftKeys struct {
+	EnforceKeys     []string `json:"enforceKeys,omitempty"`
+	IgnoreDriftKeys []string `json:"ignoreDriftKeys,omitempty"`
+}
+
+// ConvertTo converts this NamespaceLabel to the v1alpha1 hub. Label entries that expire or follow a schedule
+// become label rules, and entries that set enforce have their keys listed in enforceKeys or ignoreDriftKeys,
+// along with the keys kept in the drift keys annotation.
+func (src *NamespaceLabel) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.NamespaceLabel)
+	dst.ObjectMeta = src.ObjectMeta
+	var kept driftKeys
+	if value, exists := src.Annotations[DriftKeysAnnotation]; exists {
+		if err := json.Unmarshal([]byte(value), &kept); err != nil {
+			return fmt.Errorf("invalid %s annotation: %w", DriftKeysAnnotation, err)
+		}
+		dst.Annotations = maps.Clone(src.Annotations)
+		delete(dst.Annotations, DriftKeysAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	dst.Spec = v1alpha1.NamespaceLabelSpec{
+		ResyncInterval:   src.Spec.ResyncInterval,
+		TargetNamespaces: src.Spec.TargetNamespaces,
+		Priority:         src.Spec.Priority,
+		Annotations:      src.Spec.Annotations,
+		Suspend:          src.Spec.Suspend,
+		DryRun:           src.Spec.DryRun,
+	}
+	for _, entry := range src.Spec.Labels {
+		if entry.ExpireAfter != nil || entry.ExpireAt != nil || entry.Schedule != nil {
+			dst.Spec.LabelRules = append(dst.Spec.LabelRules, v1alpha1.LabelRule{
+				Key:          entry.Key,
+				Value:        entry.Value,
+				ExpiresAfter: entry.ExpireAfter,
+				ExpiresAt:    entry.ExpireAt,
+				Schedule:     (*v1alpha1.LabelSchedule)(entry.Schedule),
+			})
+		} else {
+			if dst.Spec.Labels == nil {
+				dst.Spec.Labels = make(map[string]string)
+			}
+			dst.Spec.Labels[entry.Key] = entry.Value
+		}
+		switch {
+		case entry.Enforce == nil:
+		case *entry.Enforce:
+			dst.Spec.EnforceKeys = append(dst.Spec.EnforceKeys, entry.Key)
+		default:
+			dst.Spec.IgnoreDriftKeys = append(dst.Spec.IgnoreDriftKeys, entry.Key)
+		}
+	}
+	for _, key := range kept.EnforceKeys {
+		if !slices.Contains(dst.Spec.EnforceKeys, key) && !slices.Contains(dst.Spec.IgnoreDriftKeys, key) {
+			dst.Spec.EnforceKeys = append(dst.Spec.EnforceKeys, key)
+		}
+	}
+	for _, key := range kept.IgnoreDriftKeys {
+		if !slices.Contains(dst.Spec.EnforceKeys, key) && !slices.Contains(dst.Spec.IgnoreDriftKeys, key) {
+			dst.Spec.IgnoreDriftKeys = append(dst.Spec.IgnoreDriftKeys, key)
+		}
+	}
+	for _, source := range src.Spec.LabelsFrom {
+		dst.Spec.LabelsFrom = append(dst.Spec.LabelsFrom, v1alpha1.LabelsFromSource{
+			Prefix:       source.Prefix,
+			ConfigMapRef: (*v1alpha1.LabelsSourceReference)(source.ConfigMapRef),
+			SecretRef:    (*v1alpha1.LabelsSourceReference)(source.SecretRef),
+		})
+	}
+	if src.Spec.Propagate != nil {
+		dst.Spec.Propagate = &v1alpha1.PropagateSpec{Selector: src.Spec.Propagate.Selector}
+		for _, kind := range src.Spec.Propagate.Kinds {
+			dst.Spec.Propagate.Kinds = append(dst.Spec.Propagate.Kinds, v1alpha1.PropagateKind(kind))
+		}
+	}
+	for _, dependency := range src.Spec.DependsOn {
+		dst.Spec.DependsOn = append(dst.Spec.DependsOn, v1alpha1.NamespaceLabelReference(dependency))
+	}
+	for _, cluster := range src.Spec.Clusters {
+		dst.Spec.Clusters = append(dst.Spec.Clusters, v1alpha1.MemberCluster{
+			Name:                cluster.Name,
+			KubeconfigSecretRef: v1alpha1.KubeconfigSecretReference(cluster.KubeconfigSecretRef),
+			NamespaceSelector:   cluster.NamespaceSelector,
+		})
+	}
+
+	dst.Status = v1alpha1.NamespaceLabelStatus{
+		AppliedLabels:      src.Status.AppliedLabels,
+		AppliedAnnotations: src.Status.AppliedAnnotations,
+		DriftedLabels:      src.Status.DriftedLabels,
+		OverriddenLabels:   src.Status.OverriddenLabels,
+		LabeledNamespaces:  src.Status.LabeledNamespaces,
+		LabelExpirations:   src.Status.LabelExpirations,
+		ExpiredLabels:      src.Status.ExpiredLabels,
+		LastAppliedTime:    src.Status.LastAppliedTime,
+		PendingSince:       src.Status.PendingSince,
+		LastSyncTime:       src.Status.LastSyncTime,
+		LastSyncResult:     v1alpha1.SyncResult(src.Status.LastSyncResult),
+		RetryCount:         src.Status.RetryCount,
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         src.Status.Conditions,
+	}
+	for key, window := range src.Status.LabelWindows {
+		if dst.Status.LabelWindows == nil {
+			dst.Status.LabelWindows = make(map[string]v1alpha1.LabelWindow, len(src.Status.LabelWindows))
+		}
+		dst.Status.LabelWindows[key] = v1alpha1.LabelWindow(window)
+	}
+	for _, kind := range src.Status.PropagatedKinds {
+		dst.Status.PropagatedKinds = append(dst.Status.PropagatedKinds, v1alpha1.PropagateKind(kind))
+	}
+	for _, change := range src.Status.PendingChanges {
+		dst.Status.PendingChanges = append(dst.Status.PendingChanges, v1alpha1.PendingLabelChange{
+			Namespace: change.Namespace,
+			Key:       change.Key,
+			Operation: v1alpha1.LabelOperation(change.Operation),
+			Value:     change.Value,
+			Previous:  change.Previous,
+		})
+	}
+	for _, cluster := range src.Status.Clusters {
+		dst.Status.Clusters = append(dst.Status.Clusters, v1alpha1.MemberClusterStatus{
+			Name:                cluster.Name,
+			KubeconfigSecretRef: v1alpha1.KubeconfigSecretReference(cluster.KubeconfigSecretRef),
+			LabeledNamespaces:   cluster.LabeledNamespaces,
+			Synced:              cluster.Synced,
+			Message:             cluster.Message,
+			LastSyncTime:        cluster.LastSyncTime,
+		})
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub to this NamespaceLabel, with the labels and label rules merged into
+// label entries sorted by key. EnforceKeys and ignoreDriftKeys naming no entry are kept in the drift keys
+// annotation.
+func (dst *NamespaceLabel) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.NamespaceLabel)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = NamespaceLabelSpec{
+		ResyncInterval:   src.Spec.ResyncInterval,
+		TargetNamespaces: src.Spec.TargetNamespaces,
+		Priority:         src.Spec.Priority,
+		Annotations:      src.Spec.Annotations,
+		Suspend:          src.Spec.Suspend,
+		DryRun:           src.Spec.DryRun,
+	}
+	for key, value := range src.Spec.Labels {
+		dst.Spec.Labels = append(dst.Spec.Labels, LabelEntry{Key: key, Value: value})
+	}
+	for _, rule := range src.Spec.LabelRules {
+		dst.Spec.Labels = append(dst.Spec.Labels, LabelEntry{
+			Key:         rule.Key,
+			Value:       rule.Value,
+			ExpireAfter: rule.ExpiresAfter,
+			ExpireAt:    rule.ExpiresAt,
+			Schedule:    (*LabelSchedule)(rule.Schedule),
+		})
+	}
+	sort.Slice(dst.Spec.Labels, func(i, j int) bool { return dst.Spec.Labels[i].Key < dst.Spec.Labels[j].Key })
+	for i := range dst.Spec.Labels {
+		switch key := dst.Spec.Labels[i].Key; {
+		case slices.Contains(src.Spec.IgnoreDriftKeys, key):
+			enforce := false
+			dst.Spec.Labels[i].Enforce = &enforce
+		case slices.Contains(src.Spec.EnforceKeys, key):
+			enforce := true
+			dst.Spec.Labels[i].Enforce = &enforce
+		}
+	}
+	var kept driftKeys
+	hasEntry := func(key string) bool {
+		return slices.ContainsFunc(dst.Spec.Labels, func(entry LabelEntry) bool { return entry.Key == key })
+	}
+	for _, key := range src.Spec.EnforceKeys {
+		if !hasEntry(key) {
+			kept.EnforceKeys = append(kept.EnforceKeys, key)
+		}
+	}
+	for _, key := range src.Spec.IgnoreDriftKeys {
+		if !hasEntry(key) {
+			kept.IgnoreDriftKeys = append(kept.IgnoreDriftKeys, key)
+		}
+	}
+	if len(kept.EnforceKeys) > 0 || len(kept.IgnoreDriftKeys) > 0 {
+		value, err := json.Marshal(kept)
+		if err != nil {
+			return err
+		}
+		dst.Annotations = maps.Clone(dst.Annotations)
+		if dst.Annotations == nil {
+			dst.Annotations = make(map[string]string)
+		}
+		dst.Annotations[DriftKeysAnnotation] = string(value)
+	}
+	for _, source := range src.Spec.LabelsFrom {
+		dst.Spec.LabelsFrom = append(dst.Spec.LabelsFrom, LabelsFromSource{
+			Prefix:       source.Prefix,
+			ConfigMapRef: (*LabelsSourceReference)(source.ConfigMapRef),
+			SecretRef:    (*LabelsSourceReference)(source.SecretRef),
+		})
+	}
+	if src.Spec.Propagate != nil {
+		dst.Spec.Propagate = &PropagateSpec{Selector: src.Spec.Propagate.Selector}
+		for _, kind := range src.Spec.Propagate.Kinds {
+			dst.Spec.Propagate.Kinds = append(dst.Spec.Propagate.Kinds, PropagateKind(kind))
+		}
+	}
+	for _, dependency := range src.Spec.DependsOn {
+		dst.Spec.DependsOn = append(dst.Spec.DependsOn, NamespaceLabelReference(dependency))
+	}
+	for _, cluster := range src.Spec.Clusters {
+		dst.Spec.Clusters = append(dst.Spec.Clusters, MemberCluster{
+			Name:                cluster.Name,
+			KubeconfigSecretRef: KubeconfigSecretReference(cluster.KubeconfigSecretRef),
+			NamespaceSelector:   cluster.NamespaceSelector,
+		})
+	}
+
+	dst.Status = NamespaceLabelStatus{
+		AppliedLabels:      src.Status.AppliedLabels,
+		AppliedAnnotations: src.Status.AppliedAnnotations,
+		DriftedLabels:      src.Status.DriftedLabels,
+		OverriddenLabels:   src.Status.OverriddenLabels,
+		LabeledNamespaces:  src.Status.LabeledNamespaces,
+		LabelExpirations:   src.Status.LabelExpirations,
+		ExpiredLabels:      src.Status.ExpiredLabels,
+		LastAppliedTime:    src.Status.LastAppliedTime,
+		PendingSince:       src.Status.PendingSince,
+		LastSyncTime:       src.Status.LastSyncTime,
+		LastSyncResult:     SyncResult(src.Status.LastSyncResult),
+		RetryCount:         src.Status.RetryCount,
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         src.Status.Conditions,
+	}
+	for key, window := range src.Status.LabelWindows {
+		if dst.Status.LabelWindows == nil {
+			dst.Status.LabelWindows = make(map[string]LabelWindow, len(src.Status.LabelWindows))
+		}
+		dst.Status.LabelWindows[key] = LabelWindow(window)
+	}
+	for _, kind := range src.Status.PropagatedKinds {
+		dst.Status.PropagatedKinds = append(dst.Status.PropagatedKinds, PropagateKind(kind))
+	}
+	for _, change := range src.Status.PendingChanges {
+		dst.Status.PendingChanges = append(dst.Status.PendingChanges, PendingLabelChange{
+			Namespace: change.Namespace,
+			Key:       change.Key,
+			Operation: LabelOperation(change.Operation),
+			Value:     change.Value,
+			Previous:  change.Previous,
+		})
+	}
+	for _, cluster := range src.Status.Clusters {
+		dst.Status.Clusters = append(dst.Status.Clusters, MemberClusterStatus{
+			Name:                cluster.Name,
+			KubeconfigSecretRef: KubeconfigSecretReference(cluster.KubeconfigSecretRef),
+			LabeledNamespaces:   cluster.LabeledNamespaces,
+			Synced:              cluster.Synced,
+			Message:             cluster.Message,
+			LastSyncTime:        cluster.LastSyncTime,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/TalDebi/namespacelabel-assignment.git/internal/certs"
+)
+
+// Webhook certificate management modes of --webhook-cert-management
+const (
+	certManagementAuto        = "auto"
+	certManagementSelf        = "self"
+	certManagementCertManager = "cert-manager"
+	certManagementNone        = "none"
+)
+
+// setupCertRotator resolves the certificate management mode, writes the first certificate before the webhook
+// server starts and registers the rotator with the manager. The manager's cache is not started yet, so the
+// rotator uses a direct client.
+func setupCertRotator(ctx context.Context, mgr ctrl.Manager, mode string, rotator *certs.Rotator) error {
+	switch mode {
+	case certManagementNone:
+		return nil
+	case certManagementSelf, certManagementCertManager:
+	case certManagementAuto:
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+		if err != nil {
+			return err
+		}
+		installed, err := certs.CertManagerInstalled(discoveryClient)
+		if err != nil {
+			return fmt.Errorf("unable to detect cert-manager: %w", err)
+		}
+		if installed {
+			mode = certManagementCertManager
+		}
+	default:
+		return fmt.Errorf("unknown --webhook-cert-management %q", mode)
+	}
+	rotator.CertManager = mode == certManagementCertManager
+	setupLog.Info("managing webhook certificates", "certManager", rotator.CertManager, "secret", rotator.Secret)
+
+	directClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+	if err != nil {
+		return err
+	}
+	rotator.Client = directClient
+
+	// cert-manager may not have issued the Secret yet, and a replica may lose the race to create it
+	err = wait.PollUntilContextTimeout(ctx, 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if err := rotator.Refresh(ctx); err != nil {
+			setupLog.Info("webhook certificates not ready", "reason", err.Error())
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("webhook certificates not ready: %w", err)
+	}
+	return mgr.Add(rotator)
+}
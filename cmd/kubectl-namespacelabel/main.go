@@ -0,0 +1,176 @@
+// Command kubectl-namespacelabel is a kubectl plugin inspecting NamespaceLabels and the Namespace labels they
+// manage. Installed on the PATH it runs as "kubectl namespacelabel <status|diff|orphans>".
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/internal/inspect"
+)
+
+const usage = `Inspect NamespaceLabels and the Namespace labels they manage.
+
+Usage:
+  kubectl namespacelabel status  [-n NAMESPACE | -A] [-o json]   Summarize the status of NamespaceLabels
+  kubectl namespacelabel diff    [-n NAMESPACE | -A] [-o json]   Compare declared labels with Namespace labels
+  kubectl namespacelabel orphans [-o json]                       List controller labels no resource manages
+`
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(danav1alpha1.AddToScheme(scheme))
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "status", "diff", "orphans":
+		os.Exit(run(os.Args[1], os.Args[2:]))
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// run implements a subcommand and returns its exit code: 1 when diff or orphans found anything, 2 on errors
+func run(command string, args []string) int {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	kubeconfig := flags.String("kubeconfig", "", "The kubeconfig file to use. Defaults to $KUBECONFIG or ~/.kube/config")
+	namespace := flags.String("namespace", "", "The namespace to inspect. Defaults to the kubeconfig's namespace")
+	flags.StringVar(namespace, "n", "", "Shorthand for --namespace")
+	allNamespaces := flags.Bool("all-namespaces", false, "If set, every namespace is inspected")
+	flags.BoolVar(allNamespaces, "A", false, "Shorthand for --all-namespaces")
+	output := flags.String("output", "", "The output format: empty for a table, or json")
+	flags.StringVar(output, "o", "", "Shorthand for --output")
+	_ = flags.Parse(args)
+
+	if *output != "" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "unsupported output format %q\n", *output)
+		return 2
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = *kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load kubeconfig: %v\n", err)
+		return 2
+	}
+	if *allNamespaces {
+		*namespace = ""
+	} else if *namespace == "" {
+		if *namespace, _, err = clientConfig.Namespace(); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to resolve namespace: %v\n", err)
+			return 2
+		}
+	}
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create client: %v\n", err)
+		return 2
+	}
+
+	ctx := context.Background()
+	var (
+		result any
+		rows   [][]string
+		found  bool
+	)
+	switch command {
+	case "status":
+		statuses, err := inspect.Status(ctx, c, *namespace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "status failed: %v\n", err)
+			return 2
+		}
+		result = statuses
+		rows = append(rows, []string{"NAMESPACE", "NAME", "READY", "REASON", "APPLIED", "DRIFTED", "OVERRIDDEN", "LAST APPLIED"})
+		for _, s := range statuses {
+			lastApplied := "<never>"
+			if s.LastApplied != nil {
+				lastApplied = s.LastApplied.UTC().Format(time.RFC3339)
+			}
+			rows = append(rows, []string{s.Namespace, s.Name, s.Ready, s.Reason, fmt.Sprint(s.Applied),
+				fmt.Sprint(s.Drifted), fmt.Sprint(s.Overridden), lastApplied})
+		}
+
+	case "diff":
+		diffs, err := inspect.Diffs(ctx, c, *namespace, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "diff failed: %v\n", err)
+			return 2
+		}
+		result, found = diffs, len(diffs) > 0
+		rows = append(rows, []string{"NAMESPACE", "OWNER", "KEY", "KIND", "DESIRED", "ACTUAL"})
+		for _, d := range diffs {
+			rows = append(rows, []string{d.Namespace, d.Owner, d.Key, string(d.Kind), d.Desired, d.Actual})
+		}
+
+	case "orphans":
+		orphans, err := inspect.Orphans(ctx, c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "orphans failed: %v\n", err)
+			return 2
+		}
+		result, found = orphans, len(orphans) > 0
+		rows = append(rows, []string{"NAMESPACE", "KEY", "VALUE"})
+		for _, o := range orphans {
+			rows = append(rows, []string{o.Namespace, o.Key, o.Value})
+		}
+	}
+
+	if err := write(os.Stdout, *output, result, rows); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to write results: %v\n", err)
+		return 2
+	}
+	if found {
+		return 1
+	}
+	return 0
+}
+
+// write prints the result as indented JSON, or the rows as a table
+func write(out io.Writer, output string, result any, rows [][]string) error {
+	if output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	if len(rows) == 1 {
+		_, err := fmt.Fprintln(out, "No resources found.")
+		return err
+	}
+	table := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(table, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return table.Flush()
+}
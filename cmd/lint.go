@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/TalDebi/namespacelabel-assignment.git/internal/lint"
+)
+
+// runLint implements the lint verb: it checks the NamespaceLabel manifests in a directory against the
+// cluster's admission rules and prints the results as JSON. It exits 1 when a manifest is rejected.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	dir := flags.String("dir", ".", "The directory holding the NamespaceLabel manifests to check")
+	namespace := flags.String("namespace", "default", "The namespace of manifests that do not set one")
+	_ = flags.Parse(args)
+
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create client: %v\n", err)
+		return 2
+	}
+
+	results, err := lint.Dir(context.Background(), c, *dir, *namespace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint failed: %v\n", err)
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to write results: %v\n", err)
+		return 2
+	}
+
+	if lint.Failed(results) {
+		return 1
+	}
+	return 0
+}
@@ -19,13 +19,21 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -35,7 +43,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	danav1beta1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1beta1"
+	"github.com/TalDebi/namespacelabel-assignment.git/internal/certs"
 	"github.com/TalDebi/namespacelabel-assignment.git/internal/controller"
+	"github.com/TalDebi/namespacelabel-assignment.git/internal/httpauth"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
+	labelwebhook "github.com/TalDebi/namespacelabel-assignment.git/pkg/webhook"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -48,15 +62,85 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(danav1alpha1.AddToScheme(scheme))
+	utilruntime.Must(danav1beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "resync":
+			os.Exit(runResync(os.Args[2:]))
+		}
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var breakGlassGroups string
+	var defaultLabels string
+	var defaultGroupLabels string
+	var defaultLabelAnnotations string
+	var lowercaseLabelKeys bool
+	var watchLabel string
+	var leaderElectionID string
+	var partitionName string
+	var partitionNamespace string
+	var partitionSelector string
+	var partitionHashRange string
+	var ignoreDriftKeys string
+	var ignoreDriftNamespaces string
+	var strictMode bool
+	var managedLabelPrefix string
+	var staleThreshold time.Duration
+	var resyncPeriod time.Duration
+	var maxConcurrentReconciles int
+	var backoffBaseDelay time.Duration
+	var backoffMaxDelay time.Duration
+	var namespaceRequeuesPerSecond float64
+	var namespaceRequeueBurst int
+	var targetNamespaceTemplate string
+	var adminNamespaces string
+	var namespaceEvents bool
+	var namespaceWritesPerSecond float64
+	var namespaceWriteBurst int
+	var controllerServiceAccount string
+	var warnOnManagedLabelChanges bool
+	var protectedLabelPrefixes string
+	var protectedLabelPatterns string
+	var protectedLabelsConfigMap string
+	var protectedAnnotationPrefixes string
+	var allowMultipleNamespaceLabels bool
+	var enableMultiCluster bool
+	var optInAnnotation string
+	var memberClusterTimeout time.Duration
+	var protectedNamespaces string
+	var protectedNamespaceSelector string
+	var maxLabels int
+	var maxManagedLabels int
+	var auditLog string
+	var auditEvents bool
+	var notifyWebhookURLsFile string
+	var notifySlackURLsFile string
+	var notifyEvents string
+	var notifyRetries int
+	var bypassUsers string
+	var bypassGroups string
+	var bypassServiceAccounts string
+	var reportInterval time.Duration
+	var orphanScanInterval time.Duration
+	var reportPageSize int
+	var reportMaxPages int
+	var webhookCertManagement string
+	var webhookCertSecret string
+	var webhookCertDir string
+	var webhookDNSNames string
+	var webhookCertValidity time.Duration
+	var webhookCertRotateBefore time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metric endpoint binds to. "+
 		"Use the port :8080. If not set, it will be 0 in order to disable the metrics server")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -64,9 +148,167 @@ func main() {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&secureMetrics, "metrics-secure", false,
-		"If set the metrics endpoint is served securely")
+		"If set the metrics endpoint is served securely. Required for the "+controller.ResyncPath+" and "+
+			controller.SnapshotPath+" endpoints, which are not served over plain HTTP")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&webhookCertManagement, "webhook-cert-management", certManagementAuto,
+		"How the webhook serving certificate is managed: 'self' issues and rotates a self-signed CA and certificate, "+
+			"'cert-manager' uses the certificate cert-manager writes to --webhook-cert-secret, 'auto' defers to "+
+			"cert-manager when its API is served and self-manages otherwise, 'none' leaves --webhook-cert-dir as is")
+	flag.StringVar(&webhookCertSecret, "webhook-cert-secret", "cert-manager/webhook-server-cert",
+		"The Secret, as namespace/name, holding the webhook CA and serving certificate")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir",
+		filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs"),
+		"The directory the webhook server reads tls.crt and tls.key from")
+	flag.StringVar(&webhookDNSNames, "webhook-dns-names",
+		"webhook-service.cert-manager.svc,webhook-service.cert-manager.svc.cluster.local",
+		"Comma-separated DNS names of the webhook Service the self-managed certificate is issued for")
+	flag.DurationVar(&webhookCertValidity, "webhook-cert-validity", 90*24*time.Hour,
+		fmt.Sprintf("How long a self-managed serving certificate is valid; the CA is valid %d times longer",
+			certs.CAValidityFactor))
+	flag.DurationVar(&webhookCertRotateBefore, "webhook-cert-rotate-before", 30*24*time.Hour,
+		"How long before they expire self-managed certificates are rotated")
+	flag.StringVar(&breakGlassGroups, "break-glass-groups", "system:masters",
+		"Comma-separated groups allowed to bypass webhook denials with the break-glass annotation")
+	flag.StringVar(&bypassUsers, "bypass-users", "",
+		"Comma-separated trusted users whose NamespaceLabel changes the webhook admits with a warning instead of denying")
+	flag.StringVar(&bypassGroups, "bypass-groups", "",
+		"Comma-separated trusted groups whose NamespaceLabel changes the webhook admits with a warning instead of "+
+			"denying (e.g. system:masters)")
+	flag.StringVar(&bypassServiceAccounts, "bypass-service-accounts", "",
+		"Comma-separated trusted service accounts, as namespace/name, whose NamespaceLabel changes the webhook "+
+			"admits with a warning instead of denying (e.g. argocd/argocd-application-controller)")
+	flag.StringVar(&defaultLabels, "default-labels", "",
+		"Comma-separated default labels set on NamespaceLabels that do not declare them, as key=value "+
+			"(e.g. managed-by=platform)")
+	flag.StringVar(&defaultGroupLabels, "default-group-labels", "",
+		"Comma-separated group prefixes whose members' NamespaceLabels get the rest of the group name as a default "+
+			"label, as prefix=label (e.g. oidc:team-=team gives members of oidc:team-payments team=payments)")
+	flag.BoolVar(&lowercaseLabelKeys, "lowercase-label-keys", false,
+		"If set, the defaulting webhook rewrites NamespaceLabel label keys to lowercase")
+	flag.StringVar(&defaultLabelAnnotations, "default-label-annotations", "",
+		"Comma-separated Namespace annotations copied into NamespaceLabels as default labels, "+
+			"as annotation or annotation=label (e.g. owner,team=dana.io/team)")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "2219c4e7.dana.io",
+		"The leader election lease name. Instances scoped with --watch-label need distinct IDs")
+	flag.StringVar(&watchLabel, "watch-label", "",
+		"Only act on NamespaceLabels carrying this label, as key=value (e.g. dana.io/instance=blue). "+
+			"If not set, all NamespaceLabels are handled")
+	flag.StringVar(&partitionName, "partition-name", "",
+		"Enable partitioned operation: the unique name of the namespace partition owned by this instance")
+	flag.StringVar(&partitionNamespace, "partition-namespace", "default",
+		"The namespace holding the partition coordination ConfigMaps of all instances")
+	flag.StringVar(&partitionSelector, "partition-selector", "",
+		"Label selector of the namespaces in this instance's partition")
+	flag.StringVar(&partitionHashRange, "partition-hash-range", "",
+		"Inclusive range of namespace name hash buckets (0-255) in this instance's partition, e.g. 0-127")
+	flag.StringVar(&ignoreDriftKeys, "ignore-drift-keys", "",
+		"Comma-separated label keys whose changes by external managers (e.g. Argo CD) are recorded as drift "+
+			"instead of reverted")
+	flag.StringVar(&ignoreDriftNamespaces, "ignore-drift-namespaces", "",
+		"Comma-separated namespaces whose label changes by external managers are recorded as drift instead of reverted")
+	flag.BoolVar(&strictMode, "strict-mode", false,
+		"If set, reconciliation fails when a namespace carries labels under --managed-label-prefix "+
+			"that no NamespaceLabel declares")
+	flag.StringVar(&managedLabelPrefix, "managed-label-prefix", "",
+		"The label prefix reserved for labels declared through NamespaceLabels, checked in strict mode")
+	flag.DurationVar(&staleThreshold, "stale-threshold", 10*time.Minute,
+		"How long a NamespaceLabel may go without its labels being applied before it is marked Stale. "+
+			"Set to 0 to disable")
+	flag.DurationVar(&resyncPeriod, "resync-period", 0,
+		"How often the labels of every NamespaceLabel and ClusterNamespaceLabel are re-applied even when no watch "+
+			"event fired, healing missed events and out-of-band edits. NamespaceLabels may override it with "+
+			"spec.resyncInterval. Set to 0 to disable")
+	flag.DurationVar(&reportInterval, "report-interval", time.Minute,
+		"How often the status of every NamespaceLabel is aggregated into the '"+controller.ReportName+
+			"' NamespaceLabelReport. Set to 0 to disable")
+	flag.DurationVar(&orphanScanInterval, "orphan-scan-interval", 0,
+		"How often Namespaces are scanned for labels whose owning NamespaceLabel no longer exists, which are "+
+			"then removed. Set to 0 to disable")
+	flag.IntVar(&reportPageSize, "report-page-size", 500,
+		"The number of Namespaces listed per page of the NamespaceLabelReport")
+	flag.IntVar(&reportMaxPages, "report-max-pages", 10,
+		"The maximum number of NamespaceLabelReport pages; Namespaces beyond them are only counted")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The number of NamespaceLabels, and separately ClusterNamespaceLabels, reconciled in parallel")
+	flag.DurationVar(&backoffBaseDelay, "backoff-base-delay", 5*time.Millisecond,
+		"The delay before the first retry of a failed reconciliation, doubled on every further failure")
+	flag.DurationVar(&backoffMaxDelay, "backoff-max-delay", 1000*time.Second,
+		"The longest delay between retries of a failed reconciliation")
+	flag.Float64Var(&namespaceRequeuesPerSecond, "namespace-requeues-per-second", 0,
+		"The requeues per second allowed for the NamespaceLabels of one namespace, so a failing namespace "+
+			"cannot crowd out the others. Set to 0 to disable")
+	flag.IntVar(&namespaceRequeueBurst, "namespace-requeue-burst", 10,
+		"The number of requeues of one namespace allowed in a burst above --namespace-requeues-per-second")
+	flag.StringVar(&targetNamespaceTemplate, "target-namespace-template", "",
+		"Virtual-cluster compatibility: a Go template mapping a NamespaceLabel's namespace to the host Namespace "+
+			"that receives its labels, e.g. '{{ .Namespace }}-x-tenants-x-vcluster'. If not set, the "+
+			"NamespaceLabel's own namespace is labeled")
+	flag.StringVar(&adminNamespaces, "admin-namespaces", "",
+		"Comma-separated namespaces whose NamespaceLabels may label other namespaces through targetNamespaces")
+	flag.BoolVar(&namespaceEvents, "namespace-events", false,
+		"If set, an Event is recorded on the Namespace for every label added, changed or removed")
+	flag.Float64Var(&namespaceWritesPerSecond, "namespace-writes-per-second", 0,
+		"The budget of Namespace writes per second of this instance (per partition when partitioned). "+
+			"Reconciliations over budget are requeued. Set to 0 to disable")
+	flag.IntVar(&namespaceWriteBurst, "namespace-write-burst", 10,
+		"The number of Namespace writes allowed in a burst above --namespace-writes-per-second")
+	flag.StringVar(&controllerServiceAccount, "controller-service-account", "controller-manager",
+		"The service account the controller runs as, in the namespace set by POD_NAMESPACE. Only it may "+
+			"remove or change labels owned by a NamespaceLabel")
+	flag.BoolVar(&warnOnManagedLabelChanges, "warn-on-managed-label-changes", false,
+		"If set, Namespace updates removing or changing labels owned by a NamespaceLabel are admitted with a "+
+			"warning instead of rejected")
+	flag.StringVar(&protectedLabelPrefixes, "protected-label-prefixes", namespacelabel.ManagementLabelPrefix,
+		"Comma-separated label key prefixes NamespaceLabels may not set, e.g. kubernetes.io,company.com/billing")
+	flag.StringVar(&protectedLabelPatterns, "protected-label-patterns", "",
+		"Comma-separated regular expressions matching label keys NamespaceLabels may not set. "+
+			"Patterns containing commas can be set in --protected-labels-configmap")
+	flag.StringVar(&protectedLabelsConfigMap, "protected-labels-configmap", "",
+		"A ConfigMap, as namespace/name, whose 'prefixes' and 'patterns' keys list further protected label "+
+			"prefixes and regular expressions, one per line. Changes are picked up without a restart")
+	flag.StringVar(&protectedAnnotationPrefixes, "protected-annotation-prefixes",
+		strings.Join(namespacelabel.DefaultProtectedAnnotationPrefixes, ","),
+		"Comma-separated annotation key prefixes NamespaceLabels may not set. The controller's own "+
+			namespacelabel.ControllerAnnotationPrefix+" annotations are always protected")
+	flag.BoolVar(&allowMultipleNamespaceLabels, "allow-multiple-namespacelabels", false,
+		"If set, a namespace may hold several NamespaceLabels. Keys declared by more than one are resolved by "+
+			"spec.priority, then by the most recently created NamespaceLabel")
+	flag.StringVar(&optInAnnotation, "opt-in-annotation", "",
+		"If set, e.g. to dana.io/labels-enabled, NamespaceLabels are only admitted and applied in namespaces "+
+			"cluster admins annotated with it set to \"true\"")
+	flag.BoolVar(&enableMultiCluster, "enable-multi-cluster", false,
+		"If set, NamespaceLabels and ClusterNamespaceLabels sync their labels to the member clusters listed in "+
+			"spec.clusters, reached through kubeconfigs stored in Secrets")
+	flag.DurationVar(&memberClusterTimeout, "member-cluster-timeout", 10*time.Second,
+		"How long requests to a member cluster may take before its sync fails")
+	flag.StringVar(&protectedNamespaces, "protected-namespaces",
+		strings.Join(namespacelabel.DefaultProtectedNamespaces, ","),
+		"Comma-separated namespace name patterns, e.g. openshift-*, the controller never labels. NamespaceLabels "+
+			"in or targeting them are rejected")
+	flag.StringVar(&protectedNamespaceSelector, "protected-namespace-selector", "",
+		"A label selector, e.g. dana.io/system=true, matching further namespaces the controller never labels")
+	flag.IntVar(&maxLabels, "max-labels", 0,
+		"The most labels a NamespaceLabel may declare; 0 is unlimited. LabelPolicies may set a lower maxLabels")
+	flag.IntVar(&maxManagedLabels, "max-managed-labels", 0,
+		"The most labels NamespaceLabels and ClusterNamespaceLabels may manage on a namespace in total; 0 is "+
+			"unlimited. LabelPolicies may set a lower maxManagedLabels")
+	flag.StringVar(&auditLog, "audit-log", "",
+		"Where to write a JSON audit record of every label change: a file path, or - for stdout. "+
+			"Empty disables the audit log")
+	flag.BoolVar(&auditEvents, "audit-events", false,
+		"If set, every label change is recorded as an Event on a NamespaceLabelAudit object in the namespace")
+	flag.StringVar(&notifyWebhookURLsFile, "notify-webhook-urls-file", "",
+		"A file, typically a mounted Secret key, listing the URLs a JSON notification is POSTed to whenever labels "+
+			"are applied, a NamespaceLabel is rejected or drift is corrected, separated by commas or newlines")
+	flag.StringVar(&notifySlackURLsFile, "notify-slack-urls-file", "",
+		"A file, typically a mounted Secret key, listing the Slack-compatible incoming webhook URLs notifications "+
+			"are posted to as messages, separated by commas or newlines")
+	flag.StringVar(&notifyEvents, "notify-events", "applied,rejected,drift-corrected",
+		"A comma-separated list of the events notifications are sent for: applied, rejected and drift-corrected")
+	flag.IntVar(&notifyRetries, "notify-retries", 3,
+		"The number of times a failed notification delivery is retried, with exponential backoff")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -75,6 +317,20 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if strictMode && managedLabelPrefix == "" {
+		setupLog.Error(nil, "--strict-mode requires --managed-label-prefix")
+		os.Exit(1)
+	}
+
+	var watchSelector labels.Selector
+	if watchLabel != "" {
+		var err error
+		if watchSelector, err = labels.Parse(watchLabel); err != nil {
+			setupLog.Error(err, "invalid --watch-label")
+			os.Exit(1)
+		}
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -92,6 +348,7 @@ func main() {
 	}
 
 	webhookServer := webhook.NewServer(webhook.Options{
+		CertDir: webhookCertDir,
 		TLSOpts: tlsOpts,
 	})
 
@@ -105,7 +362,7 @@ func main() {
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "2219c4e7.dana.io",
+		LeaderElectionID:       leaderElectionID,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -123,33 +380,416 @@ func main() {
 		os.Exit(1)
 	}
 
+	var mapNamespace namespacelabel.NamespaceMapper
+	if targetNamespaceTemplate != "" {
+		if mapNamespace, err = namespacelabel.NewTemplateNamespaceMapper(targetNamespaceTemplate); err != nil {
+			setupLog.Error(err, "invalid --target-namespace-template")
+			os.Exit(1)
+		}
+	}
+
+	var partitioner *controller.Partitioner
+	if partitionName != "" {
+		partition := controller.Partition{Name: partitionName}
+		if partitionSelector != "" {
+			if partition.Selector, err = labels.Parse(partitionSelector); err != nil {
+				setupLog.Error(err, "invalid --partition-selector")
+				os.Exit(1)
+			}
+		}
+		if partitionHashRange != "" {
+			if partition.HashRange, err = controller.ParseHashRange(partitionHashRange); err != nil {
+				setupLog.Error(err, "invalid --partition-hash-range")
+				os.Exit(1)
+			}
+		}
+		partitioner = &controller.Partitioner{
+			Client:          mgr.GetClient(),
+			Reader:          mgr.GetAPIReader(),
+			Namespace:       partitionNamespace,
+			Own:             partition,
+			RefreshInterval: 30 * time.Second,
+		}
+		if err := mgr.Add(partitioner); err != nil {
+			setupLog.Error(err, "unable to set up namespace partitioner")
+			os.Exit(1)
+		}
+	}
+
+	protectedLabels, err := namespacelabel.NewProtectedLabels(
+		splitList(protectedLabelPrefixes), splitList(protectedLabelPatterns))
+	if err != nil {
+		setupLog.Error(err, "invalid --protected-label-patterns")
+		os.Exit(1)
+	}
+	protectedAnnotations, err := namespacelabel.NewProtectedLabels(splitList(protectedAnnotationPrefixes), nil)
+	if err != nil {
+		setupLog.Error(err, "invalid --protected-annotation-prefixes")
+		os.Exit(1)
+	}
+	if protectedLabelsConfigMap != "" {
+		namespace, name, found := strings.Cut(protectedLabelsConfigMap, "/")
+		if !found || namespace == "" || name == "" {
+			setupLog.Error(nil, "--protected-labels-configmap must be namespace/name")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&controller.ProtectedLabelsLoader{
+			Reader:          mgr.GetAPIReader(),
+			ConfigMap:       types.NamespacedName{Namespace: namespace, Name: name},
+			Labels:          protectedLabels,
+			RefreshInterval: 30 * time.Second,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up protected labels loader")
+			os.Exit(1)
+		}
+	}
+
+	var namespaceSelector labels.Selector
+	if protectedNamespaceSelector != "" {
+		if namespaceSelector, err = labels.Parse(protectedNamespaceSelector); err != nil {
+			setupLog.Error(err, "invalid --protected-namespace-selector")
+			os.Exit(1)
+		}
+	}
+	protectedNamespaceSet, err := namespacelabel.NewProtectedNamespaces(splitList(protectedNamespaces), namespaceSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid --protected-namespaces")
+		os.Exit(1)
+	}
+
+	var memberClusters *controller.MemberClusters
+	if enableMultiCluster {
+		memberClusters = &controller.MemberClusters{
+			Reader:              mgr.GetAPIReader(),
+			Scheme:              scheme,
+			ProtectedNamespaces: protectedNamespaceSet,
+			Timeout:             memberClusterTimeout,
+		}
+	}
+
+	quota := validation.LabelQuota{MaxLabels: maxLabels, MaxManagedLabels: maxManagedLabels}
+
+	var auditor *controller.Auditor
+	if auditLog != "" || auditEvents {
+		auditor = &controller.Auditor{Client: mgr.GetClient()}
+		switch auditLog {
+		case "":
+		case "-":
+			auditor.Output = os.Stdout
+		default:
+			auditFile, err := os.OpenFile(auditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+			if err != nil {
+				setupLog.Error(err, "unable to open --audit-log")
+				os.Exit(1)
+			}
+			defer auditFile.Close()
+			auditor.Output = auditFile
+		}
+		if auditEvents {
+			auditor.Recorder = mgr.GetEventRecorderFor("namespacelabel-audit")
+		}
+	}
+
+	// The URLs carry the endpoints' credentials, so they are read from files rather than passed as flags
+	webhookURLs, err := readURLs(notifyWebhookURLsFile)
+	if err != nil {
+		setupLog.Error(err, "unable to read --notify-webhook-urls-file")
+		os.Exit(1)
+	}
+	slackURLs, err := readURLs(notifySlackURLsFile)
+	if err != nil {
+		setupLog.Error(err, "unable to read --notify-slack-urls-file")
+		os.Exit(1)
+	}
+	var notifier *controller.Notifier
+	if len(webhookURLs) > 0 || len(slackURLs) > 0 {
+		notifier = &controller.Notifier{
+			WebhookURLs: webhookURLs,
+			SlackURLs:   slackURLs,
+			Retries:     notifyRetries,
+		}
+		for _, event := range splitList(notifyEvents) {
+			if !slices.Contains(controller.NotificationEvents, controller.NotificationEvent(event)) {
+				setupLog.Error(nil, fmt.Sprintf("--notify-events: unknown event '%s'", event))
+				os.Exit(1)
+			}
+			notifier.Events = append(notifier.Events, controller.NotificationEvent(event))
+		}
+		if len(notifier.Events) == 0 {
+			setupLog.Error(nil, "--notify-events must list at least one event")
+			os.Exit(1)
+		}
+		if err := mgr.Add(notifier); err != nil {
+			setupLog.Error(err, "unable to set up notifications")
+			os.Exit(1)
+		}
+	}
+
+	if resyncPeriod != 0 && resyncPeriod < validation.MinResyncInterval {
+		setupLog.Error(nil, fmt.Sprintf("--resync-period must be 0 or at least %s", validation.MinResyncInterval))
+		os.Exit(1)
+	}
+
+	if maxConcurrentReconciles < 1 {
+		setupLog.Error(nil, "--max-concurrent-reconciles must be at least 1")
+		os.Exit(1)
+	}
+	if backoffBaseDelay <= 0 || backoffMaxDelay < backoffBaseDelay {
+		setupLog.Error(nil, "--backoff-base-delay must be positive and at most --backoff-max-delay")
+		os.Exit(1)
+	}
+	if namespaceRequeuesPerSecond > 0 && namespaceRequeueBurst < 1 {
+		setupLog.Error(nil, "--namespace-requeue-burst must be at least 1")
+		os.Exit(1)
+	}
+
+	var writeBudget *controller.WriteBudget
+	if namespaceWritesPerSecond > 0 {
+		if namespaceWriteBurst < 1 {
+			setupLog.Error(nil, "--namespace-write-burst must be at least 1")
+			os.Exit(1)
+		}
+		writeBudget = controller.NewWriteBudget(namespaceWritesPerSecond, namespaceWriteBurst)
+	}
+
+	// The endpoints on the metrics server authenticate callers with bearer tokens, which must not travel over
+	// plain HTTP
+	var resyncTrigger *controller.ResyncTrigger
+	if secureMetrics {
+		resyncTrigger = controller.NewResyncTrigger(mgr.GetClient())
+		if err := mgr.AddMetricsServerExtraHandler(controller.ResyncPath,
+			httpauth.Filter(mgr.GetClient(), resyncTrigger)); err != nil {
+			setupLog.Error(err, "unable to set up resync endpoint")
+			os.Exit(1)
+		}
+	} else {
+		setupLog.Info("resync endpoint disabled, it requires --metrics-secure", "path", controller.ResyncPath)
+	}
+
+	if secureMetrics {
+		snapshotExporter := &controller.SnapshotExporter{Client: mgr.GetClient(), MapNamespace: mapNamespace}
+		if err := mgr.AddMetricsServerExtraHandler(controller.SnapshotPath,
+			httpauth.Filter(mgr.GetClient(), snapshotExporter)); err != nil {
+			setupLog.Error(err, "unable to set up snapshot endpoint")
+			os.Exit(1)
+		}
+	} else {
+		setupLog.Info("snapshot endpoint disabled, it requires --metrics-secure", "path", controller.SnapshotPath)
+	}
+
+	if reportInterval > 0 {
+		if reportPageSize < 1 || reportMaxPages < 1 {
+			setupLog.Error(nil, "--report-page-size and --report-max-pages must be at least 1")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&controller.ReportWriter{
+			Client:       mgr.GetClient(),
+			MapNamespace: mapNamespace,
+			PageSize:     reportPageSize,
+			MaxPages:     reportMaxPages,
+			Interval:     reportInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up NamespaceLabel report")
+			os.Exit(1)
+		}
+	}
+
+	if orphanScanInterval > 0 {
+		if err := mgr.Add(&controller.OrphanScanner{
+			Client:   mgr.GetClient(),
+			Interval: orphanScanInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up orphaned label scan")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&controller.NamespaceLabelReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		WatchSelector:           watchSelector,
+		Partitioner:             partitioner,
+		IgnoreDriftKeys:         splitList(ignoreDriftKeys),
+		IgnoreDriftNamespaces:   splitList(ignoreDriftNamespaces),
+		StrictMode:              strictMode,
+		ManagedLabelPrefix:      managedLabelPrefix,
+		StaleThreshold:          staleThreshold,
+		MapNamespace:            mapNamespace,
+		AdminNamespaces:         splitList(adminNamespaces),
+		ProtectedLabels:         protectedLabels,
+		ProtectedAnnotations:    protectedAnnotations,
+		ProtectedNamespaces:     protectedNamespaceSet,
+		OptInAnnotation:         optInAnnotation,
+		Quota:                   quota,
+		AllowMultiple:           allowMultipleNamespaceLabels,
+		Recorder:                mgr.GetEventRecorderFor("namespacelabel-controller"),
+		NamespaceEvents:         namespaceEvents,
+		ResyncTrigger:           resyncTrigger,
+		WriteBudget:             writeBudget,
+		Auditor:                 auditor,
+		Notifier:                notifier,
+		ResyncPeriod:            resyncPeriod,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		RateLimiter: controller.NewRateLimiter(backoffBaseDelay, backoffMaxDelay, namespaceRequeuesPerSecond,
+			namespaceRequeueBurst),
+		MemberClusters: memberClusters,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "NamespaceLabel")
 		os.Exit(1)
 	}
+	if err = (&controller.ClusterNamespaceLabelReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		ProtectedLabels:         protectedLabels,
+		ProtectedNamespaces:     protectedNamespaceSet,
+		Recorder:                mgr.GetEventRecorderFor("clusternamespacelabel-controller"),
+		NamespaceEvents:         namespaceEvents,
+		WriteBudget:             writeBudget,
+		Auditor:                 auditor,
+		Notifier:                notifier,
+		ResyncPeriod:            resyncPeriod,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		RateLimiter:             controller.NewRateLimiter(backoffBaseDelay, backoffMaxDelay, 0, 0),
+		MemberClusters:          memberClusters,
+		Partitioner:             partitioner,
+		Quota:                   quota,
+		MapNamespace:            mapNamespace,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterNamespaceLabel")
+		os.Exit(1)
+	}
 
 	// +kubebuilder:scaffold:builder
 
-	if err := controller.SetupWebhookWithManager(mgr); err != nil {
+	defaultLabelSet, err := labels.ConvertSelectorToLabelsMap(defaultLabels)
+	if err != nil {
+		setupLog.Error(err, "invalid --default-labels")
+		os.Exit(1)
+	}
+
+	webhookOptions := labelwebhook.Options{
+		BreakGlassGroups: splitList(breakGlassGroups),
+		BypassPrincipals: &danav1alpha1.PrincipalList{
+			Users:           splitList(bypassUsers),
+			Groups:          splitList(bypassGroups),
+			ServiceAccounts: splitList(bypassServiceAccounts),
+		},
+		DefaultLabels:           defaultLabelSet,
+		DefaultGroupLabels:      parseKeyMapping(defaultGroupLabels),
+		LowercaseLabelKeys:      lowercaseLabelKeys,
+		DefaultLabelAnnotations: parseKeyMapping(defaultLabelAnnotations),
+		WatchSelector:           watchSelector,
+		MapNamespace:            mapNamespace,
+		AdminNamespaces:         splitList(adminNamespaces),
+		ProtectedLabels:         protectedLabels,
+		ProtectedAnnotations:    protectedAnnotations,
+		ProtectedNamespaces:     protectedNamespaceSet,
+		OptInAnnotation:         optInAnnotation,
+		Quota:                   quota,
+		AllowMultiple:           allowMultipleNamespaceLabels,
+		ControllerUsername: fmt.Sprintf("system:serviceaccount:%s:%s",
+			os.Getenv("POD_NAMESPACE"), controllerServiceAccount),
+		WarnOnManagedLabelChanges: warnOnManagedLabelChanges,
+	}
+	if err := labelwebhook.SetupWithManager(mgr, webhookOptions); err != nil {
 		setupLog.Error(err, "unable to set up webhook")
 		os.Exit(1)
 	}
 
+	if webhookCertRotateBefore >= webhookCertValidity {
+		setupLog.Error(nil, "--webhook-cert-rotate-before must be shorter than --webhook-cert-validity")
+		os.Exit(1)
+	}
+	certNamespace, certName, found := strings.Cut(webhookCertSecret, "/")
+	if !found || splitList(webhookDNSNames) == nil {
+		setupLog.Error(nil, "--webhook-cert-secret must be namespace/name and --webhook-dns-names must not be empty")
+		os.Exit(1)
+	}
+	ctx := ctrl.SetupSignalHandler()
+	if err := setupCertRotator(ctx, mgr, webhookCertManagement, &certs.Rotator{
+		Secret:             types.NamespacedName{Namespace: certNamespace, Name: certName},
+		CertDir:            webhookCertDir,
+		DNSNames:           splitList(webhookDNSNames),
+		ValidatingWebhooks: []string{"validating-webhook-configuration"},
+		MutatingWebhooks:   []string{"mutating-webhook-configuration"},
+		CRDs:               []string{"namespacelabels.dana.dana.io"},
+		Validity:           webhookCertValidity,
+		RotateBefore:       webhookCertRotateBefore,
+		Interval:           time.Hour,
+	}); err != nil {
+		setupLog.Error(err, "unable to set up webhook certificates")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
+	if err := mgr.AddHealthzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+		setupLog.Error(err, "unable to set up webhook health check")
+		os.Exit(1)
+	}
 	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	// Admission traffic is only routed to a pod serving TLS that can read NamespaceLabels from the API server
+	if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+		setupLog.Error(err, "unable to set up webhook ready check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("namespacelabels",
+		controller.NamespaceLabelListCheck(mgr.GetAPIReader())); err != nil {
+		setupLog.Error(err, "unable to set up NamespaceLabel ready check")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// splitList parses a comma-separated list, ignoring empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// readURLs reads the URLs listed in a file, separated by commas or newlines; an empty path lists none
+func readURLs(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.FieldsFunc(string(data), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	}), nil
+}
+
+// parseKeyMapping parses a comma-separated list of "from" or "from=to" entries into a map,
+// where an entry without "=to" maps a key to itself
+func parseKeyMapping(value string) map[string]string {
+	mapping := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		from, to, found := strings.Cut(entry, "=")
+		if !found {
+			to = from
+		}
+		mapping[from] = to
+	}
+	return mapping
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/TalDebi/namespacelabel-assignment.git/internal/controller"
+)
+
+// runResync implements the resync verb: it asks a running manager to reconcile the NamespaceLabels of a
+// namespace or label selector immediately, authenticating with the kubeconfig's bearer token
+func runResync(args []string) int {
+	flags := flag.NewFlagSet("resync", flag.ExitOnError)
+	endpoint := flags.String("url", "", "The base URL of the manager's metrics server, e.g. https://localhost:8443")
+	namespace := flags.String("namespace", "", "The namespace whose NamespaceLabels are resynced")
+	selector := flags.String("selector", "", "A label selector of the Namespaces whose NamespaceLabels are resynced")
+	token := flags.String("token", "", "The bearer token to authenticate with. Defaults to the kubeconfig's token")
+	insecure := flags.Bool("insecure-skip-tls-verify", false, "If set, the manager's serving certificate is not verified")
+	_ = flags.Parse(args)
+
+	if *endpoint == "" || (*namespace == "") == (*selector == "") {
+		fmt.Fprintln(os.Stderr, "resync requires --url and exactly one of --namespace or --selector")
+		return 2
+	}
+
+	if *token == "" {
+		config, err := ctrl.GetConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to load kubeconfig: %v\n", err)
+			return 2
+		}
+		*token = config.BearerToken
+		if *token == "" && config.BearerTokenFile != "" {
+			raw, err := os.ReadFile(config.BearerTokenFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "unable to read token file: %v\n", err)
+				return 2
+			}
+			*token = strings.TrimSpace(string(raw))
+		}
+	}
+
+	query := url.Values{}
+	if *namespace != "" {
+		query.Set("namespace", *namespace)
+	} else {
+		query.Set("selector", *selector)
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(*endpoint, "/")+controller.ResyncPath+"?"+query.Encode(), nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --url: %v\n", err)
+		return 2
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+
+	httpClient := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: *insecure}, // nolint:gosec
+	}}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resync failed: %v\n", err)
+		return 2
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "resync failed: %s\n", resp.Status)
+		return 1
+	}
+	response := controller.ResyncResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to read response: %v\n", err)
+		return 2
+	}
+	fmt.Printf("triggered resync of %d NamespaceLabels\n", response.Triggered)
+
+	return 0
+}
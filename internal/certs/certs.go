@@ -0,0 +1,392 @@
+// Package certs manages the serving certificate of the operator's webhooks: it keeps a self-signed CA and a
+// serving certificate in a Secret, writes them to the directory the webhook server reads them from, injects
+// the CA bundle into the webhook configurations and rotates both before they expire. It can instead defer
+// issuance to cert-manager and only copy the certificate cert-manager writes to the Secret.
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations;mutatingwebhookconfigurations,verbs=get;update
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;update
+
+const (
+	// CACertKey is the Secret key of the CA certificate, as written by cert-manager too
+	CACertKey = "ca.crt"
+	// caKeyKey is the Secret key of the self-signed CA's private key
+	caKeyKey = "ca.key"
+	// previousCACertKey is the Secret key of the CA replaced by the last CA rotation, still trusted until it
+	// expires so serving certificates it signed keep working while every replica picks up the new one
+	previousCACertKey = "ca-previous.crt"
+
+	// CAValidityFactor is how many times longer than the serving certificate the CA is valid
+	CAValidityFactor = 10
+
+	// CertManagerGroup is the API group served by cert-manager
+	CertManagerGroup = "cert-manager.io"
+)
+
+var crdGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+
+// Rotator keeps the webhook serving certificate valid. Every replica runs one: it issues or rotates the
+// certificates in the Secret when needed, conflicting writes of other replicas being retried on the next
+// refresh, and writes the Secret's certificate to its own CertDir, which the webhook server watches.
+type Rotator struct {
+	Client client.Client
+	// Secret holds the CA and the serving certificate
+	Secret client.ObjectKey
+	// CertDir is the directory the webhook server reads tls.crt and tls.key from
+	CertDir string
+	// DNSNames are the names the serving certificate is valid for, e.g. the webhook Service's
+	DNSNames []string
+	// ValidatingWebhooks and MutatingWebhooks are the names of the webhook configurations the CA bundle is
+	// injected into
+	ValidatingWebhooks []string
+	MutatingWebhooks   []string
+	// CRDs are the names of the CustomResourceDefinitions whose conversion webhook the CA bundle is injected into
+	CRDs []string
+	// Validity is how long a serving certificate is valid
+	Validity time.Duration
+	// RotateBefore is how long before it expires a certificate is replaced
+	RotateBefore time.Duration
+	// Interval is how often the certificates are checked
+	Interval time.Duration
+	// CertManager defers issuance, rotation and CA injection to cert-manager: the Secret is only read
+	CertManager bool
+}
+
+// CertManagerInstalled reports whether the cluster serves the cert-manager API
+func CertManagerInstalled(d discovery.ServerGroupsInterface) (bool, error) {
+	groups, err := d.ServerGroups()
+	if err != nil {
+		return false, err
+	}
+	for _, group := range groups.Groups {
+		if group.Name == CertManagerGroup {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Start implements manager.Runnable
+func (r *Rotator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if err := r.Refresh(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to refresh webhook certificates")
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable; every replica serves webhooks from its own
+// certificate directory
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+// Refresh issues or rotates the certificates in the Secret if needed, injects the CA bundle and writes the
+// serving certificate to CertDir. It is called before the manager starts, so the webhook server finds a
+// certificate, and then every Interval.
+func (r *Rotator) Refresh(ctx context.Context) error {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, r.Secret, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if r.CertManager {
+		if !exists {
+			return fmt.Errorf("waiting for cert-manager to issue Secret '%s'", r.Secret)
+		}
+		return r.writeCertDir(secret.Data)
+	}
+
+	data, rotated, err := r.issue(secret.Data, time.Now())
+	if err != nil {
+		return err
+	}
+	switch {
+	case !exists:
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: r.Secret.Name, Namespace: r.Secret.Namespace},
+			Type:       corev1.SecretTypeTLS,
+			Data:       data,
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Issued webhook certificates", "secret", r.Secret)
+	case rotated:
+		secret.Data = data
+		if err := r.Client.Update(ctx, secret); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Rotated webhook certificates", "secret", r.Secret)
+	}
+
+	if err := r.injectCABundle(ctx, caBundle(data)); err != nil {
+		return err
+	}
+	return r.writeCertDir(data)
+}
+
+// issue returns the Secret data with a CA and a serving certificate valid for at least RotateBefore, and
+// whether anything was issued. A new CA is issued with a new serving certificate; the CA it replaces stays
+// in the bundle until it expires.
+func (r *Rotator) issue(data map[string][]byte, now time.Time) (map[string][]byte, bool, error) {
+	issued := make(map[string][]byte, len(data))
+	for key, value := range data {
+		issued[key] = value
+	}
+	rotated := false
+
+	ca, caKey, err := parseKeyPair(data[CACertKey], data[caKeyKey])
+	if err != nil || expiresWithin(ca, now, r.RotateBefore) {
+		if err == nil && now.Before(ca.NotAfter) {
+			issued[previousCACertKey] = data[CACertKey]
+		}
+		validity := CAValidityFactor * r.Validity
+		template := &x509.Certificate{
+			Subject:               pkix.Name{CommonName: "namespacelabel-webhook-ca"},
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		}
+		if issued[CACertKey], issued[caKeyKey], err = createCertificate(template, nil, nil, now, validity); err != nil {
+			return nil, false, err
+		}
+		if ca, caKey, err = parseKeyPair(issued[CACertKey], issued[caKeyKey]); err != nil {
+			return nil, false, err
+		}
+		rotated = true
+	}
+	if previous, err := parseCertificate(issued[previousCACertKey]); err == nil && !now.Before(previous.NotAfter) {
+		delete(issued, previousCACertKey)
+		rotated = true
+	}
+
+	cert, _, err := parseKeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey])
+	if rotated || err != nil || expiresWithin(cert, now, r.RotateBefore) || cert.CheckSignatureFrom(ca) != nil ||
+		!slices.Equal(cert.DNSNames, r.DNSNames) {
+		template := &x509.Certificate{
+			Subject:     pkix.Name{CommonName: r.DNSNames[0]},
+			DNSNames:    r.DNSNames,
+			KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		issued[corev1.TLSCertKey], issued[corev1.TLSPrivateKeyKey], err = createCertificate(template, ca, caKey, now,
+			r.Validity)
+		if err != nil {
+			return nil, false, err
+		}
+		rotated = true
+	}
+
+	return issued, rotated, nil
+}
+
+// injectCABundle sets the CA bundle of every configured webhook that does not have it yet. Missing webhook
+// configurations and CRDs are skipped.
+func (r *Rotator) injectCABundle(ctx context.Context, bundle []byte) error {
+	for _, name := range r.ValidatingWebhooks {
+		config := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, config); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		changed := false
+		for i := range config.Webhooks {
+			if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, bundle) {
+				config.Webhooks[i].ClientConfig.CABundle = bundle
+				changed = true
+			}
+		}
+		if changed {
+			if err := r.Client.Update(ctx, config); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range r.MutatingWebhooks {
+		config := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, config); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		changed := false
+		for i := range config.Webhooks {
+			if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, bundle) {
+				config.Webhooks[i].ClientConfig.CABundle = bundle
+				changed = true
+			}
+		}
+		if changed {
+			if err := r.Client.Update(ctx, config); err != nil {
+				return err
+			}
+		}
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(bundle)
+	for _, name := range r.CRDs {
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(crdGVK)
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if strategy, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "strategy"); strategy != "Webhook" {
+			continue
+		}
+		path := []string{"spec", "conversion", "webhook", "clientConfig", "caBundle"}
+		if current, _, _ := unstructured.NestedString(crd.Object, path...); current == encoded {
+			continue
+		}
+		if err := unstructured.SetNestedField(crd.Object, encoded, path...); err != nil {
+			return err
+		}
+		if err := r.Client.Update(ctx, crd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCertDir writes the serving certificate and key to CertDir when they changed, replacing each file
+// atomically so the webhook server never reads a partial one
+func (r *Rotator) writeCertDir(data map[string][]byte) error {
+	if _, err := tls.X509KeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey]); err != nil {
+		return fmt.Errorf("Secret '%s' holds no valid serving certificate: %w", r.Secret, err)
+	}
+	if err := os.MkdirAll(r.CertDir, 0o700); err != nil {
+		return err
+	}
+	for _, key := range []string{corev1.TLSPrivateKeyKey, corev1.TLSCertKey} {
+		path := filepath.Join(r.CertDir, key)
+		if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data[key]) {
+			continue
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, data[key], 0o600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// caBundle returns the PEM bundle of the trusted CAs: the current one and the one it replaced, if any
+func caBundle(data map[string][]byte) []byte {
+	bundle := slices.Clone(data[CACertKey])
+	return append(bundle, data[previousCACertKey]...)
+}
+
+// createCertificate issues a certificate from the template valid from now for validity, signed by the
+// parent, or self-signed when the parent is nil, and returns it and its new key PEM-encoded
+func createCertificate(template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey,
+	now time.Time, validity time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return nil, nil, err
+	}
+	// Backdated to tolerate clock skew between the API server and the manager
+	template.NotBefore = now.Add(-time.Hour)
+	template.NotAfter = now.Add(validity)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// parseCertificate parses the first PEM-encoded certificate
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM-encoded certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// parseKeyPair parses a PEM-encoded certificate and its ECDSA key
+func parseKeyPair(certPEM, keyPEM []byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return nil, nil, err
+	}
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return cert, ecdsaKey, nil
+}
+
+// expiresWithin reports whether a certificate is not valid for the given duration any more
+func expiresWithin(cert *x509.Certificate, now time.Time, window time.Duration) bool {
+	return !now.Add(window).Before(cert.NotAfter)
+}
@@ -0,0 +1,181 @@
+package certs
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Webhook certificates", func() {
+	var (
+		ctx       context.Context
+		k8sClient client.Client
+		rotator   *Rotator
+		certDir   string
+		secretKey = client.ObjectKey{Namespace: "system", Name: "webhook-server-cert"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		certDir, err = os.MkdirTemp("", "serving-certs")
+		Expect(err).NotTo(HaveOccurred())
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(crdGVK)
+		crd.SetName("namespacelabels.dana.dana.io")
+		Expect(unstructured.SetNestedField(crd.Object, "Webhook", "spec", "conversion", "strategy")).To(Succeed())
+
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "validating-webhook-configuration"},
+				Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "vnamespacelabel.kb.io"}},
+			},
+			&admissionregistrationv1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "mutating-webhook-configuration"},
+				Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "mnamespacelabel.kb.io"}},
+			},
+			crd,
+		).Build()
+
+		rotator = &Rotator{
+			Client:             k8sClient,
+			Secret:             secretKey,
+			CertDir:            certDir,
+			DNSNames:           []string{"webhook-service.system.svc"},
+			ValidatingWebhooks: []string{"validating-webhook-configuration"},
+			MutatingWebhooks:   []string{"mutating-webhook-configuration", "missing"},
+			CRDs:               []string{"namespacelabels.dana.dana.io"},
+			Validity:           90 * 24 * time.Hour,
+			RotateBefore:       30 * 24 * time.Hour,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(certDir)).To(Succeed())
+	})
+
+	getSecret := func() *corev1.Secret {
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, secretKey, secret)).To(Succeed())
+		return secret
+	}
+
+	It("should issue a certificate, serve it and inject the CA bundle", func() {
+		Expect(rotator.Refresh(ctx)).To(Succeed())
+
+		secret := getSecret()
+		cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.DNSNames).To(Equal(rotator.DNSNames))
+		ca, err := parseCertificate(secret.Data[CACertKey])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.CheckSignatureFrom(ca)).To(Succeed())
+		Expect(ca.NotAfter).To(BeTemporally(">", cert.NotAfter))
+
+		served, err := os.ReadFile(filepath.Join(rotator.CertDir, corev1.TLSCertKey))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(served).To(Equal(secret.Data[corev1.TLSCertKey]))
+
+		validating := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "validating-webhook-configuration"}, validating)).To(Succeed())
+		Expect(validating.Webhooks[0].ClientConfig.CABundle).To(Equal(secret.Data[CACertKey]))
+		mutating := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "mutating-webhook-configuration"}, mutating)).To(Succeed())
+		Expect(mutating.Webhooks[0].ClientConfig.CABundle).To(Equal(secret.Data[CACertKey]))
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(crdGVK)
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "namespacelabels.dana.dana.io"}, crd)).To(Succeed())
+		injected, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "webhook", "clientConfig", "caBundle")
+		Expect(injected).To(Equal(base64.StdEncoding.EncodeToString(secret.Data[CACertKey])))
+
+		By("keeping certificates that are still valid")
+		Expect(rotator.Refresh(ctx)).To(Succeed())
+		Expect(getSecret().ResourceVersion).To(Equal(secret.ResourceVersion))
+	})
+
+	It("should rotate the serving certificate before it expires", func() {
+		Expect(rotator.Refresh(ctx)).To(Succeed())
+		before := getSecret()
+
+		data, rotated, err := rotator.issue(before.Data, time.Now().Add(70*24*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotated).To(BeTrue())
+		Expect(data[CACertKey]).To(Equal(before.Data[CACertKey]))
+		Expect(data[corev1.TLSCertKey]).NotTo(Equal(before.Data[corev1.TLSCertKey]))
+		Expect(data).NotTo(HaveKey(previousCACertKey))
+	})
+
+	It("should keep trusting the previous CA after rotating it", func() {
+		Expect(rotator.Refresh(ctx)).To(Succeed())
+		before := getSecret()
+
+		data, rotated, err := rotator.issue(before.Data, time.Now().Add(880*24*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotated).To(BeTrue())
+		Expect(data[CACertKey]).NotTo(Equal(before.Data[CACertKey]))
+		Expect(data[previousCACertKey]).To(Equal(before.Data[CACertKey]))
+		Expect(caBundle(data)).To(Equal(append(append([]byte{}, data[CACertKey]...), before.Data[CACertKey]...)))
+
+		cert, err := parseCertificate(data[corev1.TLSCertKey])
+		Expect(err).NotTo(HaveOccurred())
+		ca, err := parseCertificate(data[CACertKey])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.CheckSignatureFrom(ca)).To(Succeed())
+
+		By("dropping the previous CA once it expired")
+		data, _, err = rotator.issue(data, time.Now().Add(901*24*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).NotTo(HaveKey(previousCACertKey))
+	})
+
+	It("should only serve the certificate issued by cert-manager", func() {
+		rotator.CertManager = true
+		Expect(rotator.Refresh(ctx)).To(MatchError(ContainSubstring("waiting for cert-manager")))
+
+		data, _, err := rotator.issue(nil, time.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: secretKey.Namespace, Name: secretKey.Name},
+			Data: map[string][]byte{
+				corev1.TLSCertKey: data[corev1.TLSCertKey], corev1.TLSPrivateKeyKey: data[corev1.TLSPrivateKeyKey],
+				CACertKey: data[CACertKey],
+			},
+		})).To(Succeed())
+		Expect(rotator.Refresh(ctx)).To(Succeed())
+
+		served, err := os.ReadFile(filepath.Join(rotator.CertDir, corev1.TLSPrivateKeyKey))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(served).To(Equal(data[corev1.TLSPrivateKeyKey]))
+		validating := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "validating-webhook-configuration"}, validating)).To(Succeed())
+		Expect(validating.Webhooks[0].ClientConfig.CABundle).To(BeEmpty())
+	})
+
+	It("should detect cert-manager", func() {
+		discovery := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		Expect(CertManagerInstalled(discovery)).To(BeFalse())
+
+		discovery.Resources = []*metav1.APIResourceList{{GroupVersion: CertManagerGroup + "/v1"}}
+		Expect(CertManagerInstalled(discovery)).To(BeTrue())
+	})
+})
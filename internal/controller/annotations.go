@@ -0,0 +1,84 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// annotationClaims resolves the annotations the siblings currently hold on the Namespace, represented like
+// their labels by the annotations they last applied
+func annotationClaims(siblings []danav1alpha1.NamespaceLabel) map[string]labelClaim {
+	claims := make(map[string]labelClaim)
+	for i := range siblings {
+		sibling := &siblings[i]
+		for key, value := range sibling.Status.AppliedAnnotations {
+			if claim, claimed := claims[key]; claimed && !outranks(sibling, claim.owner) {
+				continue
+			}
+			claims[key] = labelClaim{value: value, owner: sibling}
+		}
+	}
+	return claims
+}
+
+// namespaceAnnotations resolves the annotations a NamespaceLabel applies to a Namespace. Annotations follow the
+// lifecycle of labels: owned are the declared annotations no higher-ranked sibling holds, apply adds the ones
+// the siblings hold, and remove lists the keys the NamespaceLabel applied before and no one declares anymore.
+func namespaceAnnotations(namespaceLabel *danav1alpha1.NamespaceLabel, ns *corev1.Namespace,
+	siblings []danav1alpha1.NamespaceLabel) (owned, apply map[string]string, remove map[string]struct{}) {
+	claims := annotationClaims(siblings)
+	owned = make(map[string]string, len(namespaceLabel.Spec.Annotations))
+	for key, value := range namespaceLabel.Spec.Annotations {
+		if claim, claimed := claims[key]; claimed {
+			if outranks(claim.owner, namespaceLabel) {
+				continue
+			}
+			delete(claims, key)
+		}
+		owned[key] = value
+	}
+
+	apply = make(map[string]string, len(owned)+len(claims))
+	for key, value := range owned {
+		apply[key] = value
+	}
+	for key, claim := range claims {
+		apply[key] = claim.value
+	}
+
+	remove = make(map[string]struct{})
+	for key := range namespaceLabel.Status.AppliedAnnotations {
+		_, exists := ns.Annotations[key]
+		if _, kept := apply[key]; exists && !kept {
+			remove[key] = struct{}{}
+		}
+	}
+	return owned, apply, remove
+}
+
+// setFanOutAnnotations sets the declared annotations on a fan-out target Namespace and removes the ones applied
+// before and no longer declared, reporting whether any changed
+func setFanOutAnnotations(ns *corev1.Namespace, declared, applied map[string]string) bool {
+	changed := false
+	for key := range applied {
+		if _, kept := declared[key]; kept {
+			continue
+		}
+		if _, exists := ns.Annotations[key]; exists {
+			delete(ns.Annotations, key)
+			changed = true
+		}
+	}
+	for key, value := range declared {
+		if current, exists := ns.Annotations[key]; exists && current == value {
+			continue
+		}
+		if ns.Annotations == nil {
+			ns.Annotations = make(map[string]string)
+		}
+		ns.Annotations[key] = value
+		changed = true
+	}
+	return changed
+}
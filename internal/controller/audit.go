@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// auditObjectName is the name of the NamespaceLabelAudit object audit Events are recorded on in each namespace
+const auditObjectName = "namespacelabel-audit"
+
+// +kubebuilder:rbac:groups=dana.dana.io,resources=namespacelabelaudits,verbs=get;create
+
+// Auditor writes a structured audit record of every label the controllers add, change or remove on a Namespace:
+// who asked for it, when, through which object, and the old and new values
+type Auditor struct {
+	// Client creates the NamespaceLabelAudit objects Events are recorded on
+	Client client.Client
+	// Output receives each record as a JSON line; nil writes no records
+	Output io.Writer
+	// Recorder records each change as an Event on the NamespaceLabelAudit object of the Namespace; nil
+	// records none
+	Recorder record.EventRecorder
+
+	mu sync.Mutex
+}
+
+// auditRecord is a label change as written to the audit log
+type auditRecord struct {
+	Time      time.Time `json:"time"`
+	Namespace string    `json:"namespace"`
+	// Owner is the NamespaceLabel or ClusterNamespaceLabel the change was made for
+	Owner string `json:"owner"`
+	// User last created or updated the owner, if known
+	User     string  `json:"user,omitempty"`
+	Action   string  `json:"action"`
+	Key      string  `json:"key"`
+	OldValue *string `json:"oldValue,omitempty"`
+	NewValue *string `json:"newValue,omitempty"`
+}
+
+// record audits the label changes made on a Namespace for an owner last modified by user
+func (a *Auditor) record(ctx context.Context, ns *corev1.Namespace, owner, user string, changes labelChanges) {
+	if a == nil || len(changes) == 0 {
+		return
+	}
+	log := log.FromContext(ctx)
+	now := time.Now().UTC()
+
+	if a.Output != nil {
+		a.mu.Lock()
+		encoder := json.NewEncoder(a.Output)
+		for _, change := range changes {
+			if err := encoder.Encode(newAuditRecord(now, ns.Name, owner, user, change)); err != nil {
+				log.Error(err, "Failed to write audit record", "Namespace", ns.Name, "Key", change.key)
+			}
+		}
+		a.mu.Unlock()
+	}
+
+	if a.Recorder != nil {
+		audit, err := a.auditObject(ctx, ns.Name)
+		if err != nil {
+			log.Error(err, "Failed to get NamespaceLabelAudit", "Namespace", ns.Name)
+			return
+		}
+		for _, change := range changes {
+			a.Recorder.Event(audit, corev1.EventTypeNormal, change.reason, auditMessage(owner, user, change))
+		}
+	}
+}
+
+func newAuditRecord(now time.Time, namespace, owner, user string, change labelChange) auditRecord {
+	rec := auditRecord{
+		Time: now, Namespace: namespace, Owner: owner, User: user, Action: change.reason, Key: change.key,
+	}
+	if change.reason != "LabelAdded" {
+		rec.OldValue = &change.previous
+	}
+	if change.reason != "LabelRemoved" {
+		rec.NewValue = &change.value
+	}
+	return rec
+}
+
+// auditMessage describes a label change in an audit Event
+func auditMessage(owner, user string, change labelChange) string {
+	var message string
+	switch change.reason {
+	case "LabelAdded":
+		message = fmt.Sprintf("label '%s' set to '%s' by %s", change.key, change.value, owner)
+	case "LabelUpdated":
+		message = fmt.Sprintf("label '%s' changed from '%s' to '%s' by %s", change.key, change.previous,
+			change.value, owner)
+	default:
+		message = fmt.Sprintf("label '%s' with value '%s' removed by %s", change.key, change.previous, owner)
+	}
+	if user != "" {
+		message += " on behalf of " + user
+	}
+	return message
+}
+
+// auditObject returns the NamespaceLabelAudit object of a namespace, creating it if missing
+func (a *Auditor) auditObject(ctx context.Context, namespace string) (*danav1alpha1.NamespaceLabelAudit, error) {
+	audit := &danav1alpha1.NamespaceLabelAudit{}
+	key := types.NamespacedName{Namespace: namespace, Name: auditObjectName}
+	err := a.Client.Get(ctx, key, audit)
+	if !apierrors.IsNotFound(err) {
+		return audit, err
+	}
+
+	audit = &danav1alpha1.NamespaceLabelAudit{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: auditObjectName}}
+	if err := a.Client.Create(ctx, audit); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		return audit, a.Client.Get(ctx, key, audit)
+	}
+	return audit, nil
+}
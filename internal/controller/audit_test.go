@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+var _ = Describe("Label Audit", func() {
+	namespacedName := types.NamespacedName{Name: "test-resource", Namespace: "default"}
+
+	var (
+		output   *bytes.Buffer
+		recorder *record.FakeRecorder
+		auditor  *Auditor
+	)
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		createNamespace("default")
+		output = &bytes.Buffer{}
+		recorder = record.NewFakeRecorder(10)
+		auditor = &Auditor{Client: k8sClient, Output: output, Recorder: recorder}
+	})
+
+	records := func() []auditRecord {
+		var parsed []auditRecord
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			var rec auditRecord
+			Expect(json.Unmarshal(scanner.Bytes(), &rec)).To(Succeed())
+			parsed = append(parsed, rec)
+		}
+		return parsed
+	}
+
+	It("should record who changed which label from which value to which", func() {
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        namespacedName.Name,
+				Namespace:   namespacedName.Namespace,
+				Annotations: map[string]string{namespacelabel.ModifiedByAnnotation: "alice"},
+			},
+			Spec: danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a"}},
+		}
+		Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+		controllerReconciler := &NamespaceLabelReconciler{
+			Client:  k8sClient,
+			Scheme:  scheme,
+			Log:     zap.New(zap.UseDevMode(true)),
+			Auditor: auditor,
+		}
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		added := records()
+		Expect(added).To(HaveLen(1))
+		Expect(added[0].Namespace).To(Equal("default"))
+		Expect(added[0].Owner).To(Equal("NamespaceLabel default/test-resource"))
+		Expect(added[0].User).To(Equal("alice"))
+		Expect(added[0].Action).To(Equal("LabelAdded"))
+		Expect(added[0].OldValue).To(BeNil())
+		Expect(*added[0].NewValue).To(Equal("a"))
+		Expect(recorder.Events).To(Receive(Equal(
+			"Normal LabelAdded label 'team' set to 'a' by NamespaceLabel default/test-resource on behalf of alice")))
+
+		audit := &danav1alpha1.NamespaceLabelAudit{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: auditObjectName}, audit)).To(Succeed())
+
+		By("changing the label")
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		namespaceLabel.Spec.Labels = map[string]string{"team": "b"}
+		Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := records()
+		Expect(updated).To(HaveLen(1))
+		Expect(updated[0].Action).To(Equal("LabelUpdated"))
+		Expect(*updated[0].OldValue).To(Equal("a"))
+		Expect(*updated[0].NewValue).To(Equal("b"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("changed from 'a' to 'b'")))
+	})
+})
@@ -0,0 +1,540 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
+)
+
+// ClusterNamespaceLabelReconciler reconciles a ClusterNamespaceLabel object
+type ClusterNamespaceLabelReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// ProtectedLabels are the label keys ClusterNamespaceLabels may not set; nil protects the Kubernetes
+	// management labels
+	ProtectedLabels *namespacelabel.ProtectedLabels
+	// ProtectedNamespaces are the Namespaces never labeled; nil protects namespacelabel.DefaultProtectedNamespaces
+	ProtectedNamespaces *namespacelabel.ProtectedNamespaces
+	// Recorder records Events on Namespaces
+	Recorder record.EventRecorder
+	// NamespaceEvents records an Event on the Namespace for every managed label change
+	NamespaceEvents bool
+	// WriteBudget limits the rate of Namespace writes; nil writes without limit
+	WriteBudget *WriteBudget
+	// Auditor writes an audit record of every label change; nil audits nothing
+	Auditor *Auditor
+	// Notifier sends a notification of every label change; nil sends none
+	Notifier *Notifier
+	// ResyncPeriod re-applies the labels of ClusterNamespaceLabels this often, healing changes no watch event
+	// reported; zero re-applies them only on watch events
+	ResyncPeriod time.Duration
+	// MaxConcurrentReconciles is the number of ClusterNamespaceLabels reconciled in parallel; defaults to 1
+	MaxConcurrentReconciles int
+	// RateLimiter spaces out the requeues of failed reconciliations; nil uses the controller-runtime default
+	RateLimiter workqueue.RateLimiter
+	// MemberClusters syncs the labels to the member clusters listed in spec.clusters; nil disables
+	// multi-cluster mode
+	MemberClusters *MemberClusters
+	// Partitioner restricts the controller to the Namespaces in this instance's partition; nil manages all.
+	// Every instance reconciles each ClusterNamespaceLabel and labels only the selected Namespaces it owns.
+	Partitioner *Partitioner
+	// PolicySource provides the LabelPolicies checked before labels are applied; nil reads the LabelPolicy
+	// objects in the cluster
+	PolicySource validation.PolicySource
+	// Quota caps the labels managed on a Namespace, on top of the LabelPolicy limits. MaxLabels limits single
+	// NamespaceLabels and does not apply.
+	Quota validation.LabelQuota
+	// MapNamespace maps a NamespaceLabel's namespace to the Namespace it labels, for counting the labels it
+	// manages; nil maps it to itself
+	MapNamespace namespacelabel.NamespaceMapper
+}
+
+// +kubebuilder:rbac:groups=dana.dana.io,resources=clusternamespacelabels,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dana.dana.io,resources=clusternamespacelabels/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=dana.dana.io,resources=clusternamespacelabels/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=create;delete
+
+// Reconcile stamps the labels of a ClusterNamespaceLabel on every Namespace its selector selects and removes
+// them from Namespaces it no longer selects. Like a fan-out NamespaceLabel it only manages the keys it
+// declares.
+func (r *ClusterNamespaceLabelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+	start := time.Now()
+
+	clusterNamespaceLabel := &danav1alpha1.ClusterNamespaceLabel{}
+	if err := r.Get(ctx, req.NamespacedName, clusterNamespaceLabel); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Suspended ClusterNamespaceLabels neither apply nor remove labels, even when deleted
+	if clusterNamespaceLabel.Spec.Suspend {
+		if !clusterNamespaceLabel.DeletionTimestamp.IsZero() {
+			if controllerutil.ContainsFinalizer(clusterNamespaceLabel, finalizerName) {
+				controllerutil.RemoveFinalizer(clusterNamespaceLabel, finalizerName)
+				return ctrl.Result{}, r.Update(ctx, clusterNamespaceLabel)
+			}
+			return ctrl.Result{}, nil
+		}
+		message := "reconciliation is suspended; labels are neither applied nor removed"
+		meta.SetStatusCondition(&clusterNamespaceLabel.Status.Conditions, metav1.Condition{
+			Type: "Suspended", Status: metav1.ConditionTrue, ObservedGeneration: clusterNamespaceLabel.Generation,
+			Reason: "Suspended", Message: message,
+		})
+		r.updateStatus(ctx, clusterNamespaceLabel, metav1.ConditionFalse, "Suspended", message)
+		return ctrl.Result{}, nil
+	}
+	meta.RemoveStatusCondition(&clusterNamespaceLabel.Status.Conditions, "Suspended")
+
+	// Handle deletion
+	if clusterNamespaceLabel.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(clusterNamespaceLabel, finalizerName) {
+			controllerutil.AddFinalizer(clusterNamespaceLabel, finalizerName)
+			if err := r.Update(ctx, clusterNamespaceLabel); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if controllerutil.ContainsFinalizer(clusterNamespaceLabel, finalizerName) {
+			labeled := clusterNamespaceLabel.Status.LabeledNamespaces
+			if delay := r.WriteBudget.reserve(len(labeled)); delay > 0 {
+				return ctrl.Result{RequeueAfter: delay}, nil
+			}
+			if clusterNamespaceLabel.Spec.DeleteCreatedNamespaces {
+				if err := r.deleteCreatedNamespaces(ctx, clusterNamespaceLabel); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			pending, err := r.unlabelNamespaces(ctx, clusterNamespaceLabel, labeled)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			// Namespaces of other partitions are left to the instances owning them, which only see them if
+			// they are still listed
+			if len(pending) > 0 {
+				clusterNamespaceLabel.Status.LabeledNamespaces = pending
+				if err := r.Status().Update(ctx, clusterNamespaceLabel); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: r.Partitioner.RefreshInterval}, nil
+			}
+			if _, err := r.MemberClusters.Sync(ctx, clusterNamespaceLabelOwner(clusterNamespaceLabel), "", nil,
+				clusterNamespaceLabel.Status.Clusters, nil, nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			controllerutil.RemoveFinalizer(clusterNamespaceLabel, finalizerName)
+			if err := r.Update(ctx, clusterNamespaceLabel); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	err := validation.ValidateLabels(r.ProtectedLabels, clusterNamespaceLabel.Spec.Labels)
+	if err == nil {
+		err = validation.ValidateMemberClusters(clusterNamespaceLabel.Spec.Clusters, "")
+	}
+	if err != nil {
+		labelsRejected.WithLabelValues(sourceClusterNamespaceLabel, "ValidationFailed").
+			Add(float64(len(clusterNamespaceLabel.Spec.Labels)))
+		r.updateStatus(ctx, clusterNamespaceLabel, metav1.ConditionFalse, "ValidationFailed", err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaces); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var targets []*corev1.Namespace
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if !ns.DeletionTimestamp.IsZero() || r.ProtectedNamespaces.IsProtected(ns) {
+			continue
+		}
+		selected, err := namespacelabel.SelectsNamespace(clusterNamespaceLabel, ns)
+		if err != nil {
+			r.updateStatus(ctx, clusterNamespaceLabel, metav1.ConditionFalse, "ValidationFailed", err.Error())
+			return ctrl.Result{}, nil
+		}
+		if selected {
+			targets = append(targets, ns)
+		}
+	}
+
+	var missing []string
+	if clusterNamespaceLabel.Spec.CreateNamespace {
+		if missing, err = r.missingNamespaces(clusterNamespaceLabel, namespaces.Items); err != nil {
+			r.updateStatus(ctx, clusterNamespaceLabel, metav1.ConditionFalse, "ValidationFailed", err.Error())
+			return ctrl.Result{}, nil
+		}
+	}
+
+	var dropped []string
+	for _, name := range clusterNamespaceLabel.Status.LabeledNamespaces {
+		if !slices.ContainsFunc(targets, func(ns *corev1.Namespace) bool { return ns.Name == name }) &&
+			!slices.Contains(missing, name) {
+			dropped = append(dropped, name)
+		}
+	}
+
+	if delay := r.WriteBudget.reserve(len(targets) + len(dropped) + len(missing)); delay > 0 {
+		log.Info("Namespace write budget exhausted, requeueing", "after", delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	for _, name := range missing {
+		ns, err := r.createNamespace(ctx, clusterNamespaceLabel, name)
+		if err != nil {
+			r.updateStatus(ctx, clusterNamespaceLabel, metav1.ConditionFalse, "CreateError", err.Error())
+			return ctrl.Result{}, err
+		}
+		targets = append(targets, ns)
+	}
+
+	policySource := r.PolicySource
+	if policySource == nil {
+		policySource = &validation.ClusterPolicySource{Client: r.Client}
+	}
+
+	var labeled []string
+	var exceeded *validation.QuotaExceeded
+	now := time.Now()
+	for _, ns := range targets {
+		// Templated values are rendered and checked for each Namespace
+		rendered, err := namespacelabel.RenderLabels(clusterNamespaceLabel.Spec.Labels, ns, now)
+		if err == nil {
+			err = validation.ValidateLabels(r.ProtectedLabels, rendered)
+		}
+		if err == nil {
+			var policies []danav1alpha1.LabelPolicy
+			if policies, err = policySource.PoliciesFor(ctx, ns); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err = validation.ValidatePolicies(policies, rendered); err == nil {
+				err = validation.ValidateRules(policies, rendered)
+			}
+			if err == nil {
+				if err = r.validateQuota(ctx, clusterNamespaceLabel, ns, policies, rendered); err != nil &&
+					!errors.As(err, &exceeded) {
+					return ctrl.Result{}, err
+				}
+			}
+		}
+		if err != nil {
+			labelsRejected.WithLabelValues(sourceClusterNamespaceLabel, invalidReason(err)).
+				Add(float64(len(clusterNamespaceLabel.Spec.Labels)))
+			r.updateStatus(ctx, clusterNamespaceLabel, metav1.ConditionFalse, invalidReason(err),
+				fmt.Sprintf("namespace '%s': %v", ns.Name, err))
+			// Quotas depend on the labels other objects manage too, so exceeding one is retried
+			if exceeded != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+		if err := r.applyLabels(ctx, clusterNamespaceLabel, ns, rendered); err != nil {
+			r.updateStatus(ctx, clusterNamespaceLabel, metav1.ConditionFalse, "UpdateError", err.Error())
+			return ctrl.Result{}, err
+		}
+		labeled = append(labeled, ns.Name)
+	}
+
+	// Remove the labels from Namespaces the selector no longer selects; those of other partitions stay listed
+	// until the instances owning them have
+	pending, err := r.unlabelNamespaces(ctx, clusterNamespaceLabel, dropped)
+	if err != nil {
+		r.updateStatus(ctx, clusterNamespaceLabel, metav1.ConditionFalse, "UpdateError", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	namespacePatchLatency.WithLabelValues(sourceClusterNamespaceLabel).Observe(time.Since(start).Seconds())
+
+	sort.Strings(labeled)
+	applied := make(map[string]string, len(clusterNamespaceLabel.Spec.Labels))
+	for key, value := range clusterNamespaceLabel.Spec.Labels {
+		applied[key] = value
+	}
+	clusterNamespaceLabel.Status.AppliedLabels = applied
+	clusterNamespaceLabel.Status.LabeledNamespaces = append(slices.Clone(labeled), pending...)
+	sort.Strings(clusterNamespaceLabel.Status.LabeledNamespaces)
+
+	// Member clusters label the namesakes of the selected Namespaces, rendering templated values for each of
+	// their own Namespaces; a failing one is reported and retried without holding up the others
+	statuses, syncErr := r.MemberClusters.Sync(ctx, clusterNamespaceLabelOwner(clusterNamespaceLabel), "",
+		clusterNamespaceLabel.Spec.Clusters, clusterNamespaceLabel.Status.Clusters, labeled,
+		clusterNamespaceLabel.Spec.Labels)
+	clusterNamespaceLabel.Status.Clusters = statuses
+	setClustersSyncedCondition(&clusterNamespaceLabel.Status.Conditions, clusterNamespaceLabel.Generation, statuses)
+
+	r.updateStatus(ctx, clusterNamespaceLabel, metav1.ConditionTrue, "Success",
+		fmt.Sprintf("labels applied to %d namespaces", len(labeled)))
+
+	if syncErr != nil {
+		return ctrl.Result{}, syncErr
+	}
+	return ctrl.Result{RequeueAfter: jitterResync(r.ResyncPeriod)}, nil
+}
+
+// validateQuota checks the labels of a ClusterNamespaceLabel, rendered for a Namespace, against the quota of
+// labels managed on it
+func (r *ClusterNamespaceLabelReconciler) validateQuota(ctx context.Context,
+	clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel, ns *corev1.Namespace,
+	policies []danav1alpha1.LabelPolicy, rendered map[string]string) error {
+	quota := validation.EffectiveQuota(r.Quota, policies)
+	if quota.MaxManagedLabels == 0 {
+		return nil
+	}
+
+	managed, err := namespacelabel.ManagedLabelKeys(ctx, r.Client, r.MapNamespace, ns, clusterNamespaceLabel)
+	if err != nil {
+		return err
+	}
+	for key := range rendered {
+		managed[key] = struct{}{}
+	}
+	return quota.Validate(ns.Name, 0, len(managed))
+}
+
+// applyLabels sets the declared labels, rendered for a selected Namespace, and removes the keys the
+// ClusterNamespaceLabel applied previously but no longer declares. Namespaces outside this instance's partition
+// are left to the instance owning them.
+func (r *ClusterNamespaceLabelReconciler) applyLabels(ctx context.Context,
+	clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel, ns *corev1.Namespace, rendered map[string]string) error {
+	if owned, err := r.owns(ctx, ns); err != nil || !owned {
+		return err
+	}
+
+	patch := client.MergeFrom(ns.DeepCopy())
+	if ns.Labels == nil {
+		ns.Labels = make(map[string]string)
+	}
+
+	removed := 0
+	var changes labelChanges
+	for key := range clusterNamespaceLabel.Status.AppliedLabels {
+		if _, declared := clusterNamespaceLabel.Spec.Labels[key]; declared {
+			continue
+		}
+		if previous, exists := ns.Labels[key]; exists {
+			delete(ns.Labels, key)
+			removed++
+			changes.removed(key, previous)
+		}
+	}
+
+	added, updated := 0, 0
+	for key, value := range rendered {
+		current, exists := ns.Labels[key]
+		switch {
+		case !exists:
+			added++
+			changes.added(key, value)
+		case current != value:
+			updated++
+			changes.updated(key, current, value)
+		}
+		ns.Labels[key] = value
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err := r.Patch(ctx, ns, patch); err != nil {
+		return err
+	}
+
+	labelsAdded.WithLabelValues(sourceClusterNamespaceLabel).Add(float64(added))
+	labelsUpdated.WithLabelValues(sourceClusterNamespaceLabel).Add(float64(updated))
+	labelsRemoved.WithLabelValues(sourceClusterNamespaceLabel).Add(float64(removed))
+	r.recordLabelChanges(ctx, ns, clusterNamespaceLabel, changes)
+
+	return nil
+}
+
+// unlabelNamespaces removes the labels a ClusterNamespaceLabel applied from the given Namespaces. It returns
+// the Namespaces outside this instance's partition, which are left to the instance owning them.
+func (r *ClusterNamespaceLabelReconciler) unlabelNamespaces(ctx context.Context,
+	clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel, names []string) (pending []string, err error) {
+	for _, name := range names {
+		ns := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if r.ProtectedNamespaces.IsProtected(ns) {
+			continue
+		}
+		owned, err := r.owns(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+		if !owned {
+			pending = append(pending, name)
+			continue
+		}
+
+		patch := client.MergeFrom(ns.DeepCopy())
+		var changes labelChanges
+		for key := range clusterNamespaceLabel.Status.AppliedLabels {
+			if previous, exists := ns.Labels[key]; exists {
+				delete(ns.Labels, key)
+				changes.removed(key, previous)
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		if err := r.Patch(ctx, ns, patch); err != nil {
+			return nil, err
+		}
+		labelsRemoved.WithLabelValues(sourceClusterNamespaceLabel).Add(float64(len(changes)))
+		r.recordLabelChanges(ctx, ns, clusterNamespaceLabel, changes)
+	}
+
+	return pending, nil
+}
+
+// owns reports whether this instance manages the labels of a Namespace. Namespaces claimed by several
+// partitions are managed by none until the conflict is resolved.
+func (r *ClusterNamespaceLabelReconciler) owns(ctx context.Context, ns *corev1.Namespace) (bool, error) {
+	if r.Partitioner == nil {
+		return true, nil
+	}
+	owned, conflict, err := r.Partitioner.Owns(ns)
+	if conflict != "" {
+		log.FromContext(ctx).Info("Skipping Namespace claimed by several partitions", "namespace", ns.Name,
+			"conflict", conflict)
+	}
+	return owned, err
+}
+
+// recordLabelChanges audits the label changes made on a Namespace and, when enabled, emits an Event on the
+// Namespace for each
+func (r *ClusterNamespaceLabelReconciler) recordLabelChanges(ctx context.Context,
+	ns *corev1.Namespace, clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel, changes labelChanges) {
+	owner := "ClusterNamespaceLabel " + clusterNamespaceLabel.Name
+	user := clusterNamespaceLabel.Annotations[namespacelabel.ModifiedByAnnotation]
+	r.Auditor.record(ctx, ns, owner, user, changes)
+	r.Notifier.notifyChanges(NotifyApplied, ns.Name, owner, user, changes)
+	if r.NamespaceEvents {
+		recordLabelEvents(r.Recorder, ns, owner, changes)
+	}
+}
+
+// updateStatus sets the LabelsApplied condition and the Ready summary derived from it
+func (r *ClusterNamespaceLabelReconciler) updateStatus(ctx context.Context,
+	clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel, status metav1.ConditionStatus, reason, message string) {
+	generation := clusterNamespaceLabel.Generation
+	clusterNamespaceLabel.Status.ObservedGeneration = generation
+	for _, conditionType := range []string{"LabelsApplied", conditionReady} {
+		meta.SetStatusCondition(&clusterNamespaceLabel.Status.Conditions, metav1.Condition{
+			Type: conditionType, Status: status, ObservedGeneration: generation, Reason: reason, Message: message,
+		})
+	}
+
+	if err := r.Status().Update(ctx, clusterNamespaceLabel); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update ClusterNamespaceLabel status")
+	}
+}
+
+// clusterNamespaceLabels returns the labels that ClusterNamespaceLabels selecting a Namespace declare
+func clusterNamespaceLabels(ctx context.Context, c client.Client, ns *corev1.Namespace) (map[string]string, error) {
+	clusterNamespaceLabels := &danav1alpha1.ClusterNamespaceLabelList{}
+	if err := c.List(ctx, clusterNamespaceLabels); err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]string)
+	for i := range clusterNamespaceLabels.Items {
+		selected, err := namespacelabel.SelectsNamespace(&clusterNamespaceLabels.Items[i], ns)
+		if err != nil || !selected {
+			continue
+		}
+		for key, value := range clusterNamespaceLabels.Items[i].Spec.Labels {
+			declared[key] = value
+		}
+	}
+
+	return declared, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterNamespaceLabelReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&danav1alpha1.ClusterNamespaceLabel{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.namespaceRequests),
+			builder.WithPredicates(namespaceMetadataChanged)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles, RateLimiter: r.RateLimiter}).
+		Complete(r)
+}
+
+// namespaceRequests maps a Namespace event to the ClusterNamespaceLabels that select the Namespace or labeled
+// it before, so relabeled and new Namespaces are picked up and deselected ones are cleaned up
+func (r *ClusterNamespaceLabelReconciler) namespaceRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return nil
+	}
+
+	clusterNamespaceLabels := &danav1alpha1.ClusterNamespaceLabelList{}
+	if err := r.List(ctx, clusterNamespaceLabels); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ClusterNamespaceLabels")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range clusterNamespaceLabels.Items {
+		clusterNamespaceLabel := &clusterNamespaceLabels.Items[i]
+		selected, _ := namespacelabel.SelectsNamespace(clusterNamespaceLabel, ns)
+		if selected || slices.Contains(clusterNamespaceLabel.Status.LabeledNamespaces, ns.Name) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: clusterNamespaceLabel.Name},
+			})
+		}
+	}
+
+	return requests
+}
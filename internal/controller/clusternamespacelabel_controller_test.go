@@ -0,0 +1,300 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
+)
+
+var _ = Describe("ClusterNamespaceLabel Controller", func() {
+	const resourceName = "platform"
+	namespacedName := types.NamespacedName{Name: resourceName}
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		for name, tier := range map[string]string{"team-a": "gold", "team-b": "silver", "team-c": "gold"} {
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"tier": tier}},
+			})).To(Succeed())
+		}
+	})
+
+	namespaceLabels := func(name string) map[string]string {
+		ns := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, ns)).To(Succeed())
+		return ns.Labels
+	}
+
+	It("should label the selected Namespaces, follow relabeled ones and clean up on deletion", func() {
+		Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+			Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+				Labels: map[string]string{"backup": "daily"},
+				NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+					Names:         []string{"team-b"},
+				},
+			},
+		})).To(Succeed())
+
+		controllerReconciler := &ClusterNamespaceLabelReconciler{Client: k8sClient, Scheme: scheme}
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, name := range []string{"team-a", "team-b", "team-c"} {
+			Expect(namespaceLabels(name)).To(HaveKeyWithValue("backup", "daily"))
+		}
+		clusterNamespaceLabel := &danav1alpha1.ClusterNamespaceLabel{}
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).To(Succeed())
+		Expect(clusterNamespaceLabel.Status.LabeledNamespaces).To(Equal([]string{"team-a", "team-b", "team-c"}))
+		Expect(meta.IsStatusConditionTrue(clusterNamespaceLabel.Status.Conditions, conditionReady)).To(BeTrue())
+
+		By("relabeling a Namespace out of the selector")
+		ns := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "team-c"}, ns)).To(Succeed())
+		ns.Labels["tier"] = "bronze"
+		Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+		Expect(controllerReconciler.namespaceRequests(ctx, ns)).To(HaveLen(1))
+
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaceLabels("team-c")).NotTo(HaveKey("backup"))
+		Expect(namespaceLabels("team-a")).To(HaveKeyWithValue("backup", "daily"))
+
+		By("deleting the ClusterNamespaceLabel")
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, clusterNamespaceLabel)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		for _, name := range []string{"team-a", "team-b", "team-c"} {
+			Expect(namespaceLabels(name)).NotTo(HaveKey("backup"))
+		}
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).NotTo(Succeed())
+	})
+
+	It("should keep tenant NamespaceLabels from overriding or removing cluster labels", func() {
+		Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+			Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+				Labels:            map[string]string{"backup": "daily"},
+				NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: []string{"team-a"}},
+			},
+		})).To(Succeed())
+		clusterReconciler := &ClusterNamespaceLabelReconciler{Client: k8sClient, Scheme: scheme}
+		_, err := clusterReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Create(ctx, &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "team-a"},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"backup": "never", "owner": "a"}},
+		})).To(Succeed())
+		tenantReconciler := &NamespaceLabelReconciler{Client: k8sClient, Scheme: scheme, Log: zap.New(zap.UseDevMode(true))}
+		_, err = tenantReconciler.Reconcile(ctx, ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: "tenant", Namespace: "team-a"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(namespaceLabels("team-a")).To(HaveKeyWithValue("backup", "daily"))
+		Expect(namespaceLabels("team-a")).To(HaveKeyWithValue("owner", "a"))
+	})
+
+	It("should render templated values for each Namespace", func() {
+		ns := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "team-a"}, ns)).To(Succeed())
+		ns.Annotations = map[string]string{"owner": "alice"}
+		Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+		Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+			Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+				Labels: map[string]string{
+					"tenant": "{{ .Namespace.Name }}",
+					"owner":  `{{ or (.Namespace.Annotations "owner") "unowned" }}`,
+					"since":  `{{ now "2006" }}`,
+				},
+				NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: []string{"team-a", "team-b"}},
+			},
+		})).To(Succeed())
+
+		controllerReconciler := &ClusterNamespaceLabelReconciler{Client: k8sClient, Scheme: scheme}
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		year := time.Now().Format("2006")
+		Expect(namespaceLabels("team-a")).To(And(HaveKeyWithValue("tenant", "team-a"),
+			HaveKeyWithValue("owner", "alice"), HaveKeyWithValue("since", year)))
+		Expect(namespaceLabels("team-b")).To(And(HaveKeyWithValue("tenant", "team-b"),
+			HaveKeyWithValue("owner", "unowned")))
+
+		By("rejecting values that do not render to valid label values")
+		clusterNamespaceLabel := &danav1alpha1.ClusterNamespaceLabel{}
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).To(Succeed())
+		clusterNamespaceLabel.Spec.Labels = map[string]string{"tenant": "{{ .Namespace.Name }} team"}
+		Expect(k8sClient.Update(ctx, clusterNamespaceLabel)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).To(Succeed())
+		Expect(meta.IsStatusConditionFalse(clusterNamespaceLabel.Status.Conditions, conditionReady)).To(BeTrue())
+		Expect(namespaceLabels("team-a")).To(HaveKeyWithValue("tenant", "team-a"))
+	})
+
+	It("should create the named Namespaces that do not exist and delete them with it", func() {
+		Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Labels: map[string]string{"tenant": "acme"}},
+			Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+				Labels: map[string]string{"tenant": "acme"},
+				NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{
+					Names: []string{"team-a", `{{ .ClusterNamespaceLabel.Labels "tenant" }}-dev`, "kube-system"},
+				},
+				CreateNamespace:         true,
+				DeleteCreatedNamespaces: true,
+			},
+		})).To(Succeed())
+
+		controllerReconciler := &ClusterNamespaceLabelReconciler{Client: k8sClient, Scheme: scheme}
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		created := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "acme-dev"}, created)).To(Succeed())
+		Expect(created.Annotations).To(HaveKeyWithValue(namespacelabel.CreatedByAnnotation, resourceName))
+		Expect(created.Labels).To(HaveKeyWithValue("tenant", "acme"))
+		Expect(namespaceLabels("team-a")).To(HaveKeyWithValue("tenant", "acme"))
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-system"},
+			&corev1.Namespace{}))).To(BeTrue())
+		clusterNamespaceLabel := &danav1alpha1.ClusterNamespaceLabel{}
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).To(Succeed())
+		Expect(clusterNamespaceLabel.Status.LabeledNamespaces).To(Equal([]string{"acme-dev", "team-a"}))
+
+		By("deleting the ClusterNamespaceLabel")
+		Expect(k8sClient.Delete(ctx, clusterNamespaceLabel)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{Name: "acme-dev"}, created))).To(BeTrue())
+		Expect(namespaceLabels("team-a")).NotTo(HaveKey("tenant"))
+	})
+
+	It("should check the LabelPolicies of each selected Namespace before labeling it", func() {
+		Expect(k8sClient.Create(ctx, &danav1alpha1.LabelPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-silver-backups"},
+			Spec: danav1alpha1.LabelPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "silver"}},
+				DeniedValues:      []danav1alpha1.DeniedValueRule{{Key: "backup", Values: []string{"daily"}}},
+			},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+			Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+				Labels:            map[string]string{"backup": "daily"},
+				NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: []string{"team-b"}},
+			},
+		})).To(Succeed())
+
+		controllerReconciler := &ClusterNamespaceLabelReconciler{Client: k8sClient, Scheme: scheme}
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(namespaceLabels("team-b")).NotTo(HaveKey("backup"))
+		clusterNamespaceLabel := &danav1alpha1.ClusterNamespaceLabel{}
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).To(Succeed())
+		ready := meta.FindStatusCondition(clusterNamespaceLabel.Status.Conditions, conditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal("PolicyViolation"))
+		Expect(ready.Message).To(ContainSubstring("namespace 'team-b'"))
+	})
+
+	It("should not manage more labels on a Namespace than the managed label quota allows", func() {
+		Expect(k8sClient.Create(ctx, &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "team-a"},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"owner": "a", "cost-center": "42"}},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+			Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+				Labels:            map[string]string{"backup": "daily"},
+				NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: []string{"team-a"}},
+			},
+		})).To(Succeed())
+
+		controllerReconciler := &ClusterNamespaceLabelReconciler{Client: k8sClient, Scheme: scheme,
+			Quota: validation.LabelQuota{MaxManagedLabels: 2}}
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).To(HaveOccurred())
+
+		Expect(namespaceLabels("team-a")).NotTo(HaveKey("backup"))
+		clusterNamespaceLabel := &danav1alpha1.ClusterNamespaceLabel{}
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).To(Succeed())
+		ready := meta.FindStatusCondition(clusterNamespaceLabel.Status.Conditions, conditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal("QuotaExceeded"))
+
+		By("raising the quota")
+		controllerReconciler.Quota.MaxManagedLabels = 3
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaceLabels("team-a")).To(HaveKeyWithValue("backup", "daily"))
+	})
+
+	It("should only label and unlabel the Namespaces in this instance's partition", func() {
+		newPartitioner := func(name, tier string) *Partitioner {
+			partitioner := &Partitioner{
+				Client: k8sClient, Reader: k8sClient, Namespace: "operators", RefreshInterval: time.Minute,
+				Own: Partition{Name: name, Selector: labels.SelectorFromSet(labels.Set{"tier": tier})},
+			}
+			Expect(partitioner.refresh(ctx)).To(Succeed())
+			return partitioner
+		}
+		gold := &ClusterNamespaceLabelReconciler{Client: k8sClient, Scheme: scheme, Partitioner: newPartitioner("gold", "gold")}
+		silver := &ClusterNamespaceLabelReconciler{Client: k8sClient, Scheme: scheme,
+			Partitioner: newPartitioner("silver", "silver")}
+
+		Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+			Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+				Labels:            map[string]string{"backup": "daily"},
+				NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: []string{"team-a", "team-b"}},
+			},
+		})).To(Succeed())
+		_, err := gold.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaceLabels("team-a")).To(HaveKeyWithValue("backup", "daily"))
+		Expect(namespaceLabels("team-b")).NotTo(HaveKey("backup"))
+
+		_, err = silver.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaceLabels("team-b")).To(HaveKeyWithValue("backup", "daily"))
+		clusterNamespaceLabel := &danav1alpha1.ClusterNamespaceLabel{}
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).To(Succeed())
+		Expect(clusterNamespaceLabel.Status.LabeledNamespaces).To(Equal([]string{"team-a", "team-b"}))
+
+		By("deleting the ClusterNamespaceLabel")
+		Expect(k8sClient.Delete(ctx, clusterNamespaceLabel)).To(Succeed())
+		result, err := gold.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(time.Minute))
+		Expect(namespaceLabels("team-a")).NotTo(HaveKey("backup"))
+		Expect(namespaceLabels("team-b")).To(HaveKeyWithValue("backup", "daily"))
+		Expect(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel)).To(Succeed())
+		Expect(clusterNamespaceLabel.Status.LabeledNamespaces).To(Equal([]string{"team-b"}))
+
+		_, err = silver.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaceLabels("team-b")).NotTo(HaveKey("backup"))
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, namespacedName, clusterNamespaceLabel))).To(BeTrue())
+	})
+})
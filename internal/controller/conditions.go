@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// Summary condition types following the kstatus conventions used by Argo CD and Kyverno to compute health
+const (
+	// conditionReady is True once the current spec is applied and nothing needs attention
+	conditionReady = "Ready"
+	// conditionReconciling is True while the current spec is being applied or retried
+	conditionReconciling = "Reconciling"
+	// conditionStalled is True when the spec cannot be applied without a change by the user
+	conditionStalled = "Stalled"
+)
+
+// stalledConditions are abnormal-true conditions that retrying will not clear
+var stalledConditions = []string{"Suspended", "DryRun", "Invalid", "Rejected", "QuotaExceeded", "PartitionConflict",
+	"Degraded"}
+
+// stalledReasons are LabelsApplied=False reasons that retrying will not clear
+var stalledReasons = []string{"Conflict", "TargetNamespaceError", "ProtectedNamespace", "DependencyCycle",
+	"NamespaceNotOptedIn"}
+
+// setSummaryConditions derives the Ready, Reconciling and Stalled conditions from the detailed ones
+func setSummaryConditions(namespaceLabel *danav1alpha1.NamespaceLabel) {
+	conditions := &namespaceLabel.Status.Conditions
+	generation := namespaceLabel.Generation
+	namespaceLabel.Status.ObservedGeneration = generation
+
+	var stalled *metav1.Condition
+	for _, conditionType := range stalledConditions {
+		if condition := meta.FindStatusCondition(*conditions, conditionType); condition != nil &&
+			condition.Status == metav1.ConditionTrue {
+			stalled = condition.DeepCopy()
+			break
+		}
+	}
+	applied := meta.FindStatusCondition(*conditions, "LabelsApplied")
+	if stalled == nil && applied != nil && applied.Status == metav1.ConditionFalse &&
+		slices.Contains(stalledReasons, applied.Reason) {
+		stalled = applied.DeepCopy()
+	}
+
+	switch {
+	case stalled != nil:
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type: conditionStalled, Status: metav1.ConditionTrue, ObservedGeneration: generation,
+			Reason: stalled.Reason, Message: stalled.Message,
+		})
+		meta.RemoveStatusCondition(conditions, conditionReconciling)
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type: conditionReady, Status: metav1.ConditionFalse, ObservedGeneration: generation,
+			Reason: stalled.Reason, Message: stalled.Message,
+		})
+	case applied != nil && applied.Status == metav1.ConditionTrue && namespaceLabel.Status.PendingSince == nil:
+		meta.RemoveStatusCondition(conditions, conditionStalled)
+		meta.RemoveStatusCondition(conditions, conditionReconciling)
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type: conditionReady, Status: metav1.ConditionTrue, ObservedGeneration: generation,
+			Reason: applied.Reason, Message: applied.Message,
+		})
+	default:
+		reason, message := "Progressing", "labels are being applied to the Namespace"
+		if applied != nil && applied.Status == metav1.ConditionFalse {
+			reason, message = applied.Reason, applied.Message
+		}
+		meta.RemoveStatusCondition(conditions, conditionStalled)
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type: conditionReconciling, Status: metav1.ConditionTrue, ObservedGeneration: generation,
+			Reason: reason, Message: message,
+		})
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type: conditionReady, Status: metav1.ConditionFalse, ObservedGeneration: generation,
+			Reason: reason, Message: message,
+		})
+	}
+}
+
+// setLastSync records the time and outcome of a reconciliation attempt, derived from the summary conditions,
+// and the retries preceding it in the status of a NamespaceLabel. The retry count is reset once the labels are
+// applied.
+func setLastSync(namespaceLabel *danav1alpha1.NamespaceLabel, now metav1.Time, retries int) {
+	conditions := namespaceLabel.Status.Conditions
+	result := danav1alpha1.SyncProgressing
+	switch {
+	case meta.IsStatusConditionTrue(conditions, conditionReady):
+		result = danav1alpha1.SyncSucceeded
+		retries = 0
+	case meta.IsStatusConditionTrue(conditions, conditionStalled):
+		result = danav1alpha1.SyncStalled
+	case meta.IsStatusConditionFalse(conditions, "LabelsApplied") &&
+		!meta.IsStatusConditionTrue(conditions, conditionWaitingForDependency):
+		result = danav1alpha1.SyncFailed
+	}
+	namespaceLabel.Status.LastSyncTime = &now
+	namespaceLabel.Status.LastSyncResult = result
+	namespaceLabel.Status.RetryCount = int32(retries)
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// conditionWaitingForDependency is True while a NamespaceLabel in spec.dependsOn is not Ready
+const conditionWaitingForDependency = "WaitingForDependency"
+
+// readinessChanged passes NamespaceLabel events that can change whether its dependents may apply their labels:
+// its creation and deletion, and updates of its Ready condition or of the generation it reports it for
+var readinessChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldLabel, oldOk := e.ObjectOld.(*danav1alpha1.NamespaceLabel)
+		newLabel, newOk := e.ObjectNew.(*danav1alpha1.NamespaceLabel)
+		if !oldOk || !newOk {
+			return true
+		}
+		return isReady(oldLabel) != isReady(newLabel)
+	},
+}
+
+// isReady reports whether a NamespaceLabel is Ready for its current generation
+func isReady(namespaceLabel *danav1alpha1.NamespaceLabel) bool {
+	ready := meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionReady)
+	return ready != nil && ready.Status == metav1.ConditionTrue && ready.ObservedGeneration == namespaceLabel.Generation &&
+		namespaceLabel.DeletionTimestamp.IsZero()
+}
+
+// dependencyKey returns the namespaced name of a NamespaceLabel in spec.dependsOn
+func dependencyKey(namespaceLabel *danav1alpha1.NamespaceLabel,
+	dependency danav1alpha1.NamespaceLabelReference) types.NamespacedName {
+	namespace := dependency.Namespace
+	if namespace == "" {
+		namespace = namespaceLabel.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: dependency.Name}
+}
+
+// waitForDependencies reports whether a NamespaceLabel has to wait for the NamespaceLabels it depends on before
+// applying its labels. While it waits, the WaitingForDependency condition names the first one not Ready, and
+// LabelsApplied is False; a dependency cycle can never resolve and stalls the NamespaceLabel instead.
+func (r *NamespaceLabelReconciler) waitForDependencies(ctx context.Context,
+	namespaceLabel *danav1alpha1.NamespaceLabel) (bool, error) {
+	var waiting string
+	for _, dependency := range namespaceLabel.Spec.DependsOn {
+		key := dependencyKey(namespaceLabel, dependency)
+		dependencyLabel := &danav1alpha1.NamespaceLabel{}
+		if err := r.Get(ctx, key, dependencyLabel); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return false, err
+			}
+			waiting = fmt.Sprintf("NamespaceLabel '%s' does not exist", key)
+			break
+		}
+		if !isReady(dependencyLabel) {
+			waiting = fmt.Sprintf("NamespaceLabel '%s' is not Ready", key)
+			break
+		}
+	}
+	if waiting == "" {
+		meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, conditionWaitingForDependency)
+		return false, nil
+	}
+
+	reason := "DependencyNotReady"
+	cycle, err := r.dependencyCycle(ctx, namespaceLabel)
+	if err != nil {
+		return false, err
+	}
+	if len(cycle) > 0 {
+		reason, waiting = "DependencyCycle", "dependency cycle: "+strings.Join(cycle, " -> ")
+	}
+	meta.SetStatusCondition(&namespaceLabel.Status.Conditions, metav1.Condition{
+		Type: conditionWaitingForDependency, Status: metav1.ConditionTrue, ObservedGeneration: namespaceLabel.Generation,
+		Reason: reason, Message: waiting,
+	})
+	r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionFalse, reason, waiting)
+	return true, nil
+}
+
+// dependencyCycle returns the NamespaceLabels of a dependency cycle leading back to a NamespaceLabel, starting
+// and ending with it, or nil when its dependencies do not lead back to it
+func (r *NamespaceLabelReconciler) dependencyCycle(ctx context.Context,
+	namespaceLabel *danav1alpha1.NamespaceLabel) ([]string, error) {
+	start := types.NamespacedName{Namespace: namespaceLabel.Namespace, Name: namespaceLabel.Name}
+	visited := map[types.NamespacedName]bool{start: true}
+
+	var visit func(current *danav1alpha1.NamespaceLabel, path []string) ([]string, error)
+	visit = func(current *danav1alpha1.NamespaceLabel, path []string) ([]string, error) {
+		for _, dependency := range current.Spec.DependsOn {
+			key := dependencyKey(current, dependency)
+			if key == start {
+				return append(path, key.String()), nil
+			}
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+
+			next := &danav1alpha1.NamespaceLabel{}
+			if err := r.Get(ctx, key, next); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return nil, err
+			}
+			if cycle, err := visit(next, append(path, key.String())); cycle != nil || err != nil {
+				return cycle, err
+			}
+		}
+		return nil, nil
+	}
+	return visit(namespaceLabel, []string{start.String()})
+}
+
+// dependentRequests maps a NamespaceLabel event to the watched NamespaceLabels depending on it
+func (r *NamespaceLabelReconciler) dependentRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := r.List(ctx, namespaceLabels); err != nil {
+		r.Log.Error(err, "Failed to list NamespaceLabels for dependency", "Namespace", obj.GetNamespace(),
+			"Name", obj.GetName())
+		return nil
+	}
+
+	updated := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	var requests []reconcile.Request
+	for i := range namespaceLabels.Items {
+		nl := &namespaceLabels.Items[i]
+		if !namespacelabel.IsWatched(r.WatchSelector, nl) {
+			continue
+		}
+		for _, dependency := range nl.Spec.DependsOn {
+			if dependencyKey(nl, dependency) == updated {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: nl.Namespace, Name: nl.Name},
+				})
+				break
+			}
+		}
+	}
+
+	return requests
+}
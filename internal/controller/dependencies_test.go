@@ -0,0 +1,100 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+var _ = Describe("NamespaceLabel dependencies", func() {
+	const namespaceName = "default"
+	var controllerReconciler *NamespaceLabelReconciler
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		createNamespace(namespaceName)
+		controllerReconciler = &NamespaceLabelReconciler{
+			Client:        k8sClient,
+			Scheme:        scheme,
+			Log:           zap.New(zap.UseDevMode(true)),
+			AllowMultiple: true,
+		}
+	})
+
+	AfterEach(func() {
+		deleteAllNamespaceLabels()
+		deleteNamespace(namespaceName)
+	})
+
+	newNamespaceLabel := func(name string, labels map[string]string, dependsOn ...string) *danav1alpha1.NamespaceLabel {
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespaceName},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: labels},
+		}
+		for _, dependency := range dependsOn {
+			namespaceLabel.Spec.DependsOn = append(namespaceLabel.Spec.DependsOn,
+				danav1alpha1.NamespaceLabelReference{Name: dependency})
+		}
+		Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+		return namespaceLabel
+	}
+	reconcile := func(name string) *danav1alpha1.NamespaceLabel {
+		key := types.NamespacedName{Namespace: namespaceName, Name: name}
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		namespaceLabel := &danav1alpha1.NamespaceLabel{}
+		Expect(k8sClient.Get(ctx, key, namespaceLabel)).To(Succeed())
+		return namespaceLabel
+	}
+
+	It("should apply its labels only once its dependencies are Ready", func() {
+		newNamespaceLabel("base", map[string]string{"tier": "base"})
+		newNamespaceLabel("override", map[string]string{"app": "web"}, "base")
+
+		override := reconcile("override")
+		waiting := meta.FindStatusCondition(override.Status.Conditions, conditionWaitingForDependency)
+		Expect(waiting).NotTo(BeNil())
+		Expect(waiting.Reason).To(Equal("DependencyNotReady"))
+		Expect(waiting.Message).To(ContainSubstring("NamespaceLabel 'default/base' is not Ready"))
+		Expect(meta.IsStatusConditionFalse(override.Status.Conditions, "LabelsApplied")).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(override.Status.Conditions, conditionReconciling)).To(BeTrue())
+		namespace := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+		Expect(namespace.Labels).NotTo(HaveKey("app"))
+
+		By("reconciling the dependency")
+		Expect(meta.IsStatusConditionTrue(reconcile("base").Status.Conditions, conditionReady)).To(BeTrue())
+		requests := controllerReconciler.dependentRequests(ctx, &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: namespaceName},
+		})
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Name).To(Equal("override"))
+
+		override = reconcile("override")
+		Expect(meta.FindStatusCondition(override.Status.Conditions, conditionWaitingForDependency)).To(BeNil())
+		Expect(meta.IsStatusConditionTrue(override.Status.Conditions, conditionReady)).To(BeTrue())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+		Expect(namespace.Labels).To(HaveKeyWithValue("tier", "base"))
+		Expect(namespace.Labels).To(HaveKeyWithValue("app", "web"))
+	})
+
+	It("should stall on a dependency cycle", func() {
+		newNamespaceLabel("first", map[string]string{"a": "1"}, "second")
+		newNamespaceLabel("second", map[string]string{"b": "2"}, "first")
+
+		first := reconcile("first")
+		waiting := meta.FindStatusCondition(first.Status.Conditions, conditionWaitingForDependency)
+		Expect(waiting).NotTo(BeNil())
+		Expect(waiting.Reason).To(Equal("DependencyCycle"))
+		Expect(waiting.Message).To(Equal("dependency cycle: default/first -> default/second -> default/first"))
+		Expect(meta.IsStatusConditionTrue(first.Status.Conditions, conditionStalled)).To(BeTrue())
+	})
+})
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// labelOperations maps the reason of a label change to the operation a dry run reports
+var labelOperations = map[string]danav1alpha1.LabelOperation{
+	"LabelAdded":   danav1alpha1.LabelAdd,
+	"LabelUpdated": danav1alpha1.LabelUpdate,
+	"LabelRemoved": danav1alpha1.LabelRemove,
+}
+
+// addPendingChanges records the label changes a dry-run NamespaceLabel would make on a Namespace
+func addPendingChanges(namespaceLabel *danav1alpha1.NamespaceLabel, namespace string, changes labelChanges) {
+	for _, change := range changes {
+		namespaceLabel.Status.PendingChanges = append(namespaceLabel.Status.PendingChanges,
+			danav1alpha1.PendingLabelChange{
+				Namespace: namespace,
+				Key:       change.key,
+				Operation: labelOperations[change.reason],
+				Value:     change.value,
+				Previous:  change.previous,
+			})
+	}
+}
+
+// clearDryRun drops the dry-run report of a NamespaceLabel whose changes are applied again
+func clearDryRun(namespaceLabel *danav1alpha1.NamespaceLabel) {
+	namespaceLabel.Status.PendingChanges = nil
+	meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "DryRun")
+}
+
+// reportDryRun publishes the pending changes of a dry-run NamespaceLabel in its status and, when they are first
+// reported or changed, in an Event
+func (r *NamespaceLabelReconciler) reportDryRun(ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel,
+	previous []danav1alpha1.PendingLabelChange) {
+	pending := namespaceLabel.Status.PendingChanges
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].Namespace != pending[j].Namespace {
+			return pending[i].Namespace < pending[j].Namespace
+		}
+		return pending[i].Key < pending[j].Key
+	})
+
+	counts := make(map[danav1alpha1.LabelOperation]int)
+	for _, change := range pending {
+		counts[change.Operation]++
+	}
+	message := fmt.Sprintf("dry run: would add %d, update %d and remove %d labels",
+		counts[danav1alpha1.LabelAdd], counts[danav1alpha1.LabelUpdate], counts[danav1alpha1.LabelRemove])
+	reported := meta.FindStatusCondition(namespaceLabel.Status.Conditions, "DryRun") != nil
+	if r.Recorder != nil && (!reported || !slices.Equal(previous, pending)) {
+		r.Recorder.Event(namespaceLabel, corev1.EventTypeNormal, "DryRun", message)
+	}
+
+	// Nothing is applied while in dry run, so the NamespaceLabel does not turn Stale
+	namespaceLabel.Status.PendingSince = nil
+	reason := "PendingChanges"
+	if len(pending) == 0 {
+		reason = "NoChanges"
+	}
+	r.updateStatus(ctx, namespaceLabel, "DryRun", metav1.ConditionTrue, reason, message)
+}
+
+// releaseDryRun releases the finalizer of a dry-run NamespaceLabel being deleted, leaving its labels in place
+func (r *NamespaceLabelReconciler) releaseDryRun(ctx context.Context,
+	namespaceLabel *danav1alpha1.NamespaceLabel) (ctrl.Result, error) {
+	forgetStale(namespaceLabel)
+	if controllerutil.ContainsFinalizer(namespaceLabel, finalizerName) {
+		controllerutil.RemoveFinalizer(namespaceLabel, finalizerName)
+		return ctrl.Result{}, r.Update(ctx, namespaceLabel)
+	}
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// labelChange is a managed label added, changed or removed on a Namespace
+type labelChange struct {
+	reason   string
+	key      string
+	value    string
+	previous string
+}
+
+// labelChanges are collected while a Namespace is updated and recorded once the update succeeds
+type labelChanges []labelChange
+
+func (c *labelChanges) added(key, value string) {
+	*c = append(*c, labelChange{reason: "LabelAdded", key: key, value: value})
+}
+
+func (c *labelChanges) updated(key, previous, value string) {
+	*c = append(*c, labelChange{reason: "LabelUpdated", key: key, value: value, previous: previous})
+}
+
+func (c *labelChanges) removed(key, previous string) {
+	*c = append(*c, labelChange{reason: "LabelRemoved", key: key, previous: previous})
+}
+
+// recordLabelChanges audits the label changes made on a Namespace, notifies them and, when enabled, emits an
+// Event on the Namespace for each, so `kubectl describe ns` shows the label timeline. Changes restoring a value
+// the NamespaceLabel applied before correct drift and are notified as such.
+func (r *NamespaceLabelReconciler) recordLabelChanges(ctx context.Context,
+	ns *corev1.Namespace, namespaceLabel *danav1alpha1.NamespaceLabel, changes labelChanges) {
+	owner := "NamespaceLabel " + namespaceLabel.Namespace + "/" + namespaceLabel.Name
+	user := namespaceLabel.Annotations[namespacelabel.ModifiedByAnnotation]
+	r.Auditor.record(ctx, ns, owner, user, changes)
+	var applied, corrected labelChanges
+	for _, change := range changes {
+		if previous, exists := namespaceLabel.Status.AppliedLabels[change.key]; exists &&
+			change.reason != "LabelRemoved" && previous == change.value {
+			corrected = append(corrected, change)
+		} else {
+			applied = append(applied, change)
+		}
+	}
+	r.Notifier.notifyChanges(NotifyApplied, ns.Name, owner, user, applied)
+	r.Notifier.notifyChanges(NotifyDriftCorrected, ns.Name, owner, user, corrected)
+	if r.NamespaceEvents {
+		recordLabelEvents(r.Recorder, ns, owner, changes)
+	}
+}
+
+// recordLabelEvents emits an Event on the Namespace for each label change made by the given owner
+func recordLabelEvents(recorder record.EventRecorder, ns *corev1.Namespace, owner string, changes labelChanges) {
+	if recorder == nil {
+		return
+	}
+
+	for _, change := range changes {
+		var message string
+		if change.reason == "LabelRemoved" {
+			message = fmt.Sprintf("label '%s' removed by %s", change.key, owner)
+		} else {
+			message = fmt.Sprintf("label '%s=%s' set by %s", change.key, change.value, owner)
+		}
+		recorder.Event(ns, corev1.EventTypeNormal, change.reason, message)
+	}
+}
@@ -0,0 +1,290 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// reconcileFanOut applies the labels of a NamespaceLabel declaring targetNamespaces to each listed Namespace.
+// Unlike a tenant NamespaceLabel it only manages the keys it declares, since the targets keep their own
+// NamespaceLabels.
+func (r *NamespaceLabelReconciler) reconcileFanOut(
+	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel, start time.Time) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	// Partitions assign a fan-out to the instance owning its admin namespace
+	if r.Partitioner != nil {
+		ns := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: namespaceLabel.Namespace}, ns); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		owned, conflict, err := r.Partitioner.Owns(ns)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if conflict != "" {
+			r.updateStatus(ctx, namespaceLabel, "PartitionConflict", metav1.ConditionTrue, "Conflict", conflict)
+			return ctrl.Result{RequeueAfter: r.Partitioner.RefreshInterval}, nil
+		}
+		if !owned {
+			log.Info("Skipping NamespaceLabel outside this instance's partition")
+			return ctrl.Result{}, nil
+		}
+		meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "PartitionConflict")
+	}
+
+	markPending(namespaceLabel)
+
+	// Handle deletion
+	if namespaceLabel.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(namespaceLabel, finalizerName) {
+			controllerutil.AddFinalizer(namespaceLabel, finalizerName)
+			if err := r.Update(ctx, namespaceLabel); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if namespaceLabel.Spec.DryRun {
+			return r.releaseDryRun(ctx, namespaceLabel)
+		}
+		if controllerutil.ContainsFinalizer(namespaceLabel, finalizerName) {
+			if delay := r.WriteBudget.reserve(len(namespaceLabel.Status.LabeledNamespaces)); delay > 0 {
+				return ctrl.Result{RequeueAfter: delay}, nil
+			}
+			if err := r.unlabelNamespaces(ctx, namespaceLabel, namespaceLabel.Status.LabeledNamespaces); err != nil {
+				return ctrl.Result{}, err
+			}
+			forgetStale(namespaceLabel)
+			controllerutil.RemoveFinalizer(namespaceLabel, finalizerName)
+			if err := r.Update(ctx, namespaceLabel); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	var targets []*corev1.Namespace
+	for _, name := range namespaceLabel.Spec.TargetNamespaces {
+		ns := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Info("Skipping missing target Namespace", "Namespace", name)
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+		if r.ProtectedNamespaces.IsProtected(ns) {
+			log.Info("Skipping protected target Namespace", "Namespace", name)
+			continue
+		}
+		targets = append(targets, ns)
+	}
+
+	expiry := r.resolveLabelRules(namespaceLabel, time.Now())
+	err := r.resolveLabelsFrom(ctx, namespaceLabel)
+	if err == nil {
+		err = r.validateSpec(ctx, namespaceLabel, targets...)
+	}
+	if err != nil {
+		labelsRejected.WithLabelValues(sourceNamespaceLabel, invalidReason(err)).Add(float64(len(namespaceLabel.Spec.Labels)))
+		retryErr := r.reject(namespaceLabel, invalidReason(err), err)
+		r.reportInvalid(ctx, namespaceLabel, err)
+		return ctrl.Result{}, retryErr
+	}
+	meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "Invalid")
+	meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, conditionRejected)
+	meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "QuotaExceeded")
+
+	if waiting, err := r.waitForDependencies(ctx, namespaceLabel); waiting || err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var dropped []string
+	for _, name := range namespaceLabel.Status.LabeledNamespaces {
+		if !slices.Contains(namespaceLabel.Spec.TargetNamespaces, name) {
+			dropped = append(dropped, name)
+		}
+	}
+
+	if !namespaceLabel.Spec.DryRun {
+		if delay := r.WriteBudget.reserve(len(targets) + len(dropped)); delay > 0 {
+			log.Info("Namespace write budget exhausted, requeueing", "after", delay)
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
+	}
+	previousPending := namespaceLabel.Status.PendingChanges
+	namespaceLabel.Status.PendingChanges = nil
+
+	var labeled []string
+	now := time.Now()
+	for _, ns := range targets {
+		rendered, err := namespacelabel.RenderLabels(namespaceLabel.Spec.Labels, ns, now)
+		if err != nil {
+			r.updateStatus(ctx, namespaceLabel, "Invalid", metav1.ConditionTrue, "ValidationFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		if err := r.applyFanOutLabels(ctx, namespaceLabel, ns, rendered); err != nil {
+			r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionFalse, "UpdateError", err.Error())
+			return ctrl.Result{}, err
+		}
+		labeled = append(labeled, ns.Name)
+	}
+
+	// Remove the labels from Namespaces dropped from targetNamespaces
+	if err := r.unlabelNamespaces(ctx, namespaceLabel, dropped); err != nil {
+		r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionFalse, "UpdateError", err.Error())
+		return ctrl.Result{}, err
+	}
+	if namespaceLabel.Spec.DryRun {
+		r.reportDryRun(ctx, namespaceLabel, previousPending)
+		return ctrl.Result{RequeueAfter: earliestRequeue(r.resyncInterval(namespaceLabel), expiry)}, nil
+	}
+
+	namespacePatchLatency.WithLabelValues(sourceNamespaceLabel).Observe(time.Since(start).Seconds())
+
+	sort.Strings(labeled)
+	applied := make(map[string]string, len(namespaceLabel.Spec.Labels))
+	for key, value := range namespaceLabel.Spec.Labels {
+		applied[key] = value
+	}
+	namespaceLabel.Status.AppliedLabels = applied
+	namespaceLabel.Status.AppliedAnnotations = namespaceLabel.Spec.Annotations
+	namespaceLabel.Status.LabeledNamespaces = labeled
+
+	r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionTrue, "Success",
+		fmt.Sprintf("labels applied to %d target namespaces", len(labeled)))
+
+	return ctrl.Result{RequeueAfter: earliestRequeue(r.resyncInterval(namespaceLabel), expiry)}, nil
+}
+
+// applyFanOutLabels sets the declared labels, rendered for the target Namespace, and the declared annotations,
+// and removes the keys the NamespaceLabel applied previously but no longer declares
+func (r *NamespaceLabelReconciler) applyFanOutLabels(ctx context.Context,
+	namespaceLabel *danav1alpha1.NamespaceLabel, ns *corev1.Namespace, rendered map[string]string) error {
+	patch := client.MergeFrom(ns.DeepCopy())
+	if ns.Labels == nil {
+		ns.Labels = make(map[string]string)
+	}
+
+	removed := 0
+	var changes labelChanges
+	for key := range namespaceLabel.Status.AppliedLabels {
+		if _, declared := namespaceLabel.Spec.Labels[key]; declared {
+			continue
+		}
+		if previous, exists := ns.Labels[key]; exists {
+			delete(ns.Labels, key)
+			removed++
+			changes.removed(key, previous)
+		}
+	}
+
+	added, updated := 0, 0
+	for key, value := range rendered {
+		current, exists := ns.Labels[key]
+		switch {
+		case !exists:
+			added++
+			changes.added(key, value)
+		case current != value:
+			updated++
+			changes.updated(key, current, value)
+		}
+		ns.Labels[key] = value
+	}
+	if namespaceLabel.Spec.DryRun {
+		addPendingChanges(namespaceLabel, ns.Name, changes)
+		return nil
+	}
+
+	setFanOutAnnotations(ns, namespaceLabel.Spec.Annotations, namespaceLabel.Status.AppliedAnnotations)
+	if err := r.Patch(ctx, ns, patch); err != nil {
+		return err
+	}
+
+	labelsAdded.WithLabelValues(sourceNamespaceLabel).Add(float64(added))
+	labelsUpdated.WithLabelValues(sourceNamespaceLabel).Add(float64(updated))
+	labelsRemoved.WithLabelValues(sourceNamespaceLabel).Add(float64(removed))
+	r.recordLabelChanges(ctx, ns, namespaceLabel, changes)
+
+	return nil
+}
+
+// unlabelNamespaces removes the labels and annotations a fan-out NamespaceLabel applied from the given Namespaces
+func (r *NamespaceLabelReconciler) unlabelNamespaces(
+	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel, names []string) error {
+	for _, name := range names {
+		ns := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if r.ProtectedNamespaces.IsProtected(ns) {
+			continue
+		}
+
+		patch := client.MergeFrom(ns.DeepCopy())
+		var changes labelChanges
+		for key := range namespaceLabel.Status.AppliedLabels {
+			if previous, exists := ns.Labels[key]; exists {
+				delete(ns.Labels, key)
+				changes.removed(key, previous)
+			}
+		}
+		if namespaceLabel.Spec.DryRun {
+			addPendingChanges(namespaceLabel, ns.Name, changes)
+			continue
+		}
+		annotated := setFanOutAnnotations(ns, nil, namespaceLabel.Status.AppliedAnnotations)
+		if len(changes) == 0 && !annotated {
+			continue
+		}
+		if err := r.Patch(ctx, ns, patch); err != nil {
+			return err
+		}
+		labelsRemoved.WithLabelValues(sourceNamespaceLabel).Add(float64(len(changes)))
+		r.recordLabelChanges(ctx, ns, namespaceLabel, changes)
+	}
+
+	return nil
+}
+
+// fanOutLabels returns the labels that fan-out NamespaceLabels in the admin namespaces declare for a Namespace
+func (r *NamespaceLabelReconciler) fanOutLabels(ctx context.Context, namespace string) (map[string]string, error) {
+	declared := make(map[string]string)
+	for _, adminNamespace := range r.AdminNamespaces {
+		namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+		if err := r.List(ctx, namespaceLabels, client.InNamespace(adminNamespace)); err != nil {
+			return nil, err
+		}
+		for _, nl := range namespaceLabels.Items {
+			if !slices.Contains(nl.Spec.TargetNamespaces, namespace) {
+				continue
+			}
+			for key, value := range nl.Spec.Labels {
+				declared[key] = value
+			}
+		}
+	}
+
+	return declared, nil
+}
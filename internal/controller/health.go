@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// listCheckTimeout bounds the API request of a readiness probe, so a slow API server fails the probe instead of
+// hanging it
+const listCheckTimeout = 5 * time.Second
+
+// NamespaceLabelListCheck returns a readiness check failing while NamespaceLabels cannot be listed, e.g. because
+// the API server is unreachable or the controller lost its RBAC permissions. The reader should bypass the cache
+// so every probe reaches the API server; a single item is requested.
+func NamespaceLabelListCheck(reader client.Reader) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), listCheckTimeout)
+		defer cancel()
+		return reader.List(ctx, &danav1alpha1.NamespaceLabelList{}, client.Limit(1))
+	}
+}
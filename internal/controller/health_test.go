@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Health Checks", func() {
+	BeforeEach(func() {
+		initTestEnvironment()
+	})
+
+	It("should be ready while NamespaceLabels can be listed", func() {
+		check := NamespaceLabelListCheck(k8sClient)
+		Expect(check(httptest.NewRequest(http.MethodGet, "/readyz", nil))).To(Succeed())
+
+		failing := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			List: func(context.Context, client.WithWatch, client.ObjectList, ...client.ListOption) error {
+				return errors.New("forbidden")
+			},
+		}).Build()
+		check = NamespaceLabelListCheck(failing)
+		Expect(check(httptest.NewRequest(http.MethodGet, "/readyz", nil))).To(MatchError("forbidden"))
+	})
+})
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// resolveLabelRules merges the label rules that have not expired, and the scheduled ones whose window is open,
+// into the NamespaceLabel's labels. It records the expiry of every expiring rule and the window of every
+// scheduled one in status, recording an Event for each newly expired rule and opened or closed window. The rules
+// are cleared from the in-memory spec once merged. It returns how long until the next rule expires or window
+// opens or closes, or 0 if none will.
+func (r *NamespaceLabelReconciler) resolveLabelRules(namespaceLabel *danav1alpha1.NamespaceLabel, now time.Time) time.Duration {
+	rules := namespaceLabel.Spec.LabelRules
+	if len(rules) == 0 {
+		namespaceLabel.Status.LabelExpirations = nil
+		namespaceLabel.Status.ExpiredLabels = nil
+		namespaceLabel.Status.LabelWindows = nil
+		return 0
+	}
+
+	resolved := make(map[string]string, len(namespaceLabel.Spec.Labels)+len(rules))
+	var (
+		expirations map[string]metav1.Time
+		windows     map[string]danav1alpha1.LabelWindow
+		expired     []string
+		next        time.Duration
+	)
+	for _, rule := range rules {
+		if rule.Schedule != nil {
+			// Admission rejects invalid schedules; one admitted without the webhook is never applied
+			active, transition, err := namespacelabel.LabelWindowAt(*rule.Schedule, now)
+			if err != nil {
+				continue
+			}
+			if windows == nil {
+				windows = make(map[string]danav1alpha1.LabelWindow)
+			}
+			windows[rule.Key] = danav1alpha1.LabelWindow{Active: active, NextTransition: metav1.NewTime(transition)}
+			r.recordWindowTransition(namespaceLabel, rule.Key, active)
+			if active {
+				resolved[rule.Key] = rule.Value
+			}
+			if delay := transition.Sub(now); next == 0 || delay < next {
+				next = delay
+			}
+			continue
+		}
+
+		expiresAt, expires := labelRuleExpiry(namespaceLabel, rule, now)
+		if !expires {
+			resolved[rule.Key] = rule.Value
+			continue
+		}
+
+		if expirations == nil {
+			expirations = make(map[string]metav1.Time)
+		}
+		expirations[rule.Key] = expiresAt
+		if !now.Before(expiresAt.Time) {
+			if !slices.Contains(namespaceLabel.Status.ExpiredLabels, rule.Key) && r.Recorder != nil {
+				r.Recorder.Eventf(namespaceLabel, corev1.EventTypeNormal, "LabelExpired",
+					"label '%s' expired at %s", rule.Key, expiresAt.UTC().Format(time.RFC3339))
+			}
+			expired = append(expired, rule.Key)
+			continue
+		}
+
+		resolved[rule.Key] = rule.Value
+		if delay := expiresAt.Sub(now); next == 0 || delay < next {
+			next = delay
+		}
+	}
+	// Declared labels win over rules redeclaring them, which admission rejects
+	for key, value := range namespaceLabel.Spec.Labels {
+		resolved[key] = value
+	}
+
+	sort.Strings(expired)
+	namespaceLabel.Spec.Labels = resolved
+	namespaceLabel.Spec.LabelRules = nil
+	namespaceLabel.Status.LabelExpirations = expirations
+	namespaceLabel.Status.ExpiredLabels = expired
+	namespaceLabel.Status.LabelWindows = windows
+	return next
+}
+
+// recordWindowTransition records an Event when the window of a scheduled label rule opened or closed since the
+// state recorded in status
+func (r *NamespaceLabelReconciler) recordWindowTransition(namespaceLabel *danav1alpha1.NamespaceLabel, key string,
+	active bool) {
+	previous, recorded := namespaceLabel.Status.LabelWindows[key]
+	if !recorded || previous.Active == active || r.Recorder == nil {
+		return
+	}
+	if active {
+		r.Recorder.Eventf(namespaceLabel, corev1.EventTypeNormal, "LabelWindowOpened", "label '%s' window opened", key)
+		return
+	}
+	r.Recorder.Eventf(namespaceLabel, corev1.EventTypeNormal, "LabelWindowClosed", "label '%s' window closed", key)
+}
+
+// labelRuleExpiry returns when a label rule expires. The expiry of an expiresAfter rule is kept from status
+// once recorded, so it counts from when the rule was first applied.
+func labelRuleExpiry(namespaceLabel *danav1alpha1.NamespaceLabel, rule danav1alpha1.LabelRule,
+	now time.Time) (metav1.Time, bool) {
+	switch {
+	case rule.ExpiresAt != nil:
+		return *rule.ExpiresAt, true
+	case rule.ExpiresAfter != nil:
+		if expiresAt, recorded := namespaceLabel.Status.LabelExpirations[rule.Key]; recorded {
+			return expiresAt, true
+		}
+		return metav1.NewTime(now.Add(rule.ExpiresAfter.Duration)), true
+	}
+	return metav1.Time{}, false
+}
+
+// earliestRequeue returns the shorter of two requeue delays, where zero means no requeue
+func earliestRequeue(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
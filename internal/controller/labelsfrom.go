@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// resolveLabelsFrom merges the data of the ConfigMaps and Secrets referenced by spec.labelsFrom into the
+// NamespaceLabel's labels, so every later step sees the labels to apply. Later sources override earlier ones
+// and spec.labels overrides them all.
+func (r *NamespaceLabelReconciler) resolveLabelsFrom(ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel) error {
+	resolved, err := namespacelabel.ResolveLabelsFrom(ctx, r, namespaceLabel)
+	if err != nil {
+		return err
+	}
+
+	namespaceLabel.Spec.Labels = resolved
+	return nil
+}
+
+// labelsSourceRequests maps a ConfigMap or Secret event to the watched NamespaceLabels in its namespace that
+// reference it through spec.labelsFrom
+func (r *NamespaceLabelReconciler) labelsSourceRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := r.List(ctx, namespaceLabels, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list NamespaceLabels for labels source", "Namespace", obj.GetNamespace(),
+			"Name", obj.GetName())
+		return nil
+	}
+
+	_, isSecret := obj.(*corev1.Secret)
+	var requests []reconcile.Request
+	for i := range namespaceLabels.Items {
+		nl := &namespaceLabels.Items[i]
+		if !namespacelabel.IsWatched(r.WatchSelector, nl) {
+			continue
+		}
+		for _, source := range nl.Spec.LabelsFrom {
+			ref := source.ConfigMapRef
+			if isSecret {
+				ref = source.SecretRef
+			}
+			if ref != nil && ref.Name == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: nl.Namespace, Name: nl.Name},
+				})
+				break
+			}
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// labelClaim is the value a NamespaceLabel holds for a label key on its Namespace
+type labelClaim struct {
+	value string
+	owner *danav1alpha1.NamespaceLabel
+}
+
+// siblingNamespaceLabels returns the other NamespaceLabels labeling the same Namespace when several are
+// allowed per namespace. Fan-out NamespaceLabels label other Namespaces and are not siblings.
+func (r *NamespaceLabelReconciler) siblingNamespaceLabels(
+	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel) ([]danav1alpha1.NamespaceLabel, error) {
+	if !r.AllowMultiple {
+		return nil, nil
+	}
+
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := r.List(ctx, namespaceLabels, client.InNamespace(namespaceLabel.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var siblings []danav1alpha1.NamespaceLabel
+	for _, nl := range namespaceLabels.Items {
+		if nl.Name == namespaceLabel.Name || !nl.DeletionTimestamp.IsZero() || len(nl.Spec.TargetNamespaces) > 0 {
+			continue
+		}
+		siblings = append(siblings, nl)
+	}
+	return siblings, nil
+}
+
+// outranks reports whether a NamespaceLabel wins a label key over another: the higher priority wins, and
+// within equal priority the most recently created one, with the name breaking exact ties
+func outranks(a, b *danav1alpha1.NamespaceLabel) bool {
+	if a.Spec.Priority != b.Spec.Priority {
+		return a.Spec.Priority > b.Spec.Priority
+	}
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return b.CreationTimestamp.Before(&a.CreationTimestamp)
+	}
+	return a.Name > b.Name
+}
+
+// siblingClaims resolves the labels the siblings currently hold on the Namespace. Siblings are represented
+// by the labels they last applied rather than their spec, so labels are only ever applied after their own
+// NamespaceLabel validated them; drifted labels are left to the external manager.
+func siblingClaims(siblings []danav1alpha1.NamespaceLabel) map[string]labelClaim {
+	claims := make(map[string]labelClaim)
+	for i := range siblings {
+		sibling := &siblings[i]
+		for key, value := range sibling.Status.AppliedLabels {
+			if _, drifted := sibling.Status.DriftedLabels[key]; drifted {
+				continue
+			}
+			if claim, claimed := claims[key]; claimed && !outranks(sibling, claim.owner) {
+				continue
+			}
+			claims[key] = labelClaim{value: value, owner: sibling}
+		}
+	}
+	return claims
+}
+
+// managedLabelCount is the number of labels the NamespaceLabels of a Namespace manage on it together: the labels
+// applied and the drifted labels of any of them, each counted once
+func managedLabelCount(applied, drifted map[string]string, siblings []danav1alpha1.NamespaceLabel) int {
+	keys := make(map[string]struct{}, len(applied)+len(drifted))
+	for key := range applied {
+		keys[key] = struct{}{}
+	}
+	for key := range drifted {
+		keys[key] = struct{}{}
+	}
+	for i := range siblings {
+		for key := range siblings[i].Status.DriftedLabels {
+			keys[key] = struct{}{}
+		}
+	}
+	return len(keys)
+}
+
+// setConflictCondition reports the declared labels held by higher-ranked siblings with a different value and
+// records a Warning Event when the conflict is new
+func (r *NamespaceLabelReconciler) setConflictCondition(namespaceLabel *danav1alpha1.NamespaceLabel, conflicts []string) {
+	if len(conflicts) == 0 {
+		meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "Conflicted")
+		return
+	}
+
+	sort.Strings(conflicts)
+	message := strings.Join(conflicts, "; ")
+	if condition := meta.FindStatusCondition(namespaceLabel.Status.Conditions, "Conflicted"); condition == nil ||
+		condition.Message != message {
+		if r.Recorder != nil {
+			r.Recorder.Event(namespaceLabel, corev1.EventTypeWarning, "LabelConflict", message)
+		}
+	}
+
+	namespaceLabel.Status.Conditions = updateNewCondition(namespaceLabel.Status.Conditions, metav1.Condition{
+		Type:               "Conflicted",
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "LabelConflict",
+		Message:            message,
+	})
+}
+
+// conflictMessage describes a declared label held by a higher-ranked sibling with a different value
+func conflictMessage(key string, claim labelClaim) string {
+	return fmt.Sprintf("label '%s' is held by NamespaceLabel '%s' (priority %d) with value '%s'",
+		key, claim.owner.Name, claim.owner.Spec.Priority, claim.value)
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// sourceNamespaceLabel is the source label value for labels managed through NamespaceLabels
+const sourceNamespaceLabel = "namespacelabel"
+
+// sourceClusterNamespaceLabel is the source label value for labels managed through ClusterNamespaceLabels
+const sourceClusterNamespaceLabel = "clusternamespacelabel"
+
+var (
+	// labelsAdded counts labels newly added to namespaces
+	labelsAdded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "namespacelabel_labels_added_total",
+			Help: "Number of labels added to namespaces",
+		},
+		[]string{"source"},
+	)
+
+	// labelsUpdated counts existing namespace labels whose value was changed
+	labelsUpdated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "namespacelabel_labels_updated_total",
+			Help: "Number of namespace labels whose value was changed",
+		},
+		[]string{"source"},
+	)
+
+	// labelsRemoved counts labels removed from namespaces
+	labelsRemoved = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "namespacelabel_labels_removed_total",
+			Help: "Number of labels removed from namespaces",
+		},
+		[]string{"source"},
+	)
+
+	// labelsRejected counts declared labels that were not applied because the spec failed validation
+	labelsRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "namespacelabel_labels_rejected_total",
+			Help: "Number of declared labels not applied because their spec failed validation",
+		},
+		[]string{"source", "reason"},
+	)
+
+	// specRejections counts NamespaceLabel specs the controller refused to apply, once per generation
+	specRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "namespacelabel_rejections_total",
+			Help: "Number of NamespaceLabel specs rejected for breaking the admission rules",
+		},
+		[]string{"source", "reason"},
+	)
+
+	// orphanedLabelsRemoved counts labels removed from namespaces because their owning NamespaceLabel is gone
+	orphanedLabelsRemoved = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "namespacelabel_orphaned_labels_removed_total",
+			Help: "Number of labels removed from namespaces because their owning NamespaceLabel no longer exists",
+		},
+	)
+
+	// notificationsSent counts label change notifications by event and whether they were delivered
+	notificationsSent = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "namespacelabel_notifications_total",
+			Help: "Number of label change notifications delivered, failed after all retries or dropped",
+		},
+		[]string{"event", "result"},
+	)
+
+	// managedLabels is the number of labels the NamespaceLabels of each namespace manage on it
+	managedLabels = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "namespacelabel_managed_labels",
+			Help: "Number of labels managed on a namespace by its NamespaceLabels",
+		},
+		[]string{"namespace"},
+	)
+
+	// namespacePatchLatency measures the time from the start of a reconciliation to the Namespace writes
+	// applying it
+	namespacePatchLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "namespacelabel_namespace_patch_latency_seconds",
+			Help:    "Time from the start of a reconciliation until its labels were written to the namespaces",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"source"},
+	)
+
+	// undeclaredManagedLabels counts the labels under the managed prefix that no NamespaceLabel declares
+	undeclaredManagedLabels = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "namespacelabel_undeclared_managed_labels",
+			Help: "Number of labels under the managed prefix on a namespace that no NamespaceLabel declares",
+		},
+		[]string{"namespace"},
+	)
+	// staleNamespaceLabels flags NamespaceLabels whose labels have not been applied within the stale threshold
+	staleNamespaceLabels = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "namespacelabel_stale",
+			Help: "Whether a NamespaceLabel has not been applied within the stale threshold (1) or not (0)",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	// writeBudgetSaturation is the share of the Namespace write budget currently used up
+	writeBudgetSaturation = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "namespacelabel_write_budget_saturation",
+			Help: "Share of the Namespace write budget in use, from 0 (idle) to 1 (exhausted)",
+		},
+	)
+
+	// writeBudgetThrottled counts reconciliations requeued because the write budget was exhausted
+	writeBudgetThrottled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "namespacelabel_write_budget_throttled_total",
+			Help: "Number of reconciliations requeued because the Namespace write budget was exhausted",
+		},
+	)
+
+	// namespaceRequeuesThrottled counts requeues delayed by the per-namespace rate limit. Queue depth and
+	// worker usage are reported by the controller-runtime workqueue_depth and
+	// controller_runtime_active_workers metrics, labeled by controller name.
+	namespaceRequeuesThrottled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "namespacelabel_namespace_requeues_throttled_total",
+			Help: "Number of requeues delayed by the per-namespace rate limit",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(labelsAdded, labelsUpdated, labelsRemoved, labelsRejected, specRejections,
+		orphanedLabelsRemoved, notificationsSent, managedLabels, namespacePatchLatency, undeclaredManagedLabels,
+		staleNamespaceLabels, writeBudgetSaturation, writeBudgetThrottled, namespaceRequeuesThrottled)
+}
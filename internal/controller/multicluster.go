@@ -0,0 +1,396 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// DefaultKubeconfigKey is the Secret key a member cluster's kubeconfig is read from unless its reference names
+// another
+const DefaultKubeconfigKey = "kubeconfig"
+
+// conditionClustersSynced is True once the labels are synced to every member cluster
+const conditionClustersSynced = "ClustersSynced"
+
+// MemberClusters syncs labels to the Namespaces of the member clusters of a hub-spoke fleet, reached through
+// kubeconfigs stored in Secrets of the hub. Clients are cached per Secret and rebuilt when the Secret changes.
+// A nil MemberClusters disables multi-cluster mode.
+type MemberClusters struct {
+	// Reader reads the kubeconfig Secrets
+	Reader client.Reader
+	// Scheme is the scheme of the member cluster clients
+	Scheme *runtime.Scheme
+	// ProtectedNamespaces are the member cluster Namespaces never labeled; nil protects
+	// namespacelabel.DefaultProtectedNamespaces
+	ProtectedNamespaces *namespacelabel.ProtectedNamespaces
+	// Timeout bounds every request to a member cluster; zero leaves them unbounded
+	Timeout time.Duration
+	// NewClient builds the client of a member cluster; nil uses client.New
+	NewClient func(config *rest.Config, options client.Options) (client.Client, error)
+
+	mu      sync.Mutex
+	clients map[types.NamespacedName]cachedMemberClient
+}
+
+// cachedMemberClient is a member cluster client built from a version of a kubeconfig Secret
+type cachedMemberClient struct {
+	resourceVersion string
+	key             string
+	client          client.Client
+}
+
+// Sync applies labels to the Namespaces targeted in each member cluster and removes them from the Namespaces,
+// and the clusters, no longer targeted. Clusters without a namespaceSelector label the namesakes of the hub
+// Namespaces in names. owner identifies the hub object in the field manager, so objects syncing to the same
+// remote Namespace own their labels separately. A failing cluster is reported in its status and the returned
+// error without holding up the others.
+func (m *MemberClusters) Sync(ctx context.Context, owner, namespace string, clusters []danav1alpha1.MemberCluster,
+	previous []danav1alpha1.MemberClusterStatus, names []string,
+	labels map[string]string) ([]danav1alpha1.MemberClusterStatus, error) {
+	if m == nil {
+		if len(clusters) == 0 {
+			return nil, nil
+		}
+		statuses := make([]danav1alpha1.MemberClusterStatus, 0, len(clusters))
+		for _, cluster := range clusters {
+			statuses = append(statuses, danav1alpha1.MemberClusterStatus{
+				Name:                cluster.Name,
+				KubeconfigSecretRef: kubeconfigSecretRef(cluster.KubeconfigSecretRef, namespace),
+				Message:             "multi-cluster mode is disabled; start the controller with --enable-multi-cluster",
+			})
+		}
+		return statuses, nil
+	}
+
+	fieldManager := memberFieldManager(owner)
+	var statuses []danav1alpha1.MemberClusterStatus
+	var errs []error
+	for _, cluster := range clusters {
+		last := findMemberClusterStatus(previous, cluster.Name)
+		status, err := m.syncCluster(ctx, fieldManager, namespace, cluster, last, names, labels)
+		statuses = append(statuses, status)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cluster '%s': %w", cluster.Name, err))
+		}
+	}
+
+	// Clusters dropped from the spec are cleaned up through the Secret they were last reached with
+	for _, last := range previous {
+		if slices.ContainsFunc(clusters, func(cluster danav1alpha1.MemberCluster) bool {
+			return cluster.Name == last.Name
+		}) {
+			continue
+		}
+		if err := m.cleanUpCluster(ctx, fieldManager, last); err != nil {
+			last.Synced, last.Message = false, fmt.Sprintf("removing labels: %v", err)
+			statuses = append(statuses, last)
+			errs = append(errs, fmt.Errorf("cluster '%s': %w", last.Name, err))
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses, errors.Join(errs...)
+}
+
+// syncCluster applies the labels to the Namespaces targeted in a member cluster and removes them from the ones
+// it labeled before and no longer targets. The status of a failed sync keeps every Namespace that may carry
+// the labels, so the next sync still cleans them up.
+func (m *MemberClusters) syncCluster(ctx context.Context, fieldManager, namespace string,
+	cluster danav1alpha1.MemberCluster, last *danav1alpha1.MemberClusterStatus, names []string,
+	labels map[string]string) (danav1alpha1.MemberClusterStatus, error) {
+	status := danav1alpha1.MemberClusterStatus{
+		Name:                cluster.Name,
+		KubeconfigSecretRef: kubeconfigSecretRef(cluster.KubeconfigSecretRef, namespace),
+	}
+	var labeledBefore []string
+	if last != nil {
+		labeledBefore = last.LabeledNamespaces
+		status.LastSyncTime = last.LastSyncTime
+	}
+	var labeled []string
+	fail := func(err error) (danav1alpha1.MemberClusterStatus, error) {
+		status.LabeledNamespaces = slices.Clone(labeledBefore)
+		for _, name := range labeled {
+			if !slices.Contains(status.LabeledNamespaces, name) {
+				status.LabeledNamespaces = append(status.LabeledNamespaces, name)
+			}
+		}
+		sort.Strings(status.LabeledNamespaces)
+		status.Message = err.Error()
+		return status, err
+	}
+
+	memberClient, err := m.client(ctx, status.KubeconfigSecretRef)
+	if err != nil {
+		return fail(err)
+	}
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	targets, err := m.targetNamespaces(ctx, memberClient, cluster.NamespaceSelector, names)
+	if err != nil {
+		return fail(err)
+	}
+
+	now := time.Now()
+	for _, ns := range targets {
+		rendered, err := namespacelabel.RenderLabels(labels, ns, now)
+		if err != nil {
+			return fail(fmt.Errorf("namespace '%s': %w", ns.Name, err))
+		}
+		if err := applyMemberNamespace(ctx, memberClient, fieldManager, ns.Name, rendered); err != nil {
+			return fail(fmt.Errorf("namespace '%s': %w", ns.Name, err))
+		}
+		labeled = append(labeled, ns.Name)
+	}
+	for _, name := range labeledBefore {
+		if slices.Contains(labeled, name) {
+			continue
+		}
+		if err := applyMemberNamespace(ctx, memberClient, fieldManager, name, nil); client.IgnoreNotFound(err) != nil {
+			return fail(fmt.Errorf("namespace '%s': %w", name, err))
+		}
+	}
+
+	sort.Strings(labeled)
+	syncTime := metav1.NewTime(now)
+	status.LabeledNamespaces, status.Synced, status.LastSyncTime = labeled, true, &syncTime
+	return status, nil
+}
+
+// cleanUpCluster removes the labels from the Namespaces a member cluster was last labeled in. A cluster whose
+// Secret is gone can no longer be reached and is left as it is.
+func (m *MemberClusters) cleanUpCluster(ctx context.Context, fieldManager string,
+	last danav1alpha1.MemberClusterStatus) error {
+	memberClient, err := m.client(ctx, last.KubeconfigSecretRef)
+	if apierrors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Kubeconfig Secret is gone, leaving the member cluster's labels in place",
+			"cluster", last.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	for _, name := range last.LabeledNamespaces {
+		if err := applyMemberNamespace(ctx, memberClient, fieldManager, name, nil); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("namespace '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// targetNamespaces returns the member cluster Namespaces the selector selects, or the existing namesakes of
+// the hub Namespaces without one; protected and terminating Namespaces are never targeted
+func (m *MemberClusters) targetNamespaces(ctx context.Context, memberClient client.Client,
+	selector *metav1.LabelSelector, names []string) ([]*corev1.Namespace, error) {
+	var candidates []*corev1.Namespace
+	if selector == nil {
+		for _, name := range names {
+			ns := &corev1.Namespace{}
+			if err := memberClient.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return nil, err
+			}
+			candidates = append(candidates, ns)
+		}
+	} else {
+		labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		namespaces := &corev1.NamespaceList{}
+		if err := memberClient.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: labelSelector}); err != nil {
+			return nil, err
+		}
+		for i := range namespaces.Items {
+			candidates = append(candidates, &namespaces.Items[i])
+		}
+	}
+
+	targets := candidates[:0]
+	for _, ns := range candidates {
+		if ns.DeletionTimestamp.IsZero() && !m.ProtectedNamespaces.IsProtected(ns) {
+			targets = append(targets, ns)
+		}
+	}
+	return targets, nil
+}
+
+// client returns the client of the member cluster whose kubeconfig the Secret holds
+func (m *MemberClusters) client(ctx context.Context,
+	ref danav1alpha1.KubeconfigSecretReference) (client.Client, error) {
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	if err := m.Reader.Get(ctx, key, secret); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cached, exists := m.clients[key]; exists && cached.resourceVersion == secret.ResourceVersion &&
+		cached.key == ref.Key {
+		return cached.client, nil
+	}
+
+	dataKey := ref.Key
+	if dataKey == "" {
+		dataKey = DefaultKubeconfigKey
+	}
+	kubeconfig, exists := secret.Data[dataKey]
+	if !exists {
+		return nil, fmt.Errorf("secret '%s' has no key '%s'", key, dataKey)
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig in secret '%s': %w", key, err)
+	}
+	newClient := m.NewClient
+	if newClient == nil {
+		newClient = client.New
+	}
+	memberClient, err := newClient(config, client.Options{Scheme: m.Scheme})
+	if err != nil {
+		return nil, err
+	}
+
+	if m.clients == nil {
+		m.clients = make(map[types.NamespacedName]cachedMemberClient)
+	}
+	m.clients[key] = cachedMemberClient{resourceVersion: secret.ResourceVersion, key: ref.Key, client: memberClient}
+	return memberClient, nil
+}
+
+func (m *MemberClusters) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, m.Timeout)
+}
+
+// applyMemberNamespace server-side applies labels to an existing member cluster Namespace; the labels the
+// field manager applied before and omits are removed by the API server. The Namespace is read first so an
+// apply never creates it.
+func applyMemberNamespace(ctx context.Context, memberClient client.Client, fieldManager, name string,
+	labels map[string]string) error {
+	if err := memberClient.Get(ctx, types.NamespacedName{Name: name}, &corev1.Namespace{}); err != nil {
+		return err
+	}
+	applied := &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	}
+	return memberClient.Patch(ctx, applied, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
+// memberFieldManager returns the field manager a hub object applies member cluster labels with, hashing owners
+// too long for the API server's limit of 128 characters
+func memberFieldManager(owner string) string {
+	fieldManager := namespacelabel.FieldManager + "/" + owner
+	if len(fieldManager) > 128 {
+		sum := sha256.Sum256([]byte(owner))
+		fieldManager = namespacelabel.FieldManager + "/" + hex.EncodeToString(sum[:16])
+	}
+	return fieldManager
+}
+
+// kubeconfigSecretRef defaults the namespace of a kubeconfig Secret reference
+func kubeconfigSecretRef(ref danav1alpha1.KubeconfigSecretReference,
+	namespace string) danav1alpha1.KubeconfigSecretReference {
+	if ref.Namespace == "" {
+		ref.Namespace = namespace
+	}
+	return ref
+}
+
+func findMemberClusterStatus(statuses []danav1alpha1.MemberClusterStatus,
+	name string) *danav1alpha1.MemberClusterStatus {
+	for i := range statuses {
+		if statuses[i].Name == name {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// setClustersSyncedCondition reports whether the labels are synced to every member cluster, and drops the
+// condition once no member clusters are declared
+func setClustersSyncedCondition(conditions *[]metav1.Condition, generation int64,
+	statuses []danav1alpha1.MemberClusterStatus) {
+	if len(statuses) == 0 {
+		meta.RemoveStatusCondition(conditions, conditionClustersSynced)
+		return
+	}
+
+	status, reason := metav1.ConditionTrue, "Synced"
+	message := fmt.Sprintf("labels synced to %d member clusters", len(statuses))
+	var failed []string
+	for _, cluster := range statuses {
+		if !cluster.Synced {
+			failed = append(failed, fmt.Sprintf("%s: %s", cluster.Name, cluster.Message))
+		}
+	}
+	if len(failed) > 0 {
+		status, reason = metav1.ConditionFalse, "SyncFailed"
+		message = fmt.Sprintf("labels not synced to %d of %d member clusters: %s", len(failed), len(statuses),
+			failed[0])
+	}
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type: conditionClustersSynced, Status: status, ObservedGeneration: generation, Reason: reason,
+		Message: message,
+	})
+}
+
+// syncMemberClusters syncs the labels applied to a NamespaceLabel's Namespace to its member clusters and
+// reports them in its status
+func (r *NamespaceLabelReconciler) syncMemberClusters(ctx context.Context,
+	namespaceLabel *danav1alpha1.NamespaceLabel, namespace string, labels map[string]string) error {
+	statuses, err := r.MemberClusters.Sync(ctx, namespaceLabelOwner(namespaceLabel), namespaceLabel.Namespace,
+		namespaceLabel.Spec.Clusters, namespaceLabel.Status.Clusters, []string{namespace}, labels)
+	namespaceLabel.Status.Clusters = statuses
+	setClustersSyncedCondition(&namespaceLabel.Status.Conditions, namespaceLabel.Generation, statuses)
+	return err
+}
+
+// unsyncMemberClusters removes the labels of a NamespaceLabel being deleted from its member clusters
+func (r *NamespaceLabelReconciler) unsyncMemberClusters(ctx context.Context,
+	namespaceLabel *danav1alpha1.NamespaceLabel) error {
+	_, err := r.MemberClusters.Sync(ctx, namespaceLabelOwner(namespaceLabel), namespaceLabel.Namespace, nil,
+		namespaceLabel.Status.Clusters, nil, nil)
+	return err
+}
+
+// namespaceLabelOwner identifies a NamespaceLabel in the field manager of its member cluster labels
+func namespaceLabelOwner(namespaceLabel *danav1alpha1.NamespaceLabel) string {
+	return "namespacelabel/" + namespaceLabel.Namespace + "/" + namespaceLabel.Name
+}
+
+// clusterNamespaceLabelOwner identifies a ClusterNamespaceLabel in the field manager of its member cluster labels
+func clusterNamespaceLabelOwner(clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel) string {
+	return "clusternamespacelabel/" + clusterNamespaceLabel.Name
+}
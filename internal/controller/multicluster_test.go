@@ -0,0 +1,198 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+const memberKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: spoke
+  cluster:
+    server: https://spoke.example.com
+contexts:
+- name: spoke
+  context:
+    cluster: spoke
+    user: spoke
+current-context: spoke
+users:
+- name: spoke
+  user:
+    token: secret-token
+`
+
+var _ = Describe("Member clusters", func() {
+	const namespaceName = "default"
+	namespacedName := types.NamespacedName{Name: "test-resource", Namespace: namespaceName}
+	var memberClient client.Client
+	var controllerReconciler *NamespaceLabelReconciler
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		createNamespace(namespaceName)
+
+		memberClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tier": "web"}}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+			).
+			WithInterceptorFuncs(interceptor.Funcs{Patch: newApplyEmulator().patch}).Build()
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "spoke-1", Namespace: namespaceName},
+			Data:       map[string][]byte{DefaultKubeconfigKey: []byte(memberKubeconfig)},
+		})).To(Succeed())
+
+		controllerReconciler = &NamespaceLabelReconciler{
+			Client: k8sClient,
+			Scheme: scheme,
+			Log:    zap.New(zap.UseDevMode(true)),
+			MemberClusters: &MemberClusters{
+				Reader: k8sClient,
+				Scheme: scheme,
+				NewClient: func(config *rest.Config, _ client.Options) (client.Client, error) {
+					Expect(config.Host).To(Equal("https://spoke.example.com"))
+					return memberClient, nil
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		deleteAllNamespaceLabels()
+		deleteNamespace(namespaceName)
+	})
+
+	memberLabels := func(name string) map[string]string {
+		ns := &corev1.Namespace{}
+		Expect(memberClient.Get(ctx, types.NamespacedName{Name: name}, ns)).To(Succeed())
+		return ns.Labels
+	}
+
+	It("should sync the labels to the namesake Namespace and clean them up", func() {
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespaceName},
+			Spec: danav1alpha1.NamespaceLabelSpec{
+				Labels: map[string]string{"label_1": "a", "label_2": "b"},
+				Clusters: []danav1alpha1.MemberCluster{
+					{Name: "spoke-1", KubeconfigSecretRef: danav1alpha1.KubeconfigSecretReference{Name: "spoke-1"}},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(memberLabels(namespaceName)).To(Equal(map[string]string{"label_1": "a", "label_2": "b"}))
+		Expect(memberLabels("team-a")).To(Equal(map[string]string{"tier": "web"}))
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		Expect(namespaceLabel.Status.Clusters).To(HaveLen(1))
+		Expect(namespaceLabel.Status.Clusters[0].Synced).To(BeTrue())
+		Expect(namespaceLabel.Status.Clusters[0].LabeledNamespaces).To(Equal([]string{namespaceName}))
+		Expect(meta.IsStatusConditionTrue(namespaceLabel.Status.Conditions, conditionClustersSynced)).To(BeTrue())
+
+		By("dropping a label from the spec")
+		delete(namespaceLabel.Spec.Labels, "label_2")
+		Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(memberLabels(namespaceName)).To(Equal(map[string]string{"label_1": "a"}))
+
+		By("deleting the NamespaceLabel")
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(memberLabels(namespaceName)).To(BeEmpty())
+	})
+
+	It("should label the selected Namespaces and unlabel the ones no longer selected", func() {
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespaceName},
+			Spec: danav1alpha1.NamespaceLabelSpec{
+				Labels: map[string]string{"label_1": "a"},
+				Clusters: []danav1alpha1.MemberCluster{{
+					Name:                "spoke-1",
+					KubeconfigSecretRef: danav1alpha1.KubeconfigSecretReference{Name: "spoke-1"},
+					NamespaceSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "web"}},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(memberLabels("team-a")).To(HaveKeyWithValue("label_1", "a"))
+		Expect(memberLabels("team-b")).To(BeEmpty())
+		Expect(memberLabels(namespaceName)).To(BeEmpty())
+
+		By("dropping the cluster from the spec")
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		namespaceLabel.Spec.Clusters = nil
+		Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(memberLabels("team-a")).To(Equal(map[string]string{"tier": "web"}))
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		Expect(namespaceLabel.Status.Clusters).To(BeEmpty())
+		Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionClustersSynced)).To(BeNil())
+	})
+
+	It("should report an unreachable cluster without holding up the Namespace", func() {
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespaceName},
+			Spec: danav1alpha1.NamespaceLabelSpec{
+				Labels: map[string]string{"label_1": "a"},
+				Clusters: []danav1alpha1.MemberCluster{
+					{Name: "spoke-2", KubeconfigSecretRef: danav1alpha1.KubeconfigSecretReference{Name: "missing"}},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).To(HaveOccurred())
+
+		namespace := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+		Expect(namespace.Labels).To(HaveKeyWithValue("label_1", "a"))
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(namespaceLabel.Status.Conditions, "LabelsApplied")).To(BeTrue())
+		Expect(namespaceLabel.Status.Clusters).To(HaveLen(1))
+		Expect(namespaceLabel.Status.Clusters[0].Synced).To(BeFalse())
+		Expect(namespaceLabel.Status.Clusters[0].Message).To(ContainSubstring("not found"))
+		Expect(meta.IsStatusConditionFalse(namespaceLabel.Status.Conditions, conditionClustersSynced)).To(BeTrue())
+	})
+
+	It("should refuse kubeconfig Secrets outside the NamespaceLabel's namespace", func() {
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespaceName},
+			Spec: danav1alpha1.NamespaceLabelSpec{
+				Labels: map[string]string{"label_1": "a"},
+				Clusters: []danav1alpha1.MemberCluster{{
+					Name:                "spoke-1",
+					KubeconfigSecretRef: danav1alpha1.KubeconfigSecretReference{Namespace: "kube-system", Name: "spoke-1"},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionRejected)).To(And(
+			Not(BeNil()), HaveField("Message", ContainSubstring("may only reference Secrets in namespace 'default'"))))
+	})
+})
@@ -18,19 +18,37 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
 	"github.com/go-logr/logr"
 )
 
@@ -39,17 +57,87 @@ type NamespaceLabelReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// WatchSelector restricts the controller to NamespaceLabels whose labels match it; nil matches all
+	WatchSelector labels.Selector
+	// Partitioner restricts the controller to the namespaces in this instance's partition; nil manages all
+	Partitioner *Partitioner
+	// IgnoreDriftKeys are label keys whose external changes are recorded as drift instead of reverted
+	IgnoreDriftKeys []string
+	// IgnoreDriftNamespaces are namespaces whose external label changes are recorded as drift instead of reverted
+	IgnoreDriftNamespaces []string
+	// StrictMode fails reconciliation when the Namespace carries labels under ManagedLabelPrefix
+	// that no NamespaceLabel declares
+	StrictMode bool
+	// ManagedLabelPrefix is the label prefix reserved for labels declared through NamespaceLabels
+	ManagedLabelPrefix string
+	// StaleThreshold is how long labels may remain unapplied before the NamespaceLabel is marked Stale;
+	// zero disables the check
+	StaleThreshold time.Duration
+	// MapNamespace maps a NamespaceLabel's namespace to the Namespace that receives its labels; nil labels
+	// the NamespaceLabel's own namespace
+	MapNamespace namespacelabel.NamespaceMapper
+	// AdminNamespaces are the namespaces whose NamespaceLabels may label other Namespaces through
+	// targetNamespaces
+	AdminNamespaces []string
+	// ProtectedLabels are the label keys NamespaceLabels may not set; nil protects the Kubernetes management labels
+	ProtectedLabels *namespacelabel.ProtectedLabels
+	// ProtectedAnnotations are the annotation keys NamespaceLabels may not set; nil protects
+	// namespacelabel.DefaultProtectedAnnotationPrefixes
+	ProtectedAnnotations *namespacelabel.ProtectedLabels
+	// ProtectedNamespaces are the Namespaces never labeled; nil protects namespacelabel.DefaultProtectedNamespaces
+	ProtectedNamespaces *namespacelabel.ProtectedNamespaces
+	// PolicySource provides the LabelPolicies checked before labels are applied; nil reads the LabelPolicy
+	// objects in the cluster
+	PolicySource validation.PolicySource
+	// Quota caps the labels NamespaceLabels may declare and manage on a Namespace, on top of the LabelPolicy
+	// limits
+	Quota validation.LabelQuota
+	// Recorder records Events on NamespaceLabels and Namespaces
+	Recorder record.EventRecorder
+	// NamespaceEvents records an Event on the Namespace for every managed label change
+	NamespaceEvents bool
+	// AllowMultiple allows several NamespaceLabels per namespace, merging their labels by priority
+	AllowMultiple bool
+	// ResyncTrigger queues NamespaceLabels for immediate reconciliation on request; nil disables it
+	ResyncTrigger *ResyncTrigger
+	// WriteBudget limits the rate of Namespace writes; nil writes without limit
+	WriteBudget *WriteBudget
+	// Auditor writes an audit record of every label change; nil audits nothing
+	Auditor *Auditor
+	// Notifier sends a notification of every label change; nil sends none
+	Notifier *Notifier
+	// ResyncPeriod re-applies the labels of NamespaceLabels without a resyncInterval this often, healing changes
+	// no watch event reported; zero re-applies them only on watch events
+	ResyncPeriod time.Duration
+	// MaxConcurrentReconciles is the number of NamespaceLabels reconciled in parallel; defaults to 1
+	MaxConcurrentReconciles int
+	// RateLimiter spaces out the requeues of failed reconciliations and reports their number in
+	// status.retryCount; nil uses the controller-runtime default
+	RateLimiter workqueue.RateLimiter
+	// MemberClusters syncs the labels to the member clusters listed in spec.clusters; nil disables
+	// multi-cluster mode
+	MemberClusters *MemberClusters
+	// OptInAnnotation is the annotation a namespace must carry set to "true" for its NamespaceLabels to be
+	// honored; empty honors NamespaceLabels in every namespace
+	OptInAnnotation string
 }
 
 const (
-	finalizerName         = "namespacelabel.finalizers.dana.io/finalizer"
-	managementLabelPrefix = "kubernetes.io"
+	finalizerName = "namespacelabel.finalizers.dana.io/finalizer"
+	// fieldManager owns the labels and annotations the controller server-side applies to Namespaces
+	fieldManager = namespacelabel.FieldManager
+	// statusAnnotation holds a compact summary of the labels managed on the Namespace
+	statusAnnotation = namespacelabel.ControllerAnnotationPrefix + "status"
 )
 
 // +kubebuilder:rbac:groups=dana.dana.io,resources=namespacelabels,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=dana.dana.io,resources=namespacelabels/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=dana.dana.io,resources=namespacelabels/finalizers,verbs=update
-// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
 
 func (r *NamespaceLabelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -57,6 +145,7 @@ func (r *NamespaceLabelReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	log := log.FromContext(ctx)
 
 	log.Info("Starting reconciliation for NamespaceLabel", "Namespace", req.Namespace, "Name", req.Name)
+	start := time.Now()
 
 	// Fetch the NamespaceLabel instance
 	namespaceLabel := &danav1alpha1.NamespaceLabel{}
@@ -66,14 +155,80 @@ func (r *NamespaceLabelReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	log.Info("Fetched NamespaceLabel", "NamespaceLabel", namespaceLabel)
 
+	if !namespacelabel.IsWatched(r.WatchSelector, namespaceLabel) {
+		log.Info("Skipping NamespaceLabel not watched by this instance")
+		return ctrl.Result{}, nil
+	}
+
+	if namespaceLabel.Spec.Suspend {
+		return r.suspend(ctx, namespaceLabel)
+	}
+	meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "Suspended")
+	if !namespaceLabel.Spec.DryRun {
+		clearDryRun(namespaceLabel)
+	}
+
+	// In opt-in mode NamespaceLabels in namespaces cluster admins did not opt in are left alone
+	if r.OptInAnnotation != "" {
+		own := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: namespaceLabel.Namespace}, own); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		} else if err == nil && !namespacelabel.OptedIn(r.OptInAnnotation, own) {
+			return r.skipNotOptedIn(ctx, namespaceLabel)
+		}
+	}
+
+	if len(namespaceLabel.Spec.TargetNamespaces) > 0 {
+		return r.reconcileFanOut(ctx, namespaceLabel, start)
+	}
+
 	// Fetch the Namespace instance
+	target, err := namespacelabel.TargetNamespace(r.MapNamespace, req.Namespace)
+	if err != nil {
+		r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionFalse, "TargetNamespaceError", err.Error())
+		return ctrl.Result{}, err
+	}
 	ns := &corev1.Namespace{}
-	if err := r.Get(ctx, types.NamespacedName{Name: req.Namespace}, ns); err != nil {
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+	if err := r.Get(ctx, types.NamespacedName{Name: target}, ns); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		// Nothing is left to clean up locally once the Namespace is gone
+		if !namespaceLabel.DeletionTimestamp.IsZero() && controllerutil.ContainsFinalizer(namespaceLabel, finalizerName) {
+			if err := r.unsyncMemberClusters(ctx, namespaceLabel); err != nil {
+				return ctrl.Result{}, err
+			}
+			forgetStale(namespaceLabel)
+			controllerutil.RemoveFinalizer(namespaceLabel, finalizerName)
+			return ctrl.Result{}, r.Update(ctx, namespaceLabel)
+		}
+		return ctrl.Result{}, nil
 	}
 
 	log.Info("Fetched Namespace", "NamespaceLabel", ns)
 
+	if r.ProtectedNamespaces.IsProtected(ns) {
+		return r.skipProtectedNamespace(ctx, namespaceLabel, ns)
+	}
+
+	markPending(namespaceLabel)
+
+	if r.Partitioner != nil {
+		owned, conflict, err := r.Partitioner.Owns(ns)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if conflict != "" {
+			r.updateStatus(ctx, namespaceLabel, "PartitionConflict", metav1.ConditionTrue, "Conflict", conflict)
+			return ctrl.Result{RequeueAfter: r.Partitioner.RefreshInterval}, nil
+		}
+		if !owned {
+			log.Info("Skipping Namespace outside this instance's partition")
+			return ctrl.Result{}, nil
+		}
+		meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "PartitionConflict")
+	}
+
 	// Handle deletion
 	if namespaceLabel.ObjectMeta.DeletionTimestamp.IsZero() {
 		if !controllerutil.ContainsFinalizer(namespaceLabel, finalizerName) {
@@ -83,14 +238,20 @@ func (r *NamespaceLabelReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 			}
 		}
 	} else {
+		if namespaceLabel.Spec.DryRun {
+			return r.releaseDryRun(ctx, namespaceLabel)
+		}
 		if controllerutil.ContainsFinalizer(namespaceLabel, finalizerName) {
-			r.handleDeletion(ctx, namespaceLabel, ns)
+			if delay := r.WriteBudget.reserve(1); delay > 0 {
+				return ctrl.Result{RequeueAfter: delay}, nil
+			}
+			return r.handleDeletion(ctx, namespaceLabel, ns)
 		}
 
 		return ctrl.Result{}, nil
 	}
 
-	// Ensure only one NamespaceLabel per namespace
+	// Ensure only one NamespaceLabel per namespace unless several are allowed
 	existingNamespaceLabels := &danav1alpha1.NamespaceLabelList{}
 	if err := r.List(ctx, existingNamespaceLabels, client.InNamespace(req.Namespace)); err != nil {
 		return ctrl.Result{}, err
@@ -98,36 +259,434 @@ func (r *NamespaceLabelReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	log.Info("Existing NamespaceLabels", "Count", len(existingNamespaceLabels.Items))
 
-	if len(existingNamespaceLabels.Items) > 1 {
+	if len(existingNamespaceLabels.Items) > 1 && !r.AllowMultiple {
 		var err = fmt.Errorf("only one NamespaceLabel allowed per namespace")
-		r.updateStatus(ctx, namespaceLabel, "NamespaceLabelsConflict", metav1.ConditionFalse, "Conflict", err.Error())
+		retryErr := r.reject(namespaceLabel, "Conflict", rejectedSpec{err})
+		r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionFalse, "Conflict", err.Error())
+		return ctrl.Result{}, retryErr
+	}
+
+	// Label rules and sources are resolved and templated values rendered against the Namespace first, so
+	// every later step sees the values to apply
+	rules := namespaceLabel.Spec.LabelRules
+	expiry := r.resolveLabelRules(namespaceLabel, time.Now())
+	// A break-glass waiver is checked again once it lapses
+	if namespaceLabel.Annotations[namespacelabel.AdmissionBypassAnnotation] == namespacelabel.BypassBreakGlass &&
+		namespacelabel.AdmissionBypassed(namespaceLabel, time.Now()) {
+		expires, _ := namespacelabel.BreakGlassExpiry(namespaceLabel)
+		expiry = earliestRequeue(expiry, time.Until(expires))
+	}
+	err = r.resolveLabelsFrom(ctx, namespaceLabel)
+	if err == nil {
+		var rendered map[string]string
+		if rendered, err = namespacelabel.RenderLabels(namespaceLabel.Spec.Labels, ns, time.Now()); err == nil {
+			namespaceLabel.Spec.Labels = rendered
+			err = r.validateSpec(ctx, namespaceLabel, ns)
+		}
+	}
+	if err != nil {
+		r.writeStatusSummary(ctx, ns, 0, len(namespaceLabel.Spec.Labels))
+		labelsRejected.WithLabelValues(sourceNamespaceLabel, invalidReason(err)).Add(float64(len(namespaceLabel.Spec.Labels)))
+		retryErr := r.reject(namespaceLabel, invalidReason(err), err)
+		r.reportInvalid(ctx, namespaceLabel, err)
+		return ctrl.Result{}, retryErr
+	}
+	meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "Invalid")
+	meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, conditionRejected)
+	meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "QuotaExceeded")
+
+	if r.StrictMode {
+		if err := r.verifyDeclaredLabels(ctx, namespaceLabel, rules, ns); err != nil {
+			r.updateStatus(ctx, namespaceLabel, "Degraded", metav1.ConditionTrue, "UndeclaredManagedLabels", err.Error())
+			return ctrl.Result{}, err
+		}
+		meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "Degraded")
+	}
+
+	// Labels layered over other NamespaceLabels' wait until those are applied
+	if waiting, err := r.waitForDependencies(ctx, namespaceLabel); waiting || err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if !namespaceLabel.Spec.DryRun {
+		if delay := r.WriteBudget.reserve(1); delay > 0 {
+			log.Info("Namespace write budget exhausted, requeueing", "after", delay)
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
+	}
+
 	log.Info("Creating nsl")
 
 	// Reconcile the namespace labels
+	previous := namespaceLabel.Status.AppliedLabels
+	previousPending := namespaceLabel.Status.PendingChanges
+	namespaceLabel.Status.PendingChanges = nil
 	if err := r.reconcileNamespaceLabels(ctx, namespaceLabel, ns); err != nil {
-		r.updateStatus(ctx, namespaceLabel, "UpdateLabelsFailed", metav1.ConditionFalse, "UpdateError", err.Error())
+		r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionFalse, "UpdateError", err.Error())
+		return ctrl.Result{}, err
+	}
+	if namespaceLabel.Spec.DryRun {
+		r.reportDryRun(ctx, namespaceLabel, previousPending)
+		return ctrl.Result{RequeueAfter: earliestRequeue(r.resyncInterval(namespaceLabel), expiry)}, nil
+	}
+
+	// Propagate the labels this NamespaceLabel applied, except drifted ones, to the selected workloads
+	propagated := make(map[string]string, len(namespaceLabel.Status.AppliedLabels))
+	for key, value := range namespaceLabel.Status.AppliedLabels {
+		if _, drifted := namespaceLabel.Status.DriftedLabels[key]; !drifted {
+			propagated[key] = value
+		}
+	}
+	if err := r.propagateLabels(ctx, namespaceLabel, ns.Name, previous, propagated); err != nil {
+		r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionFalse, "PropagationError", err.Error())
 		return ctrl.Result{}, err
 	}
+	namespacePatchLatency.WithLabelValues(sourceNamespaceLabel).Observe(time.Since(start).Seconds())
 
+	// A failing member cluster is reported and retried without holding up the Namespace
+	syncErr := r.syncMemberClusters(ctx, namespaceLabel, ns.Name, propagated)
+
+	setDriftCondition(namespaceLabel)
 	r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionTrue, "Success", "Namespace labels have been successfully updated")
 	log.Info("nsl Created")
 
+	if syncErr != nil {
+		return ctrl.Result{}, syncErr
+	}
+	return ctrl.Result{RequeueAfter: earliestRequeue(r.resyncInterval(namespaceLabel), expiry)}, nil
+}
+
+// suspend reports a suspended NamespaceLabel without applying or removing labels. A suspended NamespaceLabel
+// being deleted releases its finalizer and leaves its labels in place.
+func (r *NamespaceLabelReconciler) suspend(ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel) (ctrl.Result, error) {
+	defer forgetStale(namespaceLabel)
+	if !namespaceLabel.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(namespaceLabel, finalizerName) {
+			controllerutil.RemoveFinalizer(namespaceLabel, finalizerName)
+			return ctrl.Result{}, r.Update(ctx, namespaceLabel)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Nothing is pending while suspended, so the NamespaceLabel does not turn Stale
+	namespaceLabel.Status.PendingSince = nil
+	r.updateStatus(ctx, namespaceLabel, "Suspended", metav1.ConditionTrue, "Suspended",
+		"reconciliation is suspended; labels are neither applied nor removed")
 	return ctrl.Result{}, nil
 }
 
-func (r *NamespaceLabelReconciler) handleDeletion(
+// skipProtectedNamespace reports a NamespaceLabel whose Namespace is protected without applying or removing
+// labels. Deleting it releases its finalizer, since nothing on the Namespace is touched.
+func (r *NamespaceLabelReconciler) skipProtectedNamespace(
 	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel, ns *corev1.Namespace) (ctrl.Result, error) {
-	// Remove labels managed by this NamespaceLabel
+	defer forgetStale(namespaceLabel)
+	if !namespaceLabel.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(namespaceLabel, finalizerName) {
+			controllerutil.RemoveFinalizer(namespaceLabel, finalizerName)
+			return ctrl.Result{}, r.Update(ctx, namespaceLabel)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	namespaceLabel.Status.PendingSince = nil
+	r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionFalse, "ProtectedNamespace",
+		fmt.Sprintf("namespace '%s' is protected and is never labeled", ns.Name))
+	return ctrl.Result{}, nil
+}
+
+// skipNotOptedIn reports a NamespaceLabel in a namespace not opted in without applying or removing labels.
+// Deleting it releases its finalizer and leaves any labels applied before the namespace opted out in place.
+func (r *NamespaceLabelReconciler) skipNotOptedIn(
+	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel) (ctrl.Result, error) {
+	defer forgetStale(namespaceLabel)
+	if !namespaceLabel.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(namespaceLabel, finalizerName) {
+			controllerutil.RemoveFinalizer(namespaceLabel, finalizerName)
+			return ctrl.Result{}, r.Update(ctx, namespaceLabel)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	namespaceLabel.Status.PendingSince = nil
+	r.updateStatus(ctx, namespaceLabel, "LabelsApplied", metav1.ConditionFalse, "NamespaceNotOptedIn",
+		fmt.Sprintf("namespace '%s' is not opted in to NamespaceLabels; a cluster admin must annotate it with %s=true",
+			namespaceLabel.Namespace, r.OptInAnnotation))
+	return ctrl.Result{}, nil
+}
+
+// validateSpec runs the webhook's admission rules before labels are applied, so specs admitted while the
+// webhook was disabled or unavailable are reported instead of silently applied. Rules the spec breaks are
+// returned as a rejectedSpec; quota errors depend on other objects too and are retried. Specs the webhook
+// waived the rules for are not checked.
+func (r *NamespaceLabelReconciler) validateSpec(
+	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel, namespaces ...*corev1.Namespace) error {
+	if namespacelabel.AdmissionBypassed(namespaceLabel, time.Now()) {
+		return nil
+	}
+	if err := validation.ValidateSpec(namespaceLabel, r.AdminNamespaces, r.ProtectedLabels,
+		r.ProtectedAnnotations); err != nil {
+		return rejectedSpec{err}
+	}
+
+	policySource := r.PolicySource
+	if policySource == nil {
+		policySource = &validation.ClusterPolicySource{Client: r.Client}
+	}
+	for _, ns := range namespaces {
+		// Templated values are checked as rendered for each Namespace
+		rendered, err := namespacelabel.RenderLabels(namespaceLabel.Spec.Labels, ns, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := validation.ValidateLabels(r.ProtectedLabels, rendered); err != nil {
+			return rejectedSpec{err}
+		}
+
+		policies, err := policySource.PoliciesFor(ctx, ns)
+		if err != nil {
+			return err
+		}
+		if err := validation.ValidatePolicies(policies, rendered); err != nil {
+			return rejectedSpec{err}
+		}
+		if err := validation.ValidateRules(policies, rendered); err != nil {
+			return rejectedSpec{err}
+		}
+		if err := r.validateQuota(ctx, namespaceLabel, ns, policies, rendered); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateQuota checks the labels of a NamespaceLabel, rendered for a Namespace, against the label quota
+func (r *NamespaceLabelReconciler) validateQuota(ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel,
+	ns *corev1.Namespace, policies []danav1alpha1.LabelPolicy, rendered map[string]string) error {
+	quota := validation.EffectiveQuota(r.Quota, policies)
+	if quota.MaxLabels == 0 && quota.MaxManagedLabels == 0 {
+		return nil
+	}
+
+	managed := map[string]struct{}{}
+	if quota.MaxManagedLabels > 0 {
+		var err error
+		if managed, err = namespacelabel.ManagedLabelKeys(ctx, r.Client, r.MapNamespace, ns, namespaceLabel); err != nil {
+			return err
+		}
+	}
+	for key := range rendered {
+		managed[key] = struct{}{}
+	}
+	return quota.Validate(ns.Name, len(rendered), len(managed))
+}
+
+// reportInvalid sets the QuotaExceeded condition for a quota error and the Invalid condition for any other
+// validateSpec error, clearing the other one
+func (r *NamespaceLabelReconciler) reportInvalid(
+	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel, err error) {
+	conditionType, cleared := "Invalid", "QuotaExceeded"
+	var exceeded *validation.QuotaExceeded
+	if errors.As(err, &exceeded) {
+		conditionType, cleared = cleared, conditionType
+	}
+	meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, cleared)
+	r.updateStatus(ctx, namespaceLabel, conditionType, metav1.ConditionTrue, invalidReason(err), err.Error())
+}
+
+// invalidReason returns the reason of the Invalid condition set for a validateSpec error, telling LabelPolicy
+// violations apart from malformed specs
+func invalidReason(err error) string {
+	var violation *validation.PolicyViolation
+	if errors.As(err, &violation) {
+		return "PolicyViolation"
+	}
+	var exceeded *validation.QuotaExceeded
+	if errors.As(err, &exceeded) {
+		return "QuotaExceeded"
+	}
+	return "ValidationFailed"
+}
+
+// resyncInterval returns how long to wait before re-applying the labels of a NamespaceLabel: its own
+// resyncInterval, or else the controller's resync period; zero means the labels are only re-applied when a
+// watch event fires
+func (r *NamespaceLabelReconciler) resyncInterval(namespaceLabel *danav1alpha1.NamespaceLabel) time.Duration {
+	if namespaceLabel.Spec.ResyncInterval != nil {
+		return namespaceLabel.Spec.ResyncInterval.Duration
+	}
+	return jitterResync(r.ResyncPeriod)
+}
+
+// jitterResync spreads a controller-wide resync period by up to 10%, so objects reconciled together are not
+// all re-applied at the same moment; zero disables the resync
+func jitterResync(period time.Duration) time.Duration {
+	if period <= 0 {
+		return 0
+	}
+	return wait.Jitter(period, 0.1)
+}
+
+// verifyDeclaredLabels ensures every label under the managed prefix on the Namespace is declared by a
+// NamespaceLabel or ClusterNamespaceLabel, so labels added out of band are surfaced instead of silently removed.
+// The reconciled NamespaceLabel's labels are already resolved; rules are its label rules before resolution, whose
+// keys stay declared while expired or outside their schedule.
+func (r *NamespaceLabelReconciler) verifyDeclaredLabels(ctx context.Context,
+	namespaceLabel *danav1alpha1.NamespaceLabel, rules []danav1alpha1.LabelRule, ns *corev1.Namespace) error {
+	declared, err := r.declaredLabelKeys(ctx, namespaceLabel, ns)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		declared[rule.Key] = struct{}{}
+	}
+
+	var undeclared []string
+	for key := range ns.Labels {
+		if _, exists := declared[key]; strings.HasPrefix(key, r.ManagedLabelPrefix) && !exists {
+			undeclared = append(undeclared, key)
+		}
+	}
+
+	undeclaredManagedLabels.WithLabelValues(ns.Name).Set(float64(len(undeclared)))
+	if len(undeclared) > 0 {
+		sort.Strings(undeclared)
+		return fmt.Errorf("namespace has managed labels not declared by any NamespaceLabel: %s", strings.Join(undeclared, ", "))
+	}
+
+	return nil
+}
+
+// declaredLabelKeys returns the label keys declared on a Namespace by the reconciled NamespaceLabel, the other
+// NamespaceLabels and fan-outs labeling it, with their label rules and sources, and the ClusterNamespaceLabels
+// selecting it. A NamespaceLabel whose sources cannot be read declares its own labels only.
+func (r *NamespaceLabelReconciler) declaredLabelKeys(ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel,
+	ns *corev1.Namespace) (map[string]struct{}, error) {
+	declared := make(map[string]struct{})
 	for key := range namespaceLabel.Spec.Labels {
-		delete(ns.Labels, key)
+		declared[key] = struct{}{}
+	}
+
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := r.List(ctx, namespaceLabels); err != nil {
+		return nil, err
+	}
+	for i := range namespaceLabels.Items {
+		nl := &namespaceLabels.Items[i]
+		if nl.Namespace == namespaceLabel.Namespace && nl.Name == namespaceLabel.Name {
+			continue
+		}
+		targets, err := namespacelabel.LabeledNamespaces(r.MapNamespace, nl)
+		if err != nil || !slices.Contains(targets, ns.Name) {
+			continue
+		}
+		labels, err := namespacelabel.ResolveLabelsFrom(ctx, r.Client, nl)
+		if err != nil {
+			labels = nl.Spec.Labels
+		}
+		for key := range labels {
+			declared[key] = struct{}{}
+		}
+		for _, rule := range nl.Spec.LabelRules {
+			declared[rule.Key] = struct{}{}
+		}
+	}
+
+	clusterLabels, err := clusterNamespaceLabels(ctx, r.Client, ns)
+	if err != nil {
+		return nil, err
+	}
+	for key := range clusterLabels {
+		declared[key] = struct{}{}
+	}
+
+	return declared, nil
+}
+
+// handleDeletion removes the labels this NamespaceLabel applied from the Namespace before releasing the
+// finalizer, so labels are cleaned up even if the controller was down when the object was deleted. Labels it
+// overrode get their previous value back.
+func (r *NamespaceLabelReconciler) handleDeletion(
+	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel, ns *corev1.Namespace) (ctrl.Result, error) {
+	// NamespaceLabels applied before tracking only know their spec
+	managed := namespaceLabel.Status.AppliedLabels
+	if managed == nil {
+		managed = namespaceLabel.Spec.Labels
+	}
+
+	// Remove labels managed by this NamespaceLabel by applying only the labels its siblings hold
+	siblings, err := r.siblingNamespaceLabels(ctx, namespaceLabel)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	var kept map[string]string
+	claims := siblingClaims(siblings)
+	for key, claim := range claims {
+		if kept == nil {
+			kept = make(map[string]string)
+		}
+		kept[key] = claim.value
+	}
+
+	var changes labelChanges
+	remove := make(map[string]struct{}, len(managed))
+	restore := make(map[string]string)
+	for key := range managed {
+		_, exists := ns.Labels[key]
+		if _, held := kept[key]; !exists || held {
+			continue
+		}
+		if original, overridden := namespaceLabel.Status.OverriddenLabels[key]; overridden {
+			restore[key] = original
+			changes.updated(key, ns.Labels[key], original)
+			continue
+		}
+		remove[key] = struct{}{}
+		changes.removed(key, ns.Labels[key])
+	}
+	var keptAnnotations map[string]string
+	for key, claim := range annotationClaims(siblings) {
+		if keptAnnotations == nil {
+			keptAnnotations = make(map[string]string)
+		}
+		keptAnnotations[key] = claim.value
 	}
-	if err := r.Update(ctx, ns); err != nil {
+	if len(kept) > 0 {
+		if keptAnnotations == nil {
+			keptAnnotations = make(map[string]string)
+		}
+		keptAnnotations[namespacelabel.OwnedLabelsAnnotation] = namespacelabel.FormatLabelOwners(
+			labelOwners(namespaceLabel, kept, nil, claims))
+	}
+	removeAnnotations := make(map[string]struct{}, len(namespaceLabel.Status.AppliedAnnotations))
+	for key := range namespaceLabel.Status.AppliedAnnotations {
+		_, exists := ns.Annotations[key]
+		if _, held := keptAnnotations[key]; exists && !held {
+			removeAnnotations[key] = struct{}{}
+		}
+	}
+	if err := r.applyNamespace(ctx, ns, kept, keptAnnotations, remove, restore, removeAnnotations); err != nil {
 		return ctrl.Result{}, err
 	}
+	labelsUpdated.WithLabelValues(sourceNamespaceLabel).Add(float64(len(restore)))
+	labelsRemoved.WithLabelValues(sourceNamespaceLabel).Add(float64(len(remove)))
+	if len(siblings) == 0 {
+		// No NamespaceLabel is left to manage or declare labels on the Namespace
+		managedLabels.DeleteLabelValues(ns.Name)
+		undeclaredManagedLabels.DeleteLabelValues(ns.Name)
+	} else {
+		managedLabels.WithLabelValues(ns.Name).Set(float64(managedLabelCount(kept, nil, siblings)))
+	}
+	r.recordLabelChanges(ctx, ns, namespaceLabel, changes)
 
+	if err := r.propagateLabels(ctx, namespaceLabel, ns.Name, managed, nil); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.unsyncMemberClusters(ctx, namespaceLabel); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	forgetStale(namespaceLabel)
 	controllerutil.RemoveFinalizer(namespaceLabel, finalizerName)
 	if err := r.Update(ctx, namespaceLabel); err != nil {
 		return ctrl.Result{}, err
@@ -136,70 +695,290 @@ func (r *NamespaceLabelReconciler) handleDeletion(
 	return ctrl.Result{}, nil
 }
 
-func isManagementLabel(label string) bool {
-	return strings.HasPrefix(label, managementLabelPrefix)
-}
-
 func (r *NamespaceLabelReconciler) reconcileNamespaceLabels(
 	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel, ns *corev1.Namespace) error {
 
 	// Track labels to be added and removed
 	labelsToAdd := make(map[string]string)
 	labelsToRemove := make(map[string]struct{})
+	drifted := make(map[string]string)
+	overridden := make(map[string]string)
+
+	// Labels declared by fan-out NamespaceLabels and ClusterNamespaceLabels are owned by administrators
+	// and left alone
+	fanOut, err := r.fanOutLabels(ctx, ns.Name)
+	if err != nil {
+		return err
+	}
+	clusterLabels, err := clusterNamespaceLabels(ctx, r.Client, ns)
+	if err != nil {
+		return err
+	}
+	for key, value := range clusterLabels {
+		fanOut[key] = value
+	}
 
-	// Collect labels to add or update
+	// Labels held by other NamespaceLabels in the namespace are kept unless this one outranks them
+	siblings, err := r.siblingNamespaceLabels(ctx, namespaceLabel)
+	if err != nil {
+		return err
+	}
+	claims := siblingClaims(siblings)
+	var conflicts []string
+
+	// Collect labels to add or update, leaving externally changed values alone where drift is ignored
 	for key, value := range namespaceLabel.Spec.Labels {
+		if _, exists := fanOut[key]; exists {
+			continue
+		}
+		claim, claimed := claims[key]
+		if claimed {
+			if outranks(claim.owner, namespaceLabel) {
+				if claim.value != value {
+					conflicts = append(conflicts, conflictMessage(key, claim))
+				}
+				continue
+			}
+			delete(claims, key)
+		}
+
+		// Record the value a label had before this NamespaceLabel first overwrote it. Values applied by
+		// this NamespaceLabel or a sibling are not the Namespace's own.
+		if original, recorded := namespaceLabel.Status.OverriddenLabels[key]; recorded {
+			overridden[key] = original
+		} else if current, exists := ns.Labels[key]; exists && current != value && !claimed {
+			if _, applied := namespaceLabel.Status.AppliedLabels[key]; !applied {
+				overridden[key] = current
+			}
+		}
+
+		if r.isExternalDrift(namespaceLabel, ns, key) {
+			drifted[key] = ns.Labels[key]
+			continue
+		}
 		labelsToAdd[key] = value
 	}
 
-	// Ensure labels are not management labels
-	for key := range labelsToAdd {
-		if isManagementLabel(key) {
-			return fmt.Errorf("cannot add protected or management label '%s'", key)
+	labelsToApply := make(map[string]string, len(labelsToAdd)+len(claims))
+	for key, value := range labelsToAdd {
+		labelsToApply[key] = value
+	}
+	for key, claim := range claims {
+		if _, exists := fanOut[key]; !exists {
+			labelsToApply[key] = claim.value
 		}
 	}
 
-	// Collect labels to remove
-	for key := range ns.Labels {
-		if _, exists := labelsToAdd[key]; !exists && !isManagementLabel(key) {
-			labelsToRemove[key] = struct{}{}
+	// Collect labels to remove: only keys this NamespaceLabel applied before, so labels set by other tools
+	// are left alone. NamespaceLabels applied before tracking have no applied labels and remove nothing
+	// until their spec has been applied once. Labels it overrode get their previous value back instead.
+	labelsToRestore := make(map[string]string)
+	for key := range namespaceLabel.Status.AppliedLabels {
+		_, exists := ns.Labels[key]
+		_, declared := namespaceLabel.Spec.Labels[key]
+		_, kept := labelsToApply[key]
+		_, fannedOut := fanOut[key]
+		if !exists || declared || kept || fannedOut || r.ProtectedLabels.IsProtected(key) {
+			continue
+		}
+		if original, recorded := namespaceLabel.Status.OverriddenLabels[key]; recorded {
+			labelsToRestore[key] = original
+			continue
+		}
+		labelsToRemove[key] = struct{}{}
+	}
+
+	// Count the changes before applying them
+	added, updated := 0, 0
+	var changes labelChanges
+	for key, value := range labelsToApply {
+		current, exists := ns.Labels[key]
+		switch {
+		case !exists:
+			added++
+			changes.added(key, value)
+		case current != value:
+			updated++
+			changes.updated(key, current, value)
 		}
 	}
 
 	// Remove labels that are no longer present in NamespaceLabel
 	for key := range labelsToRemove {
-		delete(ns.Labels, key)
+		changes.removed(key, ns.Labels[key])
+	}
+	for key, value := range labelsToRestore {
+		if ns.Labels[key] != value {
+			updated++
+			changes.updated(key, ns.Labels[key], value)
+		}
+	}
+	if namespaceLabel.Spec.DryRun {
+		addPendingChanges(namespaceLabel, ns.Name, changes)
+		return nil
 	}
 
-	// Initialize ns.Labels if nil
-	if ns.Labels == nil {
-		ns.Labels = make(map[string]string)
+	// Apply only the labels and annotations this NamespaceLabel owns so concurrent changes by other tools are
+	// not overwritten
+	appliedAnnotations, annotations, annotationsToRemove := namespaceAnnotations(namespaceLabel, ns, siblings)
+	annotations[statusAnnotation] = statusSummary(len(labelsToApply), len(drifted))
+	if len(labelsToApply) > 0 {
+		annotations[namespacelabel.OwnedLabelsAnnotation] = namespacelabel.FormatLabelOwners(
+			labelOwners(namespaceLabel, labelsToApply, labelsToAdd, claims))
+	}
+	if err := r.applyNamespace(ctx, ns, labelsToApply, annotations, labelsToRemove, labelsToRestore,
+		annotationsToRemove); err != nil {
+		return err
 	}
 
-	// Apply labels to be added or updated
+	labelsAdded.WithLabelValues(sourceNamespaceLabel).Add(float64(added))
+	labelsUpdated.WithLabelValues(sourceNamespaceLabel).Add(float64(updated))
+	labelsRemoved.WithLabelValues(sourceNamespaceLabel).Add(float64(len(labelsToRemove)))
+	r.recordLabelChanges(ctx, ns, namespaceLabel, changes)
+
+	// Keep the last applied value of drifted labels so the drift stays detectable
+	applied := make(map[string]string, len(namespaceLabel.Spec.Labels))
 	for key, value := range labelsToAdd {
-		ns.Labels[key] = value
+		applied[key] = value
+	}
+	for key := range drifted {
+		applied[key] = namespaceLabel.Status.AppliedLabels[key]
 	}
+	namespaceLabel.Status.AppliedLabels = applied
+	namespaceLabel.Status.AppliedAnnotations = appliedAnnotations
+	namespaceLabel.Status.DriftedLabels = drifted
+	namespaceLabel.Status.OverriddenLabels = overridden
+	managedLabels.WithLabelValues(ns.Name).Set(float64(managedLabelCount(labelsToApply, drifted, siblings)))
+	r.setConflictCondition(namespaceLabel, conflicts)
+
+	return nil
+}
 
-	// Update Namespace with new labels
-	if err := r.Update(ctx, ns); err != nil {
+// applyNamespace server-side applies the labels and annotations owned by the controller to a Namespace. Fields
+// the controller applied before and no longer applies are removed by the API server, while labels set by other
+// managers are left alone. Keys in remove that survive the apply, because the controller wrote them before it
+// used server-side apply or another manager wrote the same value, are removed with a merge patch, like the
+// annotation keys in removeAnnotations. The patch also sets the labels in restore. Restored labels are not owned
+// by the controller's apply, so later applies leave them alone.
+func (r *NamespaceLabelReconciler) applyNamespace(ctx context.Context, ns *corev1.Namespace,
+	labels, annotations map[string]string, remove map[string]struct{}, restore map[string]string,
+	removeAnnotations map[string]struct{}) error {
+	applied := &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: ns.Name, Labels: labels, Annotations: annotations},
+	}
+	if err := r.Patch(ctx, applied, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
 		return err
 	}
 
+	patch := client.MergeFrom(applied.DeepCopy())
+	leftover := false
+	for key := range remove {
+		if _, exists := applied.Labels[key]; exists {
+			delete(applied.Labels, key)
+			leftover = true
+		}
+	}
+	for key := range removeAnnotations {
+		if _, exists := applied.Annotations[key]; exists {
+			delete(applied.Annotations, key)
+			leftover = true
+		}
+	}
+	for key, value := range restore {
+		if current, exists := applied.Labels[key]; !exists || current != value {
+			if applied.Labels == nil {
+				applied.Labels = make(map[string]string)
+			}
+			applied.Labels[key] = value
+			leftover = true
+		}
+	}
+	if leftover {
+		if err := r.Patch(ctx, applied, patch); err != nil {
+			return err
+		}
+	}
+
+	*ns = *applied
 	return nil
 }
 
+// isExternalDrift reports whether a label was changed or removed on the Namespace by another manager since
+// the controller applied it, and is configured to be recorded rather than reverted. Labels never applied are
+// applied regardless, and keys in spec.enforceKeys are always reverted.
+func (r *NamespaceLabelReconciler) isExternalDrift(
+	namespaceLabel *danav1alpha1.NamespaceLabel, ns *corev1.Namespace, key string) bool {
+	if slices.Contains(namespaceLabel.Spec.EnforceKeys, key) {
+		return false
+	}
+	if !slices.Contains(r.IgnoreDriftKeys, key) && !slices.Contains(namespaceLabel.Spec.IgnoreDriftKeys, key) &&
+		!slices.Contains(r.IgnoreDriftNamespaces, ns.Name) {
+		return false
+	}
+
+	current, exists := ns.Labels[key]
+	applied, tracked := namespaceLabel.Status.AppliedLabels[key]
+
+	// A spec change is a deliberate update and is applied even over external values
+	return tracked && (!exists || current != applied) && applied == namespaceLabel.Spec.Labels[key]
+}
+
+// setDriftCondition reports labels that were changed externally and intentionally left unreverted
+func setDriftCondition(namespaceLabel *danav1alpha1.NamespaceLabel) {
+	if len(namespaceLabel.Status.DriftedLabels) == 0 {
+		meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "Drifted")
+		return
+	}
+
+	keys := make([]string, 0, len(namespaceLabel.Status.DriftedLabels))
+	for key := range namespaceLabel.Status.DriftedLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	namespaceLabel.Status.Conditions = updateNewCondition(namespaceLabel.Status.Conditions, metav1.Condition{
+		Type:               "Drifted",
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "ExternalChange",
+		Message:            fmt.Sprintf("labels changed or removed by an external manager are not reverted: %s", strings.Join(keys, ", ")),
+	})
+}
+
+// statusSummary renders the management summary shown on the Namespace, e.g. "Applied(5)/Skipped(1)"
+func statusSummary(applied, skipped int) string {
+	return fmt.Sprintf("Applied(%d)/Skipped(%d)", applied, skipped)
+}
+
+// writeStatusSummary persists the status summary annotation when no other Namespace update is pending. It merge
+// patches the annotation alone, as applying it by itself would drop the labels the controller applied.
+func (r *NamespaceLabelReconciler) writeStatusSummary(ctx context.Context, ns *corev1.Namespace, applied, skipped int) {
+	patch := client.MergeFrom(ns.DeepCopy())
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[statusAnnotation] = statusSummary(applied, skipped)
+	if err := r.Patch(ctx, ns, patch, client.FieldOwner(fieldManager)); err != nil {
+		r.Log.Error(err, "Failed to update Namespace status summary", "Namespace", ns.Name)
+	}
+}
+
 func (r *NamespaceLabelReconciler) updateStatus(ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
 	condition := metav1.Condition{
 		Type:               conditionType,
 		Status:             status,
-		LastTransitionTime: metav1.Now(),
+		LastTransitionTime: now,
 		Reason:             reason,
 		Message:            message,
 	}
 
 	// Update or append condition
 	namespaceLabel.Status.Conditions = updateNewCondition(namespaceLabel.Status.Conditions, condition)
+	r.setStaleCondition(namespaceLabel, conditionType == "LabelsApplied" && status == metav1.ConditionTrue)
+	setSummaryConditions(namespaceLabel)
+	setLastSync(namespaceLabel, now, r.retries(namespaceLabel))
 
 	// Update status
 	if err := r.Status().Update(ctx, namespaceLabel); err != nil {
@@ -220,7 +999,61 @@ func updateNewCondition(conditions []metav1.Condition, newCondition metav1.Condi
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *NamespaceLabelReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&danav1alpha1.NamespaceLabel{}).
-		Complete(r)
+	if r.RateLimiter == nil {
+		r.RateLimiter = workqueue.DefaultControllerRateLimiter()
+	}
+	watched := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return namespacelabel.IsWatched(r.WatchSelector, obj)
+	})
+
+	// Label and annotation changes on a Namespace re-queue the NamespaceLabels managing it, so external edits
+	// are healed right away instead of on the next resync
+	blder := ctrl.NewControllerManagedBy(mgr).
+		For(&danav1alpha1.NamespaceLabel{}, builder.WithPredicates(watched, specOrLabelsChanged)).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.namespaceRequests),
+			builder.WithPredicates(namespaceMetadataChanged)).
+		// Changes to referenced ConfigMaps and Secrets re-queue the NamespaceLabels sourcing labels from them
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.labelsSourceRequests)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.labelsSourceRequests)).
+		// NamespaceLabels turning Ready or not re-queue the NamespaceLabels depending on them
+		Watches(&danav1alpha1.NamespaceLabel{}, handler.EnqueueRequestsFromMapFunc(r.dependentRequests),
+			builder.WithPredicates(readinessChanged)).
+		// New and relabeled workloads re-queue the NamespaceLabels propagating labels into their namespace
+		Watches(&appsv1.Deployment{}, handler.EnqueueRequestsFromMapFunc(r.workloadRequests),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Watches(&appsv1.StatefulSet{}, handler.EnqueueRequestsFromMapFunc(r.workloadRequests),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles, RateLimiter: r.RateLimiter})
+	if r.ResyncTrigger != nil {
+		blder = blder.WatchesRawSource(r.ResyncTrigger.source())
+	}
+
+	return blder.Complete(r)
+}
+
+// namespaceRequests maps a Namespace event to the watched NamespaceLabels that label the Namespace, including
+// fan-out NamespaceLabels targeting it
+func (r *NamespaceLabelReconciler) namespaceRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := r.List(ctx, namespaceLabels); err != nil {
+		r.Log.Error(err, "Failed to list NamespaceLabels for Namespace", "Namespace", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range namespaceLabels.Items {
+		nl := &namespaceLabels.Items[i]
+		if !namespacelabel.IsWatched(r.WatchSelector, nl) {
+			continue
+		}
+		targets, err := namespacelabel.LabeledNamespaces(r.MapNamespace, nl)
+		if err != nil || !slices.Contains(targets, obj.GetName()) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: nl.Namespace, Name: nl.Name},
+		})
+	}
+
+	return requests
 }
@@ -2,21 +2,32 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
 )
 
 var (
@@ -29,7 +40,9 @@ func initTestEnvironment() {
 	scheme = runtime.NewScheme()
 	Expect(danav1alpha1.AddToScheme(scheme)).To(Succeed())
 	Expect(corev1.AddToScheme(scheme)).To(Succeed())
-	k8sClient = fake.NewClientBuilder().WithScheme(scheme).Build()
+	Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+	k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&danav1alpha1.NamespaceLabel{},
+		&danav1alpha1.ClusterNamespaceLabel{}, &danav1alpha1.NamespaceLabelReport{}).WithInterceptorFuncs(interceptor.Funcs{Patch: newApplyEmulator().patch}).Build()
 	ctx = context.Background()
 }
 
@@ -55,6 +68,68 @@ func deleteAllNamespaceLabels() {
 	}
 }
 
+// applyEmulator emulates server-side apply of Namespace labels and annotations, which the fake client does not
+// support: keys a field manager applied before and omits from its next apply are removed, unless another
+// writer changed them since and so took over their ownership
+type applyEmulator struct {
+	owned map[string]appliedFields
+}
+
+// appliedFields are the labels and annotations last applied by a field manager to a Namespace
+type appliedFields struct {
+	labels      map[string]string
+	annotations map[string]string
+}
+
+func newApplyEmulator() *applyEmulator {
+	return &applyEmulator{owned: make(map[string]appliedFields)}
+}
+
+func (e *applyEmulator) patch(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+	opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Patch(ctx, obj, patch, opts...)
+	}
+	applied, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return fmt.Errorf("apply is only emulated for Namespaces")
+	}
+	patchOptions := &client.PatchOptions{}
+	patchOptions.ApplyOptions(opts)
+
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(applied), ns); err != nil {
+		return err
+	}
+
+	owner := ns.Name + "/" + patchOptions.FieldManager
+	ns.Labels = applyFields(ns.Labels, e.owned[owner].labels, applied.Labels)
+	ns.Annotations = applyFields(ns.Annotations, e.owned[owner].annotations, applied.Annotations)
+	e.owned[owner] = appliedFields{labels: applied.Labels, annotations: applied.Annotations}
+
+	if err := c.Update(ctx, ns); err != nil {
+		return err
+	}
+	ns.DeepCopyInto(applied)
+	return nil
+}
+
+// applyFields removes the previously applied fields that still hold the applied value and sets the new ones
+func applyFields(current, previous, desired map[string]string) map[string]string {
+	if current == nil {
+		current = make(map[string]string)
+	}
+	for key, value := range previous {
+		if current[key] == value {
+			delete(current, key)
+		}
+	}
+	for key, value := range desired {
+		current[key] = value
+	}
+	return current
+}
+
 var _ = Describe("NamespaceLabel Controller", func() {
 	BeforeEach(func() {
 		initTestEnvironment()
@@ -94,12 +169,17 @@ var _ = Describe("NamespaceLabel Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			By("checking that the labels were applied to the Namespace")
+			Expect(testutil.ToFloat64(labelsAdded.WithLabelValues(sourceNamespaceLabel))).To(BeNumerically(">=", 2))
 			namespace := &corev1.Namespace{}
 			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
 			Expect(namespace.Labels).To(HaveKeyWithValue("label_1", "a"))
 			Expect(namespace.Labels).To(HaveKeyWithValue("label_2", "b"))
+			Expect(namespace.Annotations).To(HaveKeyWithValue(statusAnnotation, "Applied(2)/Skipped(0)"))
+			Expect(testutil.ToFloat64(managedLabels.WithLabelValues(namespaceName))).To(Equal(2.0))
+			Expect(testutil.CollectAndCount(namespacePatchLatency)).To(BeNumerically(">=", 1))
 
 			By("updating the NamespaceLabel resource")
+			updatedBefore := testutil.ToFloat64(labelsUpdated.WithLabelValues(sourceNamespaceLabel))
 			retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 				if err := k8sClient.Get(ctx, namespacedName, namespaceLabel); err != nil {
 					return err
@@ -112,14 +192,24 @@ var _ = Describe("NamespaceLabel Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
 			Expect(namespace.Labels).To(HaveKeyWithValue("label_1", "updated"))
+			Expect(testutil.ToFloat64(labelsUpdated.WithLabelValues(sourceNamespaceLabel))).To(Equal(updatedBefore + 1))
 
 			By("deleting a single label from the NamespaceLabel resource")
-			delete(namespaceLabel.Spec.Labels, "label_2")
-			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			removedBefore := testutil.ToFloat64(labelsRemoved.WithLabelValues(sourceNamespaceLabel))
+			retryErr = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				if err := k8sClient.Get(ctx, namespacedName, namespaceLabel); err != nil {
+					return err
+				}
+				delete(namespaceLabel.Spec.Labels, "label_2")
+				return k8sClient.Update(ctx, namespaceLabel)
+			})
+			Expect(retryErr).To(Succeed())
 			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
 			Expect(namespace.Labels).NotTo(HaveKey("label_2"))
+			Expect(testutil.ToFloat64(labelsRemoved.WithLabelValues(sourceNamespaceLabel))).To(Equal(removedBefore + 1))
+			Expect(testutil.ToFloat64(managedLabels.WithLabelValues(namespaceName))).To(Equal(1.0))
 
 			By("deleting the NamespaceLabel resource")
 			Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
@@ -145,14 +235,33 @@ var _ = Describe("NamespaceLabel Controller", func() {
 			Expect(k8sClient.Create(ctx, secondNamespaceLabel)).To(Succeed())
 
 			By("reconciling the second resource")
+			recorder := record.NewFakeRecorder(10)
 			controllerReconciler := &NamespaceLabelReconciler{
-				Client: k8sClient,
-				Scheme: scheme,
-				Log:    zap.New(zap.UseDevMode(true)),
+				Client:   k8sClient,
+				Scheme:   scheme,
+				Log:      zap.New(zap.UseDevMode(true)),
+				Recorder: recorder,
 			}
-			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "second-resource", Namespace: namespaceName}})
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("only one NamespaceLabel allowed per namespace"))
+			secondName := types.NamespacedName{Name: "second-resource", Namespace: namespaceName}
+			rejectionsBefore := testutil.ToFloat64(specRejections.WithLabelValues(sourceNamespaceLabel, "Conflict"))
+			result, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: secondName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+			Expect(recorder.Events).To(Receive(Equal("Warning Rejected only one NamespaceLabel allowed per namespace")))
+			Expect(testutil.ToFloat64(specRejections.WithLabelValues(sourceNamespaceLabel, "Conflict"))).
+				To(Equal(rejectionsBefore + 1))
+
+			Expect(k8sClient.Get(ctx, secondName, secondNamespaceLabel)).To(Succeed())
+			Expect(meta.FindStatusCondition(secondNamespaceLabel.Status.Conditions, conditionRejected)).To(And(
+				Not(BeNil()), HaveField("Reason", "Conflict")))
+			Expect(meta.IsStatusConditionTrue(secondNamespaceLabel.Status.Conditions, conditionStalled)).To(BeTrue())
+
+			By("reconciling the unchanged spec again")
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: secondName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).NotTo(Receive())
+			Expect(testutil.ToFloat64(specRejections.WithLabelValues(sourceNamespaceLabel, "Conflict"))).
+				To(Equal(rejectionsBefore + 1))
 		})
 
 		It("should prevent creating NamespaceLabel with managed labels", func() {
@@ -169,9 +278,1364 @@ var _ = Describe("NamespaceLabel Controller", func() {
 				Scheme: scheme,
 				Log:    zap.New(zap.UseDevMode(true)),
 			}
-			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "managed-label-resource", Namespace: namespaceName}})
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("cannot add protected or management label 'kubernetes.io/managed'"))
+			managedName := types.NamespacedName{Name: "managed-label-resource", Namespace: namespaceName}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: managedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, managedName, namespaceLabel)).To(Succeed())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionRejected)).To(And(Not(BeNil()),
+				HaveField("Message", ContainSubstring("cannot add protected or management label 'kubernetes.io/managed'"))))
+
+			By("checking that the status summary reports the skipped label")
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Annotations).To(HaveKeyWithValue(statusAnnotation, "Applied(0)/Skipped(1)"))
+		})
+
+		It("should apply the protected and over-quota labels of a NamespaceLabel admitted for a trusted principal", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "trusted",
+					Namespace:   namespaceName,
+					Annotations: map[string]string{namespacelabel.AdmissionBypassAnnotation: namespacelabel.BypassTrustedPrincipal},
+				},
+				Spec: danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"kubernetes.io/managed": "true", "team": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+				Quota:  validation.LabelQuota{MaxLabels: 1},
+			}
+			trustedName := types.NamespacedName{Name: "trusted", Namespace: namespaceName}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: trustedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, trustedName, namespaceLabel)).To(Succeed())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionRejected)).To(BeNil())
+
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("kubernetes.io/managed", "true"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("team", "a"))
+		})
+
+		It("should apply a break-glass NamespaceLabel only until its annotation expires", func() {
+			expires := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "break-glass",
+					Namespace: namespaceName,
+					Annotations: map[string]string{
+						namespacelabel.AdmissionBypassAnnotation:   namespacelabel.BypassBreakGlass,
+						namespacelabel.BreakGlassAnnotation:        "incident 42",
+						namespacelabel.BreakGlassExpiresAnnotation: expires.Format(time.RFC3339),
+					},
+				},
+				Spec: danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"kubernetes.io/managed": "true"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			breakGlassName := types.NamespacedName{Name: "break-glass", Namespace: namespaceName}
+			result, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: breakGlassName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Until(expires), time.Second))
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("kubernetes.io/managed", "true"))
+
+			By("rejecting the spec once the annotation has expired")
+			Expect(k8sClient.Get(ctx, breakGlassName, namespaceLabel)).To(Succeed())
+			namespaceLabel.Annotations[namespacelabel.BreakGlassExpiresAnnotation] =
+				time.Now().Add(-time.Minute).Format(time.RFC3339)
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: breakGlassName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, breakGlassName, namespaceLabel)).To(Succeed())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionRejected)).NotTo(BeNil())
+		})
+
+		It("should ignore NamespaceLabels not carrying the instance's watch label", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "other-instance", Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:        k8sClient,
+				Scheme:        scheme,
+				Log:           zap.New(zap.UseDevMode(true)),
+				WatchSelector: labels.SelectorFromSet(labels.Set{"dana.io/instance": "blue"}),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "other-instance", Namespace: namespaceName}})
+			Expect(err).NotTo(HaveOccurred())
+
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).NotTo(HaveKey("label_1"))
+		})
+
+		It("should record but not revert external changes to drift-ignored labels", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a", "env": "dev"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:          k8sClient,
+				Scheme:          scheme,
+				Log:             zap.New(zap.UseDevMode(true)),
+				IgnoreDriftKeys: []string{"team"},
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("changing the labels outside of the controller")
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			namespace.Labels["team"] = "gitops"
+			namespace.Labels["env"] = "gitops"
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("team", "gitops"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("env", "dev"))
+			Expect(namespace.Annotations).To(HaveKeyWithValue(statusAnnotation, "Applied(1)/Skipped(1)"))
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.DriftedLabels).To(Equal(map[string]string{"team": "gitops"}))
+			Expect(namespaceLabel.Status.AppliedLabels).To(HaveKeyWithValue("team", "a"))
+			Expect(namespaceLabel.Status.Conditions).To(ContainElement(HaveField("Type", "Drifted")))
+		})
+
+		It("should leave unenforced labels alone once applied and always revert enforced ones", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels:          map[string]string{"team": "a", "env": "dev", "owner": "alice"},
+					IgnoreDriftKeys: []string{"team"},
+					EnforceKeys:     []string{"env"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:                k8sClient,
+				Scheme:                scheme,
+				Log:                   zap.New(zap.UseDevMode(true)),
+				IgnoreDriftNamespaces: []string{namespaceName},
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("removing and changing the labels outside of the controller")
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			delete(namespace.Labels, "team")
+			namespace.Labels["env"] = "prod"
+			namespace.Labels["owner"] = "bob"
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).NotTo(HaveKey("team"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("env", "dev"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("owner", "bob"))
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.DriftedLabels).To(Equal(map[string]string{"team": "", "owner": "bob"}))
+			Expect(namespaceLabel.Status.AppliedLabels).To(HaveKeyWithValue("team", "a"))
+		})
+
+		It("should fail in strict mode when managed-prefix labels are not declared", func() {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			namespace.Labels = map[string]string{"tenant.dana.io/tampered": "true"}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"tenant.dana.io/team": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:             k8sClient,
+				Scheme:             scheme,
+				Log:                zap.New(zap.UseDevMode(true)),
+				StrictMode:         true,
+				ManagedLabelPrefix: "tenant.dana.io/",
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).To(MatchError(ContainSubstring("tenant.dana.io/tampered")))
+			Expect(testutil.ToFloat64(undeclaredManagedLabels.WithLabelValues(namespaceName))).To(Equal(1.0))
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.Conditions).To(ContainElement(And(
+				HaveField("Type", "Degraded"), HaveField("Status", metav1.ConditionTrue))))
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKey("tenant.dana.io/tampered"))
+
+			By("deleting the last NamespaceLabel of the Namespace")
+			Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(undeclaredManagedLabels.DeleteLabelValues(namespaceName)).To(BeFalse())
+		})
+
+		It("should count labels from label rules, sources and ClusterNamespaceLabels as declared in strict mode", func() {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			namespace.Labels = map[string]string{
+				"tenant.dana.io/freeze": "true", "tenant.dana.io/owner": "a", "tenant.dana.io/backup": "daily",
+			}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+			Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "inventory", Namespace: namespaceName},
+				Data:       map[string]string{"owner": "a"},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+				Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+					Labels:            map[string]string{"tenant.dana.io/backup": "daily"},
+					NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: []string{namespaceName}},
+				},
+			})).To(Succeed())
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels:     map[string]string{"tenant.dana.io/team": "a"},
+					LabelRules: []danav1alpha1.LabelRule{{Key: "tenant.dana.io/freeze", Value: "true"}},
+					LabelsFrom: []danav1alpha1.LabelsFromSource{{
+						Prefix: "tenant.dana.io/", ConfigMapRef: &danav1alpha1.LabelsSourceReference{Name: "inventory"},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:             k8sClient,
+				Scheme:             scheme,
+				Log:                zap.New(zap.UseDevMode(true)),
+				StrictMode:         true,
+				ManagedLabelPrefix: "tenant.dana.io/",
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testutil.ToFloat64(undeclaredManagedLabels.WithLabelValues(namespaceName))).To(Equal(0.0))
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("tenant.dana.io/team", "a"))
+
+			By("tampering with a managed-prefix label no source declares")
+			namespace.Labels["tenant.dana.io/tampered"] = "true"
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).To(MatchError(ContainSubstring("tenant.dana.io/tampered")))
+			Expect(err.Error()).NotTo(ContainSubstring("tenant.dana.io/owner"))
+		})
+
+		It("should mark NamespaceLabels Stale when labels stay unapplied beyond the threshold", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"kubernetes.io/managed": "true"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+			pendingSince := metav1.NewTime(time.Now().Add(-time.Hour))
+			namespaceLabel.Status.PendingSince = &pendingSince
+			Expect(k8sClient.Status().Update(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:         k8sClient,
+				Scheme:         scheme,
+				Log:            zap.New(zap.UseDevMode(true)),
+				StaleThreshold: 10 * time.Minute,
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.Conditions).To(ContainElement(HaveField("Type", "Stale")))
+			Expect(testutil.ToFloat64(staleNamespaceLabels.WithLabelValues(namespaceName, resourceName))).To(Equal(1.0))
+
+			By("fixing the spec so the labels can be applied")
+			namespaceLabel.Spec.Labels = map[string]string{"label_1": "a"}
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.Conditions).NotTo(ContainElement(HaveField("Type", "Stale")))
+			Expect(namespaceLabel.Status.PendingSince).To(BeNil())
+			Expect(namespaceLabel.Status.LastAppliedTime).NotTo(BeNil())
+			Expect(testutil.ToFloat64(staleNamespaceLabels.WithLabelValues(namespaceName, resourceName))).To(Equal(0.0))
+
+			By("deleting the NamespaceLabel")
+			Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staleNamespaceLabels.DeleteLabelValues(namespaceName, resourceName)).To(BeFalse())
+		})
+
+		It("should requeue at the NamespaceLabel's resync interval", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels:         map[string]string{"label_1": "a"},
+					ResyncInterval: &metav1.Duration{Duration: 5 * time.Minute},
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			result, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+
+			By("falling back to the controller's resync period")
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			namespaceLabel.Spec.ResyncInterval = nil
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			controllerReconciler.ResyncPeriod = 10 * time.Minute
+			result, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">=", 10*time.Minute))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", 11*time.Minute))
+		})
+
+		It("should label the mapped host namespace in virtual-cluster mode", func() {
+			createNamespace("default-x-tenants-x-vcluster")
+			defer deleteNamespace("default-x-tenants-x-vcluster")
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			mapper, err := namespacelabel.NewTemplateNamespaceMapper("{{ .Namespace }}-x-tenants-x-vcluster")
+			Expect(err).NotTo(HaveOccurred())
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:       k8sClient,
+				Scheme:       scheme,
+				Log:          zap.New(zap.UseDevMode(true)),
+				MapNamespace: mapper,
+			}
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default-x-tenants-x-vcluster"}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("label_1", "a"))
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).NotTo(HaveKey("label_1"))
+		})
+
+		It("should fan labels out to the target namespaces of an admin NamespaceLabel", func() {
+			for _, name := range []string{"platform", "team-a", "team-b"} {
+				createNamespace(name)
+			}
+			fanOutName := types.NamespacedName{Name: "shared", Namespace: "platform"}
+			fanOut := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: fanOutName.Name, Namespace: fanOutName.Namespace},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels:           map[string]string{"cost-center": "platform"},
+					TargetNamespaces: []string{"team-a", "team-b"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, fanOut)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:          k8sClient,
+				Scheme:          scheme,
+				Log:             zap.New(zap.UseDevMode(true)),
+				AdminNamespaces: []string{"platform"},
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: fanOutName})
+			Expect(err).NotTo(HaveOccurred())
+
+			namespace := &corev1.Namespace{}
+			for _, name := range []string{"team-a", "team-b"} {
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, namespace)).To(Succeed())
+				Expect(namespace.Labels).To(HaveKeyWithValue("cost-center", "platform"))
+			}
+			Expect(k8sClient.Get(ctx, fanOutName, fanOut)).To(Succeed())
+			Expect(fanOut.Status.LabeledNamespaces).To(Equal([]string{"team-a", "team-b"}))
+
+			By("reconciling a tenant NamespaceLabel in a target namespace")
+			tenantName := types.NamespacedName{Name: resourceName, Namespace: "team-a"}
+			tenant := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: tenantName.Name, Namespace: tenantName.Namespace},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, tenant)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: tenantName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "team-a"}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("label_1", "a"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("cost-center", "platform"))
+
+			By("dropping a namespace from the targets")
+			fanOut.Spec.TargetNamespaces = []string{"team-a"}
+			Expect(k8sClient.Update(ctx, fanOut)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: fanOutName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "team-b"}, namespace)).To(Succeed())
+			Expect(namespace.Labels).NotTo(HaveKey("cost-center"))
+
+			By("deleting the fan-out NamespaceLabel")
+			Expect(k8sClient.Get(ctx, fanOutName, fanOut)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, fanOut)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: fanOutName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "team-a"}, namespace)).To(Succeed())
+			Expect(namespace.Labels).NotTo(HaveKey("cost-center"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("label_1", "a"))
+		})
+
+		It("should refuse targetNamespaces outside the admin namespaces", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels:           map[string]string{"label_1": "a"},
+					TargetNamespaces: []string{"kube-system"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionRejected)).To(And(
+				Not(BeNil()), HaveField("Message", ContainSubstring("admin namespaces"))))
+			Expect(namespaceLabel.Status.Conditions).To(ContainElement(HaveField("Reason", "ValidationFailed")))
+		})
+
+		It("should run the webhook's LabelPolicy rules before applying labels", func() {
+			policy := &danav1alpha1.LabelPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "no-prod"},
+				Spec: danav1alpha1.LabelPolicySpec{
+					DeniedValues: []danav1alpha1.DeniedValueRule{{Key: "environment", Values: []string{"prod"}}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"environment": "prod"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testutil.ToFloat64(labelsRejected.WithLabelValues(sourceNamespaceLabel, "PolicyViolation"))).
+				To(BeNumerically(">=", 1))
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, "Invalid")).To(And(
+				Not(BeNil()), HaveField("Reason", "PolicyViolation")))
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionRejected)).To(And(
+				Not(BeNil()), HaveField("Message", ContainSubstring("denied by LabelPolicy 'no-prod'"))))
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).NotTo(HaveKey("environment"))
+
+			By("fixing the spec")
+			namespaceLabel.Spec.Labels = map[string]string{"environment": "dev"}
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.Conditions).NotTo(ContainElement(HaveField("Type", "Invalid")))
+			Expect(namespaceLabel.Status.Conditions).NotTo(ContainElement(HaveField("Type", conditionRejected)))
+		})
+
+		It("should record label changes as Events on the Namespace", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:          k8sClient,
+				Scheme:          scheme,
+				Log:             zap.New(zap.UseDevMode(true)),
+				Recorder:        recorder,
+				NamespaceEvents: true,
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).To(Receive(Equal("Normal LabelAdded label 'label_1=a' set by NamespaceLabel default/test-resource")))
+
+			By("changing the label value")
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			namespaceLabel.Spec.Labels["label_1"] = "b"
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).To(Receive(Equal("Normal LabelUpdated label 'label_1=b' set by NamespaceLabel default/test-resource")))
+
+			By("deleting the NamespaceLabel")
+			Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).To(Receive(Equal("Normal LabelRemoved label 'label_1' removed by NamespaceLabel default/test-resource")))
+		})
+
+		It("should only remove labels it applied", func() {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			namespace.Labels = map[string]string{"owner": "other-tool"}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a", "label_2": "b"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.AppliedLabels).To(Equal(map[string]string{"label_1": "a", "label_2": "b"}))
+
+			By("removing a key from the spec")
+			delete(namespaceLabel.Spec.Labels, "label_2")
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).NotTo(HaveKey("label_2"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("label_1", "a"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("owner", "other-tool"))
+		})
+
+		It("should clean up applied labels on deletion and release the finalizer", func() {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			namespace.Labels = map[string]string{"owner": "other-tool"}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Finalizers).To(ContainElement(finalizerName))
+			Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).NotTo(Succeed())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(Equal(map[string]string{"owner": "other-tool"}))
+		})
+
+		It("should apply, update and clean up its annotations like its labels", func() {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			namespace.Annotations = map[string]string{"owner": "other-tool"}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels:      map[string]string{"label_1": "a"},
+					Annotations: map[string]string{"contact": "team-a@example.com", "runbook": "https://runbooks/a"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Annotations).To(HaveKeyWithValue("contact", "team-a@example.com"))
+			Expect(namespace.Annotations).To(HaveKeyWithValue("runbook", "https://runbooks/a"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.AppliedAnnotations).To(Equal(namespaceLabel.Spec.Annotations))
+
+			By("updating one annotation and dropping the other")
+			namespaceLabel.Spec.Annotations = map[string]string{"contact": "team-b@example.com"}
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Annotations).To(HaveKeyWithValue("contact", "team-b@example.com"))
+			Expect(namespace.Annotations).NotTo(HaveKey("runbook"))
+
+			By("deleting the NamespaceLabel")
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Annotations).To(Equal(map[string]string{"owner": "other-tool"}))
+		})
+
+		It("should refuse protected annotations", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Annotations: map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "pool=tenant"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionRejected)).To(And(Not(BeNil()),
+				HaveField("Message", ContainSubstring("cannot add protected annotation 'scheduler.alpha.kubernetes.io/node-selector'"))))
+
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Annotations).NotTo(HaveKey("scheduler.alpha.kubernetes.io/node-selector"))
+		})
+
+		It("should release the finalizer when the Namespace is already gone", func() {
+			createNamespace("team-gone")
+			goneName := types.NamespacedName{Name: resourceName, Namespace: "team-gone"}
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: goneName.Name, Namespace: goneName.Namespace},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: goneName})
+			Expect(err).NotTo(HaveOccurred())
+
+			deleteNamespace("team-gone")
+			Expect(k8sClient.Get(ctx, goneName, namespaceLabel)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: goneName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, goneName, namespaceLabel)).NotTo(Succeed())
+		})
+
+		It("should summarize the outcome in kstatus conditions", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(namespaceLabel.Status.Conditions, "Ready")).To(BeTrue())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, "Stalled")).To(BeNil())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, "Reconciling")).To(BeNil())
+			Expect(namespaceLabel.Status.ObservedGeneration).To(Equal(namespaceLabel.Generation))
+
+			By("making the spec invalid")
+			namespaceLabel.Spec.Labels = map[string]string{"kubernetes.io/managed": "true"}
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(meta.IsStatusConditionFalse(namespaceLabel.Status.Conditions, "Ready")).To(BeTrue())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, "Stalled")).To(And(
+				Not(BeNil()), HaveField("Reason", "ValidationFailed")))
+		})
+
+		It("should report the time, outcome and retries of the last sync attempt", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"kubernetes.io/managed": "true"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			rateLimiter := workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Second)
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:      k8sClient,
+				Scheme:      scheme,
+				Log:         zap.New(zap.UseDevMode(true)),
+				RateLimiter: rateLimiter,
+			}
+			request := ctrl.Request{NamespacedName: namespacedName}
+			rateLimiter.When(request)
+			rateLimiter.When(request)
+			_, err := controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.LastSyncResult).To(Equal(danav1alpha1.SyncStalled))
+			Expect(namespaceLabel.Status.LastSyncTime).NotTo(BeNil())
+			Expect(namespaceLabel.Status.RetryCount).To(Equal(int32(2)))
+			Expect(namespaceLabel.Status.ObservedGeneration).To(Equal(namespaceLabel.Generation))
+
+			By("fixing the spec")
+			namespaceLabel.Spec.Labels = map[string]string{"label_1": "a"}
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.LastSyncResult).To(Equal(danav1alpha1.SyncSucceeded))
+			Expect(namespaceLabel.Status.RetryCount).To(BeZero())
+			Expect(namespaceLabel.Status.ObservedGeneration).To(Equal(namespaceLabel.Generation))
+		})
+
+		It("should map Namespace label changes to the owning NamespaceLabel and heal them", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("removing the label with kubectl")
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			delete(ns.Labels, "label_1")
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+			requests := controllerReconciler.namespaceRequests(ctx, ns)
+			Expect(requests).To(ConsistOf(ctrl.Request{NamespacedName: namespacedName}))
+			_, err = controllerReconciler.Reconcile(ctx, requests[0])
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("label_1", "a"))
+
+			By("ignoring Namespaces the NamespaceLabel does not label")
+			other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+			Expect(controllerReconciler.namespaceRequests(ctx, other)).To(BeEmpty())
+		})
+
+		It("should server-side apply its labels and clean up labels written before", func() {
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			ns.Labels = map[string]string{"legacy": "x", "other-tool": "y"}
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+			namespaceLabel.Status.AppliedLabels = map[string]string{"legacy": "x"}
+			Expect(k8sClient.Status().Update(ctx, namespaceLabel)).To(Succeed())
+
+			var managers []string
+			applyClient := interceptor.NewClient(k8sClient.(client.WithWatch), interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+					opts ...client.PatchOption) error {
+					if patch.Type() == types.ApplyPatchType {
+						patchOptions := &client.PatchOptions{}
+						patchOptions.ApplyOptions(opts)
+						managers = append(managers, patchOptions.FieldManager)
+					}
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			})
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: applyClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(managers).To(ConsistOf(fieldManager))
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("label_1", "a"))
+			Expect(ns.Labels).To(HaveKeyWithValue("other-tool", "y"))
+			Expect(ns.Labels).NotTo(HaveKey("legacy"))
+		})
+
+		It("should merge several NamespaceLabels by priority when allowed", func() {
+			networking := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "networking", Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels:   map[string]string{"network-zone": "internal", "owner": "networking"},
+					Priority: 10,
+				},
+			}
+			billing := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "billing", Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"cost-center": "42", "owner": "billing"}},
+			}
+			Expect(k8sClient.Create(ctx, networking)).To(Succeed())
+			Expect(k8sClient.Create(ctx, billing)).To(Succeed())
+
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:        k8sClient,
+				Scheme:        scheme,
+				Log:           zap.New(zap.UseDevMode(true)),
+				Recorder:      recorder,
+				AllowMultiple: true,
+			}
+			reconcile := func(name string) {
+				_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{
+					NamespacedName: types.NamespacedName{Name: name, Namespace: namespaceName},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			reconcile("networking")
+			reconcile("billing")
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("network-zone", "internal"))
+			Expect(ns.Labels).To(HaveKeyWithValue("cost-center", "42"))
+			Expect(ns.Labels).To(HaveKeyWithValue("owner", "networking"))
+			Expect(testutil.ToFloat64(managedLabels.WithLabelValues(namespaceName))).To(Equal(3.0))
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "billing", Namespace: namespaceName}, billing)).To(Succeed())
+			Expect(meta.FindStatusCondition(billing.Status.Conditions, "Conflicted")).To(And(
+				Not(BeNil()), HaveField("Message", ContainSubstring("label 'owner' is held by NamespaceLabel 'networking'"))))
+			Expect(billing.Status.AppliedLabels).To(Equal(map[string]string{"cost-center": "42"}))
+			Expect(recorder.Events).To(Receive(ContainSubstring("LabelConflict")))
+
+			By("deleting the higher-priority NamespaceLabel")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "networking", Namespace: namespaceName}, networking)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, networking)).To(Succeed())
+			reconcile("networking")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).NotTo(HaveKey("network-zone"))
+			Expect(ns.Labels).To(HaveKeyWithValue("cost-center", "42"))
+			Expect(testutil.ToFloat64(managedLabels.WithLabelValues(namespaceName))).To(Equal(1.0))
+
+			reconcile("billing")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("owner", "billing"))
+			Expect(testutil.ToFloat64(managedLabels.WithLabelValues(namespaceName))).To(Equal(2.0))
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "billing", Namespace: namespaceName}, billing)).To(Succeed())
+			Expect(meta.FindStatusCondition(billing.Status.Conditions, "Conflicted")).To(BeNil())
+		})
+
+		It("should source labels from referenced ConfigMaps and Secrets and follow their changes", func() {
+			inventory := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "inventory", Namespace: namespaceName},
+				Data:       map[string]string{"cost-center": "42", "owner": "inventory"},
+			}
+			Expect(k8sClient.Create(ctx, inventory)).To(Succeed())
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "billing", Namespace: namespaceName},
+				Data:       map[string][]byte{"account": []byte("acme")},
+			})).To(Succeed())
+
+			Expect(k8sClient.Create(ctx, &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels: map[string]string{"inventory.dana.io/owner": "platform"},
+					LabelsFrom: []danav1alpha1.LabelsFromSource{
+						{Prefix: "inventory.dana.io/", ConfigMapRef: &danav1alpha1.LabelsSourceReference{Name: "inventory"}},
+						{SecretRef: &danav1alpha1.LabelsSourceReference{Name: "billing"}},
+						{ConfigMapRef: &danav1alpha1.LabelsSourceReference{Name: "missing", Optional: true}},
+					},
+				},
+			})).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("inventory.dana.io/cost-center", "42"))
+			Expect(ns.Labels).To(HaveKeyWithValue("inventory.dana.io/owner", "platform"))
+			Expect(ns.Labels).To(HaveKeyWithValue("account", "acme"))
+
+			By("updating the ConfigMap")
+			inventory.Data = map[string]string{"cost-center": "43"}
+			Expect(k8sClient.Update(ctx, inventory)).To(Succeed())
+			requests := controllerReconciler.labelsSourceRequests(ctx, inventory)
+			Expect(requests).To(ConsistOf(ctrl.Request{NamespacedName: namespacedName}))
+			_, err = controllerReconciler.Reconcile(ctx, requests[0])
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("inventory.dana.io/cost-center", "43"))
+
+			By("ignoring unreferenced objects")
+			other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "billing", Namespace: namespaceName}}
+			Expect(controllerReconciler.labelsSourceRequests(ctx, other)).To(BeEmpty())
+		})
+
+		It("should propagate its labels to the selected workloads and clean them up", func() {
+			web := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespaceName, Labels: map[string]string{"tier": "frontend"}},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+					Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}},
+				},
+			}
+			batch := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: namespaceName},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "batch"}},
+					Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "batch"}}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, web)).To(Succeed())
+			Expect(k8sClient.Create(ctx, batch)).To(Succeed())
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels: map[string]string{"cost-center": "42", "app": "billing"},
+					Propagate: &danav1alpha1.PropagateSpec{
+						Kinds:    []danav1alpha1.PropagateKind{danav1alpha1.PropagateDeployment},
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "frontend"}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: namespaceName}, web)).To(Succeed())
+			Expect(web.Labels).To(HaveKeyWithValue("cost-center", "42"))
+			Expect(web.Spec.Template.Labels).To(Equal(map[string]string{"app": "web", "cost-center": "42"}))
+			Expect(web.Annotations).To(HaveKeyWithValue(propagatedLabelsAnnotation, "cost-center"))
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "batch", Namespace: namespaceName}, batch)).To(Succeed())
+			Expect(batch.Labels).NotTo(HaveKey("cost-center"))
+			Expect(controllerReconciler.workloadRequests(ctx, batch)).To(ConsistOf(ctrl.Request{NamespacedName: namespacedName}))
+
+			By("stopping propagation")
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			namespaceLabel.Spec.Propagate = nil
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: namespaceName}, web)).To(Succeed())
+			Expect(web.Labels).To(Equal(map[string]string{"tier": "frontend"}))
+			Expect(web.Spec.Template.Labels).To(Equal(map[string]string{"app": "web"}))
+			Expect(web.Annotations).NotTo(HaveKey(propagatedLabelsAnnotation))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.PropagatedKinds).To(BeEmpty())
+		})
+
+		It("should remove expired label rules and requeue for the next expiry", func() {
+			past := metav1.NewTime(time.Now().Add(-time.Minute))
+			Expect(k8sClient.Create(ctx, &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels: map[string]string{"team": "a"},
+					LabelRules: []danav1alpha1.LabelRule{
+						{Key: "freeze", Value: "true", ExpiresAt: &past},
+						{Key: "maintenance", Value: "true", ExpiresAfter: &metav1.Duration{Duration: time.Hour}},
+					},
+				},
+			})).To(Succeed())
+
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:   k8sClient,
+				Scheme:   scheme,
+				Log:      zap.New(zap.UseDevMode(true)),
+				Recorder: recorder,
+			}
+			result, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("maintenance", "true"))
+			Expect(ns.Labels).NotTo(HaveKey("freeze"))
+			Expect(recorder.Events).To(Receive(ContainSubstring("label 'freeze' expired")))
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{}
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Spec.LabelRules).To(HaveLen(2))
+			Expect(namespaceLabel.Status.ExpiredLabels).To(Equal([]string{"freeze"}))
+			Expect(namespaceLabel.Status.LabelExpirations).To(HaveKey("maintenance"))
+
+			By("expiring the remaining rule")
+			namespaceLabel.Status.LabelExpirations["maintenance"] = past
+			Expect(k8sClient.Status().Update(ctx, namespaceLabel)).To(Succeed())
+			result, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).NotTo(HaveKey("maintenance"))
+			Expect(ns.Labels).To(HaveKeyWithValue("team", "a"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.ExpiredLabels).To(Equal([]string{"freeze", "maintenance"}))
+		})
+
+		It("should apply scheduled label rules only while their window is open", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					LabelRules: []danav1alpha1.LabelRule{{
+						Key: "maintenance", Value: "true",
+						Schedule: &danav1alpha1.LabelSchedule{Apply: "0 22 * * 6", Remove: "0 6 * * 0"},
+					}},
+				},
+			}
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &NamespaceLabelReconciler{Recorder: recorder}
+
+			saturdayNight := time.Date(2024, time.June, 1, 23, 0, 0, 0, time.UTC)
+			next := controllerReconciler.resolveLabelRules(namespaceLabel.DeepCopy(), saturdayNight)
+			Expect(next).To(Equal(7 * time.Hour))
+
+			resolved := namespaceLabel.DeepCopy()
+			controllerReconciler.resolveLabelRules(resolved, saturdayNight)
+			Expect(resolved.Spec.Labels).To(HaveKeyWithValue("maintenance", "true"))
+			Expect(resolved.Status.LabelWindows).To(Equal(map[string]danav1alpha1.LabelWindow{
+				"maintenance": {Active: true, NextTransition: metav1.NewTime(saturdayNight.Add(7 * time.Hour))},
+			}))
+
+			By("closing the window")
+			namespaceLabel.Status = resolved.Status
+			sundayMorning := time.Date(2024, time.June, 2, 7, 0, 0, 0, time.UTC)
+			next = controllerReconciler.resolveLabelRules(namespaceLabel, sundayMorning)
+			Expect(next).To(Equal(6*24*time.Hour + 15*time.Hour))
+			Expect(namespaceLabel.Spec.Labels).NotTo(HaveKey("maintenance"))
+			Expect(namespaceLabel.Status.LabelWindows["maintenance"].Active).To(BeFalse())
+			Expect(recorder.Events).To(Receive(Equal("Normal LabelWindowClosed label 'maintenance' window closed")))
+		})
+
+		It("should neither apply nor remove labels while suspended and resume when cleared", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("suspending and changing the labels")
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			namespaceLabel.Spec.Suspend = true
+			namespaceLabel.Spec.Labels = map[string]string{"owner": "billing"}
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("team", "a"))
+			Expect(ns.Labels).NotTo(HaveKey("owner"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(namespaceLabel.Status.Conditions, "Suspended")).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(namespaceLabel.Status.Conditions, conditionReady)).To(BeTrue())
+
+			By("resuming")
+			namespaceLabel.Spec.Suspend = false
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("owner", "billing"))
+			Expect(ns.Labels).NotTo(HaveKey("team"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, "Suspended")).To(BeNil())
+			Expect(meta.IsStatusConditionTrue(namespaceLabel.Status.Conditions, conditionReady)).To(BeTrue())
+		})
+
+		It("should report the pending changes of a dry run without applying them", func() {
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			ns.Labels = map[string]string{"tier": "silver"}
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a", "tier": "gold"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:   k8sClient,
+				Scheme:   scheme,
+				Log:      zap.New(zap.UseDevMode(true)),
+				Recorder: recorder,
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("previewing a change of the labels")
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			namespaceLabel.Spec.DryRun = true
+			namespaceLabel.Spec.Labels = map[string]string{"tier": "platinum", "owner": "billing"}
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(Equal(map[string]string{"team": "a", "tier": "gold"}))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.PendingChanges).To(Equal([]danav1alpha1.PendingLabelChange{
+				{Namespace: namespaceName, Key: "owner", Operation: danav1alpha1.LabelAdd, Value: "billing"},
+				{Namespace: namespaceName, Key: "team", Operation: danav1alpha1.LabelRemove, Previous: "a"},
+				{Namespace: namespaceName, Key: "tier", Operation: danav1alpha1.LabelUpdate, Value: "platinum", Previous: "gold"},
+			}))
+			Expect(meta.IsStatusConditionTrue(namespaceLabel.Status.Conditions, "DryRun")).To(BeTrue())
+			Expect(recorder.Events).To(Receive(Equal("Normal DryRun dry run: would add 1, update 1 and remove 1 labels")))
+
+			By("not repeating the Event while the pending changes stay the same")
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).NotTo(Receive())
+
+			By("applying the changes once the dry run is cleared")
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			namespaceLabel.Spec.DryRun = false
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(Equal(map[string]string{"tier": "platinum", "owner": "billing"}))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.PendingChanges).To(BeEmpty())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, "DryRun")).To(BeNil())
+		})
+
+		It("should leave NamespaceLabels alone in namespaces not opted in", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:          k8sClient,
+				Scheme:          scheme,
+				Log:             zap.New(zap.UseDevMode(true)),
+				OptInAnnotation: "dana.io/labels-enabled",
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).NotTo(HaveKey("team"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			condition := meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionStalled)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal("NamespaceNotOptedIn"))
+
+			By("opting the namespace in")
+			ns.Annotations = map[string]string{"dana.io/labels-enabled": "true"}
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("team", "a"))
+		})
+
+		It("should never label a protected namespace", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			protected, err := namespacelabel.NewProtectedNamespaces([]string{"kube-*", namespaceName}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client:              k8sClient,
+				Scheme:              scheme,
+				Log:                 zap.New(zap.UseDevMode(true)),
+				ProtectedNamespaces: protected,
+			}
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).NotTo(HaveKey("team"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Finalizers).To(BeEmpty())
+			condition := meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionStalled)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal("ProtectedNamespace"))
+
+			var unconfigured *namespacelabel.ProtectedNamespaces
+			Expect(unconfigured.IsProtected(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}})).To(BeTrue())
+			Expect(unconfigured.IsProtected(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "openshift-monitoring"}})).To(BeTrue())
+			Expect(unconfigured.IsProtected(ns)).To(BeFalse())
+		})
+
+		It("should restore the labels it overrode when they are dropped or it is deleted", func() {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			namespace.Labels = map[string]string{"team": "ops", "owner": "admin"}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a", "owner": "billing"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("team", "a"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.OverriddenLabels).To(Equal(map[string]string{"team": "ops", "owner": "admin"}))
+
+			By("dropping a key from the spec")
+			namespaceLabel.Spec.Labels = map[string]string{"owner": "billing"}
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("team", "ops"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("owner", "billing"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.OverriddenLabels).To(Equal(map[string]string{"owner": "admin"}))
+
+			By("deleting the NamespaceLabel")
+			Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("team", "ops"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("owner", "admin"))
+		})
+
+		It("should report labels over the quota with a QuotaExceeded condition", func() {
+			namespaceLabel := &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: namespaceName},
+				Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a", "owner": "billing"}},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+
+			controllerReconciler := &NamespaceLabelReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    zap.New(zap.UseDevMode(true)),
+				Quota:  validation.LabelQuota{MaxLabels: 1},
+			}
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).To(HaveOccurred())
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).NotTo(HaveKey("team"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			condition := meta.FindStatusCondition(namespaceLabel.Status.Conditions, "QuotaExceeded")
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Message).To(ContainSubstring("exceeding the quota of 1 labels per NamespaceLabel"))
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, "Invalid")).To(BeNil())
+			Expect(meta.IsStatusConditionTrue(namespaceLabel.Status.Conditions, conditionStalled)).To(BeTrue())
+
+			By("raising the quota")
+			controllerReconciler.Quota = validation.LabelQuota{MaxLabels: 2, MaxManagedLabels: 2}
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("team", "a"))
+			Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, "QuotaExceeded")).To(BeNil())
 		})
 	})
 })
@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// NotificationEvent is a kind of label change notifications are sent for
+type NotificationEvent string
+
+const (
+	// NotifyApplied is sent when labels are added, changed or removed on a Namespace
+	NotifyApplied NotificationEvent = "applied"
+	// NotifyRejected is sent when the controller rejects a NamespaceLabel spec, once per generation
+	NotifyRejected NotificationEvent = "rejected"
+	// NotifyDriftCorrected is sent when labels changed or removed by another manager are reverted
+	NotifyDriftCorrected NotificationEvent = "drift-corrected"
+)
+
+// NotificationEvents are all the kinds of notifications
+var NotificationEvents = []NotificationEvent{NotifyApplied, NotifyRejected, NotifyDriftCorrected}
+
+// notificationQueueSize is the number of notifications waiting to be delivered before new ones are dropped
+const notificationQueueSize = 1000
+
+// Notifier POSTs a notification to webhooks whenever labels are applied, a spec is rejected or drift is
+// corrected. Notifications are delivered in the background, so a slow endpoint never holds up reconciliation;
+// when too many are waiting, new ones are dropped.
+type Notifier struct {
+	// WebhookURLs receive each notification as a JSON object
+	WebhookURLs []string
+	// SlackURLs receive each notification as a Slack-compatible message with a text field
+	SlackURLs []string
+	// Events are the kinds of notifications sent; empty sends all
+	Events []NotificationEvent
+	// Retries is the number of times a failed delivery is retried
+	Retries int
+	// RetryDelay is the delay before the first retry, doubled for every further one; defaults to 1s
+	RetryDelay time.Duration
+	// HTTPClient sends the notifications; nil uses a client timing out after 10s
+	HTTPClient *http.Client
+
+	once  sync.Once
+	queue chan notification
+}
+
+// notification is the JSON payload POSTed to webhooks
+type notification struct {
+	Time  time.Time         `json:"time"`
+	Event NotificationEvent `json:"event"`
+	// Namespace is the Namespace the labels were changed on, or the namespace of the rejected NamespaceLabel
+	Namespace string `json:"namespace"`
+	// Owner is the NamespaceLabel or ClusterNamespaceLabel the notification is about
+	Owner string `json:"owner"`
+	// User last created or updated the owner, if known
+	User string `json:"user,omitempty"`
+	// Diff lists the label changes made
+	Diff []labelDiff `json:"diff,omitempty"`
+	// Result describes the outcome
+	Result string `json:"result"`
+}
+
+// labelDiff is a label change as sent in a notification
+type labelDiff struct {
+	Action   string  `json:"action"`
+	Key      string  `json:"key"`
+	OldValue *string `json:"oldValue,omitempty"`
+	NewValue *string `json:"newValue,omitempty"`
+}
+
+// notifyChanges sends a notification of the label changes made on a Namespace for an owner
+func (n *Notifier) notifyChanges(event NotificationEvent, namespace, owner, user string, changes labelChanges) {
+	if n == nil || len(changes) == 0 {
+		return
+	}
+
+	diff := make([]labelDiff, 0, len(changes))
+	for _, change := range changes {
+		rec := newAuditRecord(time.Time{}, namespace, owner, user, change)
+		diff = append(diff, labelDiff{Action: rec.Action, Key: rec.Key, OldValue: rec.OldValue, NewValue: rec.NewValue})
+	}
+	result := "labels applied"
+	if event == NotifyDriftCorrected {
+		result = "externally changed labels reverted"
+	}
+	n.notify(notification{Event: event, Namespace: namespace, Owner: owner, User: user, Diff: diff, Result: result})
+}
+
+// notify queues a notification for delivery unless its kind is disabled
+func (n *Notifier) notify(notice notification) {
+	if n == nil || (len(n.Events) > 0 && !slices.Contains(n.Events, notice.Event)) {
+		return
+	}
+	notice.Time = time.Now().UTC()
+
+	select {
+	case n.notifications() <- notice:
+	default:
+		notificationsSent.WithLabelValues(string(notice.Event), "Dropped").Inc()
+	}
+}
+
+func (n *Notifier) notifications() chan notification {
+	n.once.Do(func() {
+		n.queue = make(chan notification, notificationQueueSize)
+	})
+	return n.queue
+}
+
+// Start implements manager.Runnable
+func (n *Notifier) Start(ctx context.Context) error {
+	queue := n.notifications()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notice := <-queue:
+			n.deliver(ctx, notice)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable; every instance delivers the notifications of
+// the changes it made
+func (n *Notifier) NeedLeaderElection() bool {
+	return false
+}
+
+// deliver POSTs a notification to every endpoint, retrying failed deliveries
+func (n *Notifier) deliver(ctx context.Context, notice notification) {
+	log := log.FromContext(ctx)
+
+	payload, err := json.Marshal(notice)
+	if err != nil {
+		log.Error(err, "Failed to encode notification")
+		return
+	}
+	slack, err := json.Marshal(map[string]string{"text": notificationText(notice)})
+	if err != nil {
+		log.Error(err, "Failed to encode notification")
+		return
+	}
+
+	for _, endpoint := range n.WebhookURLs {
+		n.post(ctx, endpoint, payload, notice.Event)
+	}
+	for _, endpoint := range n.SlackURLs {
+		n.post(ctx, endpoint, slack, notice.Event)
+	}
+}
+
+// post POSTs a payload to an endpoint, retrying with exponential backoff until it accepts it or the retries
+// are used up
+func (n *Notifier) post(ctx context.Context, endpoint string, payload []byte, event NotificationEvent) {
+	httpClient := n.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	delay := n.RetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	var err error
+	for attempt := 0; attempt <= n.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		if err = postJSON(ctx, httpClient, endpoint, payload); err == nil {
+			notificationsSent.WithLabelValues(string(event), "Delivered").Inc()
+			return
+		}
+	}
+	notificationsSent.WithLabelValues(string(event), "Failed").Inc()
+	log.FromContext(ctx).Error(err, "Failed to deliver notification", "Event", event, "Attempts", n.Retries+1)
+}
+
+// postJSON POSTs a JSON payload, failing unless the endpoint answers with a 2xx status. Errors never include
+// the URL, since webhook URLs carry their credentials.
+func postJSON(ctx context.Context, httpClient *http.Client, endpoint string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return redactURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return redactURL(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification endpoint answered %s", resp.Status)
+	}
+	return nil
+}
+
+// redactURL strips the URL from the errors of parsing and requesting it
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s notification endpoint: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// notificationText describes a notification in a chat message
+func notificationText(notice notification) string {
+	lines := []string{fmt.Sprintf("[%s] %s on namespace %s: %s", notice.Event, notice.Owner, notice.Namespace,
+		notice.Result)}
+	for _, diff := range notice.Diff {
+		switch {
+		case diff.OldValue == nil:
+			lines = append(lines, fmt.Sprintf("+ %s=%s", diff.Key, *diff.NewValue))
+		case diff.NewValue == nil:
+			lines = append(lines, fmt.Sprintf("- %s=%s", diff.Key, *diff.OldValue))
+		default:
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", diff.Key, *diff.OldValue, *diff.NewValue))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
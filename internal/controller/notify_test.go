@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+var _ = Describe("Notifications", func() {
+	namespacedName := types.NamespacedName{Name: "test-resource", Namespace: "default"}
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		createNamespace("default")
+	})
+
+	AfterEach(func() {
+		deleteAllNamespaceLabels()
+		deleteNamespace("default")
+	})
+
+	It("should notify applied labels, corrected drift and rejected specs", func() {
+		Expect(k8sClient.Create(ctx, &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespacedName.Namespace},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a"}},
+		})).To(Succeed())
+
+		notifier := &Notifier{}
+		controllerReconciler := &NamespaceLabelReconciler{
+			Client:   k8sClient,
+			Scheme:   scheme,
+			Log:      zap.New(zap.UseDevMode(true)),
+			Notifier: notifier,
+		}
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		var notice notification
+		Expect(notifier.notifications()).To(Receive(&notice))
+		Expect(notice.Event).To(Equal(NotifyApplied))
+		Expect(notice.Namespace).To(Equal("default"))
+		Expect(notice.Owner).To(Equal("NamespaceLabel default/test-resource"))
+		Expect(notice.Diff).To(HaveLen(1))
+		Expect(notice.Diff[0].Action).To(Equal("LabelAdded"))
+		Expect(*notice.Diff[0].NewValue).To(Equal("a"))
+
+		By("changing the label externally")
+		ns := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, ns)).To(Succeed())
+		ns.Labels["team"] = "b"
+		Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notifier.notifications()).To(Receive(&notice))
+		Expect(notice.Event).To(Equal(NotifyDriftCorrected))
+		Expect(*notice.Diff[0].OldValue).To(Equal("b"))
+		Expect(*notice.Diff[0].NewValue).To(Equal("a"))
+
+		By("making the spec invalid")
+		namespaceLabel := &danav1alpha1.NamespaceLabel{}
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		namespaceLabel.Spec.Labels = map[string]string{"kubernetes.io/managed": "true"}
+		Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notifier.notifications()).To(Receive(&notice))
+		Expect(notice.Event).To(Equal(NotifyRejected))
+		Expect(notice.Result).To(ContainSubstring("kubernetes.io/managed"))
+		Expect(notifier.notifications()).NotTo(Receive())
+	})
+
+	It("should only queue the enabled events", func() {
+		notifier := &Notifier{Events: []NotificationEvent{NotifyRejected}}
+		notifier.notifyChanges(NotifyApplied, "default", "NamespaceLabel default/test-resource", "",
+			labelChanges{{reason: "LabelAdded", key: "team", value: "a"}})
+		Expect(notifier.notifications()).NotTo(Receive())
+	})
+
+	It("should POST the payload to webhooks and Slack, retrying failed deliveries", func() {
+		var mu sync.Mutex
+		var bodies []string
+		failures := 1
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			if failures > 0 {
+				failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, req.URL.Path+" "+string(body))
+		}))
+		defer server.Close()
+
+		notifier := &Notifier{
+			WebhookURLs: []string{server.URL + "/hook"},
+			SlackURLs:   []string{server.URL + "/slack"},
+			Retries:     1,
+			RetryDelay:  time.Millisecond,
+		}
+		value := "a"
+		notifier.deliver(ctx, notification{
+			Event: NotifyApplied, Namespace: "default", Owner: "NamespaceLabel default/test-resource",
+			Diff: []labelDiff{{Action: "LabelAdded", Key: "team", NewValue: &value}}, Result: "labels applied",
+		})
+
+		Expect(bodies).To(HaveLen(2))
+		var payload notification
+		Expect(bodies[0]).To(HavePrefix("/hook "))
+		Expect(json.Unmarshal([]byte(bodies[0][len("/hook "):]), &payload)).To(Succeed())
+		Expect(payload.Event).To(Equal(NotifyApplied))
+		Expect(payload.Diff).To(HaveLen(1))
+		Expect(bodies[1]).To(Equal("/slack " + `{"text":"[applied] NamespaceLabel default/test-resource on ` +
+			`namespace default: labels applied\n+ team=a"}`))
+	})
+
+	It("should keep the URL out of delivery errors", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		endpoint := server.URL + "/services/secret-token"
+		server.Close()
+
+		err := postJSON(ctx, http.DefaultClient, endpoint, []byte("{}"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("Post notification endpoint: "))
+		Expect(err.Error()).NotTo(ContainSubstring("secret-token"))
+
+		err = postJSON(ctx, http.DefaultClient, "http://host/secret-token\x7f", []byte("{}"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).NotTo(ContainSubstring("secret-token"))
+	})
+})
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// labelOwners returns the owner of each label a NamespaceLabel applies to its Namespace: itself for the labels
+// in own and the sibling holding the claim for the others
+func labelOwners(namespaceLabel *danav1alpha1.NamespaceLabel, applied, own map[string]string,
+	claims map[string]labelClaim) map[string]string {
+	owners := make(map[string]string, len(applied))
+	for key := range applied {
+		owner := namespaceLabel
+		if _, owned := own[key]; !owned {
+			owner = claims[key].owner
+		}
+		owners[key] = client.ObjectKeyFromObject(owner).String()
+	}
+	return owners
+}
+
+// OrphanScanner periodically removes the labels recorded in the OwnedLabelsAnnotation of Namespaces whose
+// owning NamespaceLabel no longer exists, e.g. because it was deleted while the controller was stopped and its
+// finalizer was removed by hand
+type OrphanScanner struct {
+	Client client.Client
+	// Interval is how often Namespaces are scanned
+	Interval time.Duration
+}
+
+// Start implements manager.Runnable
+func (s *OrphanScanner) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.scan(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to scan Namespaces for orphaned labels")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable; only the leader removes orphaned labels
+func (s *OrphanScanner) NeedLeaderElection() bool {
+	return true
+}
+
+// scan removes the orphaned labels of every Namespace, with their entries in the OwnedLabelsAnnotation
+func (s *OrphanScanner) scan(ctx context.Context) error {
+	log := log.FromContext(ctx)
+	namespaces := &corev1.NamespaceList{}
+	if err := s.Client.List(ctx, namespaces); err != nil {
+		return err
+	}
+
+	exists := map[string]bool{}
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		owners, err := namespacelabel.LabelOwners(ns)
+		if err != nil {
+			log.Error(err, "Skipping Namespace", "Namespace", ns.Name)
+			continue
+		}
+
+		patch := client.MergeFrom(ns.DeepCopy())
+		orphaned, removed := 0, 0
+		for key, owner := range owners {
+			if _, found := exists[owner]; !found {
+				if exists[owner], err = s.ownerExists(ctx, owner); err != nil {
+					return err
+				}
+			}
+			if exists[owner] {
+				continue
+			}
+			if _, labeled := ns.Labels[key]; labeled {
+				delete(ns.Labels, key)
+				removed++
+			}
+			delete(owners, key)
+			orphaned++
+			log.Info("Removing orphaned label", "Namespace", ns.Name, "Key", key, "Owner", owner)
+		}
+		if orphaned == 0 {
+			continue
+		}
+		if len(owners) == 0 {
+			delete(ns.Annotations, namespacelabel.OwnedLabelsAnnotation)
+		} else {
+			ns.Annotations[namespacelabel.OwnedLabelsAnnotation] = namespacelabel.FormatLabelOwners(owners)
+		}
+		if err := s.Client.Patch(ctx, ns, patch); err != nil {
+			return err
+		}
+		orphanedLabelsRemoved.Add(float64(removed))
+	}
+	return nil
+}
+
+// ownerExists reports whether the NamespaceLabel named by an owner ("namespace/name") exists
+func (s *OrphanScanner) ownerExists(ctx context.Context, owner string) (bool, error) {
+	namespace, name, found := strings.Cut(owner, "/")
+	if !found {
+		return false, nil
+	}
+	err := s.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &danav1alpha1.NamespaceLabel{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+var _ = Describe("Orphaned labels", func() {
+	const namespaceName = "default"
+	namespacedName := types.NamespacedName{Name: "test-resource", Namespace: namespaceName}
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		createNamespace(namespaceName)
+	})
+
+	AfterEach(func() {
+		deleteAllNamespaceLabels()
+		deleteNamespace(namespaceName)
+	})
+
+	It("should record the owner of each applied label and remove the labels of a vanished owner", func() {
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespaceName},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a", "label_2": "b"}},
+		}
+		Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+		controllerReconciler := &NamespaceLabelReconciler{
+			Client: k8sClient,
+			Scheme: scheme,
+			Log:    zap.New(zap.UseDevMode(true)),
+		}
+		_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		namespace := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+		Expect(namespacelabel.LabelOwners(namespace)).To(Equal(map[string]string{
+			"label_1": "default/test-resource", "label_2": "default/test-resource",
+		}))
+
+		By("scanning while the owner exists")
+		scanner := &OrphanScanner{Client: k8sClient}
+		Expect(scanner.scan(ctx)).To(Succeed())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+		Expect(namespace.Labels).To(HaveKeyWithValue("label_1", "a"))
+
+		By("deleting the NamespaceLabel without letting the controller clean up")
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		controllerutil.RemoveFinalizer(namespaceLabel, finalizerName)
+		Expect(k8sClient.Update(ctx, namespaceLabel)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, namespaceLabel)).To(Succeed())
+		namespace.Labels["manual"] = "yes"
+		Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+		Expect(scanner.scan(ctx)).To(Succeed())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+		Expect(namespace.Labels).NotTo(HaveKey("label_1"))
+		Expect(namespace.Labels).NotTo(HaveKey("label_2"))
+		Expect(namespace.Labels).To(HaveKeyWithValue("manual", "yes"))
+		Expect(namespace.Annotations).NotTo(HaveKey(namespacelabel.OwnedLabelsAnnotation))
+	})
+})
@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// partitionLabel marks the ConfigMaps used to coordinate namespace partitions between instances
+	partitionLabel = "dana.io/partition"
+	// partitionConfigMapPrefix prefixes the name of each instance's partition ConfigMap
+	partitionConfigMapPrefix = "namespacelabel-partition-"
+	// partitionHashBuckets is the size of the hash space namespace names are mapped into
+	partitionHashBuckets = 256
+)
+
+// errPartitionsNotLoaded is returned until the partitions of the other instances have been loaded
+var errPartitionsNotLoaded = errors.New("namespace partitions have not been loaded yet")
+
+// HashRange is an inclusive range of namespace name hash buckets
+type HashRange struct {
+	Min uint32
+	Max uint32
+}
+
+// ParseHashRange parses a "min-max" hash bucket range within [0, 255]
+func ParseHashRange(value string) (*HashRange, error) {
+	minValue, maxValue, found := strings.Cut(value, "-")
+	if !found {
+		return nil, fmt.Errorf("hash range '%s' must be in min-max form", value)
+	}
+	lower, err := strconv.ParseUint(minValue, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash range '%s': %w", value, err)
+	}
+	upper, err := strconv.ParseUint(maxValue, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash range '%s': %w", value, err)
+	}
+	if lower > upper || upper >= partitionHashBuckets {
+		return nil, fmt.Errorf("hash range '%s' must satisfy 0 <= min <= max < %d", value, partitionHashBuckets)
+	}
+	return &HashRange{Min: uint32(lower), Max: uint32(upper)}, nil
+}
+
+func (h HashRange) String() string {
+	return fmt.Sprintf("%d-%d", h.Min, h.Max)
+}
+
+// namespaceHash maps a namespace name to its hash bucket
+func namespaceHash(name string) uint32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	return hash.Sum32() % partitionHashBuckets
+}
+
+// Partition describes the set of namespaces owned by a single operator instance.
+// A namespace belongs to the partition when it matches both the selector and the hash range, if set.
+type Partition struct {
+	Name      string
+	Selector  labels.Selector
+	HashRange *HashRange
+}
+
+// Contains reports whether the namespace belongs to the partition
+func (p Partition) Contains(ns *corev1.Namespace) bool {
+	if p.Selector != nil && !p.Selector.Matches(labels.Set(ns.Labels)) {
+		return false
+	}
+	if p.HashRange != nil {
+		hash := namespaceHash(ns.Name)
+		if hash < p.HashRange.Min || hash > p.HashRange.Max {
+			return false
+		}
+	}
+	return true
+}
+
+// Partitioner publishes this instance's partition in a coordination ConfigMap and tracks the
+// partitions declared by other instances, so that two instances never manage the same namespace
+type Partitioner struct {
+	Client client.Client
+	Reader client.Reader
+	// Namespace holds the partition ConfigMaps of all instances
+	Namespace string
+	Own       Partition
+	// RefreshInterval is how often the partition is renewed and peer partitions are reloaded.
+	// Peers that have not renewed within three intervals are considered gone.
+	RefreshInterval time.Duration
+
+	mu     sync.RWMutex
+	loaded bool
+	peers  []Partition
+}
+
+// Start implements manager.Runnable
+func (p *Partitioner) Start(ctx context.Context) error {
+	ticker := time.NewTicker(p.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.refresh(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to refresh namespace partitions")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable; every instance publishes its partition
+func (p *Partitioner) NeedLeaderElection() bool {
+	return false
+}
+
+// Owns reports whether this instance should manage the namespace. When the namespace is also
+// claimed by another instance, neither may manage it and the returned conflict describes why.
+func (p *Partitioner) Owns(ns *corev1.Namespace) (owned bool, conflict string, err error) {
+	if !p.Own.Contains(ns) {
+		return false, "", nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.loaded {
+		return false, "", errPartitionsNotLoaded
+	}
+	for _, peer := range p.peers {
+		if peer.Contains(ns) {
+			return false, fmt.Sprintf("namespace '%s' is claimed by partitions '%s' and '%s'", ns.Name, p.Own.Name, peer.Name), nil
+		}
+	}
+	return true, "", nil
+}
+
+// refresh renews this instance's partition ConfigMap and reloads the partitions of its peers
+func (p *Partitioner) refresh(ctx context.Context) error {
+	if err := p.publish(ctx); err != nil {
+		return err
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := p.Reader.List(ctx, configMaps, client.InNamespace(p.Namespace), client.MatchingLabels{partitionLabel: "true"}); err != nil {
+		return err
+	}
+
+	var peers []Partition
+	expiry := time.Now().Add(-3 * p.RefreshInterval)
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		if configMap.Name == partitionConfigMapPrefix+p.Own.Name {
+			continue
+		}
+		renewed, err := time.Parse(time.RFC3339, configMap.Data["renewTime"])
+		if err != nil || renewed.Before(expiry) {
+			continue
+		}
+		// A peer publishing a malformed partition must not keep this instance from loading the others
+		peer, err := partitionFromConfigMap(configMap)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "Skipping malformed peer partition", "configMap", configMap.Name)
+			continue
+		}
+		peers = append(peers, peer)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.peers = peers
+	p.loaded = true
+	return nil
+}
+
+// publish creates or renews this instance's partition ConfigMap
+func (p *Partitioner) publish(ctx context.Context) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      partitionConfigMapPrefix + p.Own.Name,
+			Namespace: p.Namespace,
+			Labels:    map[string]string{partitionLabel: "true"},
+		},
+		Data: map[string]string{
+			"name":      p.Own.Name,
+			"renewTime": time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	if p.Own.Selector != nil {
+		configMap.Data["selector"] = p.Own.Selector.String()
+	}
+	if p.Own.HashRange != nil {
+		configMap.Data["hashRange"] = p.Own.HashRange.String()
+	}
+
+	err := p.Client.Create(ctx, configMap)
+	if apierrors.IsAlreadyExists(err) {
+		return p.Client.Update(ctx, configMap)
+	}
+	return err
+}
+
+// partitionFromConfigMap decodes a partition published by another instance
+func partitionFromConfigMap(configMap *corev1.ConfigMap) (Partition, error) {
+	partition := Partition{Name: configMap.Data["name"]}
+	if selector, exists := configMap.Data["selector"]; exists {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return Partition{}, fmt.Errorf("invalid selector in partition ConfigMap '%s': %w", configMap.Name, err)
+		}
+		partition.Selector = parsed
+	}
+	if hashRange, exists := configMap.Data["hashRange"]; exists {
+		parsed, err := ParseHashRange(hashRange)
+		if err != nil {
+			return Partition{}, fmt.Errorf("invalid hash range in partition ConfigMap '%s': %w", configMap.Name, err)
+		}
+		partition.HashRange = parsed
+	}
+	return partition, nil
+}
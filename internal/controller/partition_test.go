@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var _ = Describe("Namespace Partitioner", func() {
+	const partitionNamespace = "operators"
+
+	BeforeEach(func() {
+		initTestEnvironment()
+	})
+
+	newPartitioner := func(partition Partition) *Partitioner {
+		return &Partitioner{
+			Client:          k8sClient,
+			Reader:          k8sClient,
+			Namespace:       partitionNamespace,
+			Own:             partition,
+			RefreshInterval: time.Minute,
+		}
+	}
+
+	tenantNamespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"shard": "a"}},
+	}
+
+	It("should parse and validate hash ranges", func() {
+		hashRange, err := ParseHashRange("0-127")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*hashRange).To(Equal(HashRange{Min: 0, Max: 127}))
+
+		_, err = ParseHashRange("128-300")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseHashRange("9")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should only own namespaces inside its partition", func() {
+		partitioner := newPartitioner(Partition{Name: "a", Selector: labels.SelectorFromSet(labels.Set{"shard": "a"})})
+
+		_, _, err := partitioner.Owns(tenantNamespace)
+		Expect(err).To(MatchError(errPartitionsNotLoaded))
+
+		Expect(partitioner.refresh(ctx)).To(Succeed())
+		owned, conflict, err := partitioner.Owns(tenantNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conflict).To(BeEmpty())
+		Expect(owned).To(BeTrue())
+
+		other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b", Labels: map[string]string{"shard": "b"}}}
+		owned, _, err = partitioner.Owns(other)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(owned).To(BeFalse())
+	})
+
+	It("should refuse namespaces claimed by two instances", func() {
+		first := newPartitioner(Partition{Name: "a", Selector: labels.SelectorFromSet(labels.Set{"shard": "a"})})
+		second := newPartitioner(Partition{Name: "b", HashRange: &HashRange{Min: 0, Max: partitionHashBuckets - 1}})
+		Expect(first.refresh(ctx)).To(Succeed())
+		Expect(second.refresh(ctx)).To(Succeed())
+		Expect(first.refresh(ctx)).To(Succeed())
+
+		for _, partitioner := range []*Partitioner{first, second} {
+			owned, conflict, err := partitioner.Owns(tenantNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owned).To(BeFalse())
+			Expect(conflict).To(ContainSubstring("claimed by partitions"))
+		}
+	})
+
+	It("should skip malformed peer partitions", func() {
+		Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: partitionConfigMapPrefix + "broken", Namespace: partitionNamespace,
+				Labels: map[string]string{partitionLabel: "true"},
+			},
+			Data: map[string]string{
+				"name": "broken", "hashRange": "0-300", "renewTime": time.Now().Format(time.RFC3339),
+			},
+		})).To(Succeed())
+		first := newPartitioner(Partition{Name: "a", Selector: labels.SelectorFromSet(labels.Set{"shard": "a"})})
+		second := newPartitioner(Partition{Name: "b", Selector: labels.SelectorFromSet(labels.Set{"shard": "b"})})
+		Expect(second.refresh(ctx)).To(Succeed())
+		Expect(first.refresh(ctx)).To(Succeed())
+
+		owned, conflict, err := first.Owns(tenantNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conflict).To(BeEmpty())
+		Expect(owned).To(BeTrue())
+		Expect(first.peers).To(HaveLen(1))
+		Expect(first.peers[0].Name).To(Equal("b"))
+	})
+})
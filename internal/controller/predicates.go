@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"maps"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// specOrLabelsChanged passes NamespaceLabel updates that change the spec, which bumps the generation, or the
+// labels selecting the instance and partition handling it, so the controller's own status writes do not
+// trigger another reconciliation
+var specOrLabelsChanged = predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{})
+
+// namespaceMetadataChanged passes Namespace updates that can change the labels to apply: changes to its labels,
+// which selectors and protection match, to its annotations, which label templates read, and the start of its
+// deletion. The status summary annotation the controller writes itself is ignored, as are status and
+// managed-field only updates.
+var namespaceMetadataChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return true
+		}
+		return !maps.Equal(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) ||
+			!maps.Equal(foreignAnnotations(e.ObjectOld), foreignAnnotations(e.ObjectNew)) ||
+			e.ObjectOld.GetDeletionTimestamp().IsZero() != e.ObjectNew.GetDeletionTimestamp().IsZero()
+	},
+}
+
+// foreignAnnotations returns the annotations of an object other than the controller's status summary
+func foreignAnnotations(obj client.Object) map[string]string {
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[statusAnnotation]; !ok {
+		return annotations
+	}
+	annotations = maps.Clone(annotations)
+	delete(annotations, statusAnnotation)
+	return annotations
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+var _ = Describe("Event Predicates", func() {
+	It("should only pass Namespace updates changing labels, annotations or deletion", func() {
+		old := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:            "team-a",
+			ResourceVersion: "1",
+			Labels:          map[string]string{"team": "a"},
+			Annotations:     map[string]string{"owner": "alice"},
+		}}
+		update := func(mutate func(ns *corev1.Namespace)) bool {
+			updated := old.DeepCopy()
+			updated.ResourceVersion = "2"
+			mutate(updated)
+			return namespaceMetadataChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})
+		}
+
+		Expect(update(func(ns *corev1.Namespace) { ns.Status.Phase = corev1.NamespaceActive })).To(BeFalse())
+		Expect(update(func(ns *corev1.Namespace) { ns.Annotations[statusAnnotation] = "1 applied" })).To(BeFalse())
+		Expect(update(func(ns *corev1.Namespace) { ns.Labels["tier"] = "gold" })).To(BeTrue())
+		Expect(update(func(ns *corev1.Namespace) { ns.Annotations["owner"] = "bob" })).To(BeTrue())
+		deleted := metav1.Now()
+		Expect(update(func(ns *corev1.Namespace) { ns.DeletionTimestamp = &deleted })).To(BeTrue())
+		Expect(namespaceMetadataChanged.Create(event.CreateEvent{Object: old})).To(BeTrue())
+	})
+
+	It("should skip status-only NamespaceLabel updates", func() {
+		old := &danav1alpha1.NamespaceLabel{ObjectMeta: metav1.ObjectMeta{Name: "labels", Generation: 1}}
+		update := func(mutate func(nl *danav1alpha1.NamespaceLabel)) bool {
+			updated := old.DeepCopy()
+			mutate(updated)
+			return specOrLabelsChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})
+		}
+
+		Expect(update(func(nl *danav1alpha1.NamespaceLabel) { nl.Status.ObservedGeneration = 1 })).To(BeFalse())
+		Expect(update(func(nl *danav1alpha1.NamespaceLabel) { nl.Generation = 2 })).To(BeTrue())
+		Expect(update(func(nl *danav1alpha1.NamespaceLabel) {
+			nl.Labels = map[string]string{"dana.io/instance": "blue"}
+		})).To(BeTrue())
+	})
+})
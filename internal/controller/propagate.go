@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// propagatedLabelsAnnotation records the label keys propagated to a workload, so only those are cleaned up
+const propagatedLabelsAnnotation = "dana.io/propagated-labels"
+
+// workload is a Deployment or StatefulSet along with its pod template and selector
+type workload struct {
+	obj      client.Object
+	template *corev1.PodTemplateSpec
+	selector *metav1.LabelSelector
+}
+
+// propagateLabels applies the labels to the selected workloads of a Namespace and their pod templates, and
+// removes the keys in owned propagated before that are no longer applied or whose workloads are no longer
+// selected. Nil labels remove every owned key.
+func (r *NamespaceLabelReconciler) propagateLabels(ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel,
+	namespace string, owned, labelsToPropagate map[string]string) error {
+	propagate := namespaceLabel.Spec.Propagate
+
+	kinds := slices.Clone(namespaceLabel.Status.PropagatedKinds)
+	selector := labels.Everything()
+	if propagate != nil {
+		for _, kind := range propagate.Kinds {
+			if !slices.Contains(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+		if propagate.Selector != nil {
+			var err error
+			if selector, err = metav1.LabelSelectorAsSelector(propagate.Selector); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, kind := range kinds {
+		workloads, err := r.listWorkloads(ctx, namespace, kind)
+		if err != nil {
+			return err
+		}
+		for _, w := range workloads {
+			var desired map[string]string
+			if labelsToPropagate != nil && propagate != nil && slices.Contains(propagate.Kinds, kind) &&
+				selector.Matches(labels.Set(w.obj.GetLabels())) {
+				desired = labelsToPropagate
+			}
+			if err := r.propagateToWorkload(ctx, w, owned, desired); err != nil {
+				return err
+			}
+		}
+	}
+
+	namespaceLabel.Status.PropagatedKinds = nil
+	if propagate != nil && labelsToPropagate != nil {
+		namespaceLabel.Status.PropagatedKinds = slices.Clone(propagate.Kinds)
+	}
+	return nil
+}
+
+// propagateToWorkload sets the desired labels on a workload and its pod template and removes the owned keys
+// propagated before that are no longer desired. Keys used by the workload's selector are never changed, so the
+// pod template keeps matching it.
+func (r *NamespaceLabelReconciler) propagateToWorkload(
+	ctx context.Context, w workload, owned, desired map[string]string) error {
+	patch := client.MergeFrom(w.obj.DeepCopyObject().(client.Object))
+
+	objLabels := w.obj.GetLabels()
+	if objLabels == nil {
+		objLabels = make(map[string]string)
+	}
+	if w.template.Labels == nil {
+		w.template.Labels = make(map[string]string)
+	}
+	annotations := w.obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	tracked := make(map[string]struct{})
+	for _, key := range strings.Split(annotations[propagatedLabelsAnnotation], ",") {
+		if key != "" {
+			tracked[key] = struct{}{}
+		}
+	}
+
+	changed := false
+	for key := range tracked {
+		_, keep := desired[key]
+		_, mine := owned[key]
+		if keep || !mine {
+			continue
+		}
+		delete(objLabels, key)
+		delete(w.template.Labels, key)
+		delete(tracked, key)
+		changed = true
+	}
+
+	reserved := selectorKeys(w.selector)
+	for key, value := range desired {
+		if _, used := reserved[key]; used {
+			continue
+		}
+		if objLabels[key] != value || w.template.Labels[key] != value {
+			objLabels[key] = value
+			w.template.Labels[key] = value
+			changed = true
+		}
+		if _, exists := tracked[key]; !exists {
+			tracked[key] = struct{}{}
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	keys := make([]string, 0, len(tracked))
+	for key := range tracked {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		annotations[propagatedLabelsAnnotation] = strings.Join(keys, ",")
+	} else {
+		delete(annotations, propagatedLabelsAnnotation)
+	}
+	w.obj.SetLabels(objLabels)
+	w.obj.SetAnnotations(annotations)
+
+	return r.Patch(ctx, w.obj, patch)
+}
+
+// listWorkloads returns the workloads of a kind in a Namespace
+func (r *NamespaceLabelReconciler) listWorkloads(
+	ctx context.Context, namespace string, kind danav1alpha1.PropagateKind) ([]workload, error) {
+	var workloads []workload
+	switch kind {
+	case danav1alpha1.PropagateDeployment:
+		deployments := &appsv1.DeploymentList{}
+		if err := r.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		for i := range deployments.Items {
+			d := &deployments.Items[i]
+			workloads = append(workloads, workload{obj: d, template: &d.Spec.Template, selector: d.Spec.Selector})
+		}
+	case danav1alpha1.PropagateStatefulSet:
+		statefulSets := &appsv1.StatefulSetList{}
+		if err := r.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		for i := range statefulSets.Items {
+			s := &statefulSets.Items[i]
+			workloads = append(workloads, workload{obj: s, template: &s.Spec.Template, selector: s.Spec.Selector})
+		}
+	}
+	return workloads, nil
+}
+
+// selectorKeys returns the label keys a workload selector uses
+func selectorKeys(selector *metav1.LabelSelector) map[string]struct{} {
+	keys := make(map[string]struct{})
+	if selector == nil {
+		return keys
+	}
+	for key := range selector.MatchLabels {
+		keys[key] = struct{}{}
+	}
+	for _, requirement := range selector.MatchExpressions {
+		keys[requirement.Key] = struct{}{}
+	}
+	return keys
+}
+
+// workloadRequests maps a workload event to the watched NamespaceLabels propagating labels into its namespace,
+// so new and relabeled workloads are labeled right away
+func (r *NamespaceLabelReconciler) workloadRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := r.List(ctx, namespaceLabels); err != nil {
+		r.Log.Error(err, "Failed to list NamespaceLabels for workload", "Namespace", obj.GetNamespace(),
+			"Name", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range namespaceLabels.Items {
+		nl := &namespaceLabels.Items[i]
+		if nl.Spec.Propagate == nil || !namespacelabel.IsWatched(r.WatchSelector, nl) {
+			continue
+		}
+		targets, err := namespacelabel.LabeledNamespaces(r.MapNamespace, nl)
+		if err != nil || !slices.Contains(targets, obj.GetNamespace()) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: nl.Namespace, Name: nl.Name},
+		})
+	}
+
+	return requests
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+const (
+	// protectedPrefixesKey lists protected label key prefixes in the protected labels ConfigMap, one per line
+	protectedPrefixesKey = "prefixes"
+	// protectedPatternsKey lists protected label key regular expressions in the protected labels ConfigMap,
+	// one per line
+	protectedPatternsKey = "patterns"
+)
+
+// ProtectedLabelsLoader keeps the runtime rules of ProtectedLabels in sync with a ConfigMap, so clusters can
+// protect their own label domains without restarting the manager
+type ProtectedLabelsLoader struct {
+	Reader client.Reader
+	// ConfigMap is the ConfigMap holding the protected prefixes and patterns
+	ConfigMap types.NamespacedName
+	Labels    *namespacelabel.ProtectedLabels
+	// RefreshInterval is how often the ConfigMap is reloaded
+	RefreshInterval time.Duration
+}
+
+// Start implements manager.Runnable
+func (l *ProtectedLabelsLoader) Start(ctx context.Context) error {
+	ticker := time.NewTicker(l.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.load(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to load protected labels", "ConfigMap", l.ConfigMap)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable; every instance serves the webhooks
+func (l *ProtectedLabelsLoader) NeedLeaderElection() bool {
+	return false
+}
+
+// load replaces the runtime rules with the ConfigMap's; a missing ConfigMap clears them and an invalid one
+// keeps the previous rules
+func (l *ProtectedLabelsLoader) load(ctx context.Context) error {
+	configMap := &corev1.ConfigMap{}
+	if err := l.Reader.Get(ctx, l.ConfigMap, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return l.Labels.Load(nil, nil)
+		}
+		return err
+	}
+
+	return l.Labels.Load(splitLines(configMap.Data[protectedPrefixesKey]), splitLines(configMap.Data[protectedPatternsKey]))
+}
+
+// splitLines splits a ConfigMap value into its non-empty trimmed lines
+func splitLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+var _ = Describe("Protected labels", func() {
+	configMapName := types.NamespacedName{Namespace: "operators", Name: "protected-labels"}
+
+	var (
+		protected *namespacelabel.ProtectedLabels
+		loader    *ProtectedLabelsLoader
+	)
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		var err error
+		protected, err = namespacelabel.NewProtectedLabels([]string{"kubernetes.io"}, []string{`^team\.`})
+		Expect(err).NotTo(HaveOccurred())
+		loader = &ProtectedLabelsLoader{
+			Reader:          k8sClient,
+			ConfigMap:       configMapName,
+			Labels:          protected,
+			RefreshInterval: time.Minute,
+		}
+	})
+
+	It("should protect the configured prefixes and patterns", func() {
+		Expect(protected.IsProtected("kubernetes.io/metadata.name")).To(BeTrue())
+		Expect(protected.IsProtected("team.dana.io/owner")).To(BeTrue())
+		Expect(protected.IsProtected("owner")).To(BeFalse())
+
+		var unconfigured *namespacelabel.ProtectedLabels
+		Expect(unconfigured.IsProtected("kubernetes.io/metadata.name")).To(BeTrue())
+
+		_, err := namespacelabel.NewProtectedLabels(nil, []string{"("})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reload the ConfigMap rules on top of the configured ones", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: configMapName.Namespace, Name: configMapName.Name},
+			Data: map[string]string{
+				"prefixes": "pod-security.kubernetes.io/\ncompany.com/billing\n",
+				"patterns": `^cost-center-[0-9]+$`,
+			},
+		}
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+		Expect(loader.load(ctx)).To(Succeed())
+		Expect(protected.IsProtected("company.com/billing")).To(BeTrue())
+		Expect(protected.IsProtected("cost-center-42")).To(BeTrue())
+		Expect(protected.IsProtected("team.dana.io/owner")).To(BeTrue())
+
+		By("keeping the previous rules when the ConfigMap is invalid")
+		configMap.Data["patterns"] = "("
+		Expect(k8sClient.Update(ctx, configMap)).To(Succeed())
+		Expect(loader.load(ctx)).NotTo(Succeed())
+		Expect(protected.IsProtected("company.com/billing")).To(BeTrue())
+
+		By("clearing the loaded rules when the ConfigMap is deleted")
+		Expect(k8sClient.Delete(ctx, configMap)).To(Succeed())
+		Expect(loader.load(ctx)).To(Succeed())
+		Expect(protected.IsProtected("company.com/billing")).To(BeFalse())
+		Expect(protected.IsProtected("kubernetes.io/metadata.name")).To(BeTrue())
+	})
+})
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// missingNamespaces returns the Namespaces a ClusterNamespaceLabel selects by name that do not exist yet, except
+// protected ones. Namespaces still terminating are left for a later reconciliation.
+func (r *ClusterNamespaceLabelReconciler) missingNamespaces(clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel,
+	existing []corev1.Namespace) ([]string, error) {
+	names, err := namespacelabel.NamespaceNames(clusterNamespaceLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, name := range names {
+		if slices.ContainsFunc(existing, func(ns corev1.Namespace) bool { return ns.Name == name }) ||
+			slices.Contains(missing, name) {
+			continue
+		}
+		if errs := k8svalidation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, fmt.Errorf("namespace name '%s' is invalid: %s", name, errs[0])
+		}
+		if r.ProtectedNamespaces.IsProtected(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}) {
+			continue
+		}
+		missing = append(missing, name)
+	}
+	return missing, nil
+}
+
+// createNamespace creates a Namespace on behalf of a ClusterNamespaceLabel, recording it in the
+// CreatedByAnnotation
+func (r *ClusterNamespaceLabelReconciler) createNamespace(ctx context.Context,
+	clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel, name string) (*corev1.Namespace, error) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Annotations: map[string]string{namespacelabel.CreatedByAnnotation: clusterNamespaceLabel.Name},
+	}}
+	if err := r.Create(ctx, ns); err != nil {
+		return nil, fmt.Errorf("unable to create namespace '%s': %w", name, err)
+	}
+	log.FromContext(ctx).Info("Created Namespace", "Namespace", name)
+	return ns, nil
+}
+
+// deleteCreatedNamespaces deletes the Namespaces a ClusterNamespaceLabel selects by name that carry its
+// CreatedByAnnotation. Namespaces it does not name are never deleted, whatever their annotation says.
+func (r *ClusterNamespaceLabelReconciler) deleteCreatedNamespaces(ctx context.Context,
+	clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel) error {
+	names, err := namespacelabel.NamespaceNames(clusterNamespaceLabel)
+	if err != nil {
+		// Names that never rendered never created a Namespace either
+		return nil
+	}
+
+	for _, name := range names {
+		ns := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if ns.Annotations[namespacelabel.CreatedByAnnotation] != clusterNamespaceLabel.Name ||
+			!ns.DeletionTimestamp.IsZero() || r.ProtectedNamespaces.IsProtected(ns) {
+			continue
+		}
+		if err := r.Delete(ctx, ns); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete namespace '%s': %w", name, err)
+		}
+		log.FromContext(ctx).Info("Deleted created Namespace", "Namespace", name)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// NewRateLimiter returns the work queue rate limiter of a controller: failed requests back off exponentially
+// per object from baseDelay up to maxDelay, and requeues of one namespace are limited to perNamespace per
+// second with bursts of burst, so a namespace that keeps failing cannot crowd out the others. A perNamespace
+// of 0 disables the namespace limit. Like the controller-runtime default, all requeues also share an overall
+// budget of 10 per second with bursts of 100.
+func NewRateLimiter(baseDelay, maxDelay time.Duration, perNamespace float64, burst int) workqueue.RateLimiter {
+	limiters := []workqueue.RateLimiter{
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	}
+	if perNamespace > 0 {
+		limiters = append(limiters, &namespaceRateLimiter{
+			limit:    rate.Limit(perNamespace),
+			burst:    burst,
+			limiters: make(map[string]*rate.Limiter),
+		})
+	}
+	return workqueue.NewMaxOfRateLimiter(limiters...)
+}
+
+// namespaceRateLimiter spaces out the requeues of the requests of each namespace with a token bucket per
+// namespace. Cluster-scoped requests are not limited.
+type namespaceRateLimiter struct {
+	limit    rate.Limit
+	burst    int
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// When returns how long to wait before requeueing a request
+func (l *namespaceRateLimiter) When(item interface{}) time.Duration {
+	req, ok := item.(reconcile.Request)
+	if !ok || req.Namespace == "" {
+		return 0
+	}
+
+	l.mu.Lock()
+	limiter, exists := l.limiters[req.Namespace]
+	if !exists {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[req.Namespace] = limiter
+	}
+	l.mu.Unlock()
+
+	delay := limiter.Reserve().Delay()
+	if delay > 0 {
+		namespaceRequeuesThrottled.Inc()
+	}
+	return delay
+}
+
+// Forget drops the bucket of the request's namespace once it has refilled. The bucket is shared by all the
+// requests of the namespace, but a full bucket limits like a new one, so dropping it only keeps the map from
+// growing with every namespace ever requeued.
+func (l *namespaceRateLimiter) Forget(item interface{}) {
+	req, ok := item.(reconcile.Request)
+	if !ok || req.Namespace == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limiter, exists := l.limiters[req.Namespace]; exists && limiter.Tokens() >= float64(l.burst) {
+		delete(l.limiters, req.Namespace)
+	}
+}
+
+// NumRequeues is always 0, as requeues are counted per object by the backoff
+func (l *namespaceRateLimiter) NumRequeues(interface{}) int {
+	return 0
+}
+
+// retries returns how many times the work queue requeued a NamespaceLabel with backoff since its last
+// successful reconciliation; the queue forgets the failures once one succeeds
+func (r *NamespaceLabelReconciler) retries(namespaceLabel *danav1alpha1.NamespaceLabel) int {
+	if r.RateLimiter == nil {
+		return 0
+	}
+	return r.RateLimiter.NumRequeues(reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: namespaceLabel.Namespace, Name: namespaceLabel.Name},
+	})
+}
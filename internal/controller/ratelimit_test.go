@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("Requeue Rate Limiter", func() {
+	request := func(namespace, name string) ctrl.Request {
+		return ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+	}
+
+	It("should back off failed requests exponentially up to the maximum delay", func() {
+		limiter := NewRateLimiter(time.Second, 3*time.Second, 0, 0)
+		failing := request("team-a", "labels")
+		Expect(limiter.When(failing)).To(Equal(time.Second))
+		Expect(limiter.When(failing)).To(Equal(2 * time.Second))
+		Expect(limiter.When(failing)).To(Equal(3 * time.Second))
+		Expect(limiter.NumRequeues(failing)).To(Equal(3))
+
+		limiter.Forget(failing)
+		Expect(limiter.When(failing)).To(Equal(time.Second))
+	})
+
+	It("should limit the requeues of each namespace separately", func() {
+		limiter := NewRateLimiter(time.Millisecond, time.Millisecond, 1, 2)
+		throttledBefore := testutil.ToFloat64(namespaceRequeuesThrottled)
+
+		Expect(limiter.When(request("team-a", "first"))).To(Equal(time.Millisecond))
+		Expect(limiter.When(request("team-a", "second"))).To(Equal(time.Millisecond))
+		Expect(limiter.When(request("team-a", "third"))).To(BeNumerically(">", 900*time.Millisecond))
+		Expect(testutil.ToFloat64(namespaceRequeuesThrottled)).To(Equal(throttledBefore + 1))
+
+		By("not delaying other namespaces or cluster-scoped requests")
+		Expect(limiter.When(request("team-b", "first"))).To(Equal(time.Millisecond))
+		for i := 0; i < 5; i++ {
+			Expect(limiter.When(request("", "cluster"))).To(BeNumerically("<=", time.Millisecond))
+		}
+	})
+
+	It("should drop the bucket of a namespace once it has refilled", func() {
+		limiter := &namespaceRateLimiter{limit: 10, burst: 1, limiters: make(map[string]*rate.Limiter)}
+		limiter.When(request("team-a", "first"))
+		limiter.When(request("team-b", "first"))
+		Expect(limiter.limiters).To(HaveLen(2))
+
+		By("keeping a bucket that is still draining")
+		limiter.Forget(request("team-a", "first"))
+		Expect(limiter.limiters).To(HaveKey("team-a"))
+
+		Eventually(func() map[string]*rate.Limiter {
+			limiter.Forget(request("team-a", "first"))
+			return limiter.limiters
+		}).ShouldNot(HaveKey("team-a"))
+		Expect(limiter.limiters).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// conditionRejected is True while the controller refuses to apply a spec that breaks the admission rules.
+// It is terminal: the NamespaceLabel is not requeued until its spec changes.
+const conditionRejected = "Rejected"
+
+// rejectedSpec marks an error caused by the spec itself, which retrying cannot clear
+type rejectedSpec struct {
+	error
+}
+
+func (e rejectedSpec) Unwrap() error {
+	return e.error
+}
+
+// reject sets the Rejected condition for an error caused by the spec, recording a Warning Event, sending a
+// notification and counting the rejection once per generation, and returns nil so the NamespaceLabel is not
+// retried with backoff. Any other error clears the condition and is returned to be retried.
+func (r *NamespaceLabelReconciler) reject(namespaceLabel *danav1alpha1.NamespaceLabel, reason string, err error) error {
+	var rejected rejectedSpec
+	if !errors.As(err, &rejected) {
+		meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, conditionRejected)
+		return err
+	}
+
+	previous := meta.FindStatusCondition(namespaceLabel.Status.Conditions, conditionRejected)
+	if previous == nil || previous.ObservedGeneration != namespaceLabel.Generation || previous.Reason != reason {
+		specRejections.WithLabelValues(sourceNamespaceLabel, reason).Inc()
+		if r.Recorder != nil {
+			r.Recorder.Event(namespaceLabel, corev1.EventTypeWarning, conditionRejected, err.Error())
+		}
+		r.Notifier.notify(notification{
+			Event: NotifyRejected, Namespace: namespaceLabel.Namespace,
+			Owner: "NamespaceLabel " + namespaceLabel.Namespace + "/" + namespaceLabel.Name,
+			User:  namespaceLabel.Annotations[namespacelabel.ModifiedByAnnotation], Result: err.Error(),
+		})
+	}
+	meta.SetStatusCondition(&namespaceLabel.Status.Conditions, metav1.Condition{
+		Type: conditionRejected, Status: metav1.ConditionTrue, ObservedGeneration: namespaceLabel.Generation,
+		Reason: reason, Message: err.Error(),
+	})
+	return nil
+}
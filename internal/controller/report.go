@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// ReportName is the name of the NamespaceLabelReport holding the first page of the label health report
+const ReportName = "namespacelabels"
+
+// +kubebuilder:rbac:groups=dana.dana.io,resources=namespacelabelreports,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=dana.dana.io,resources=namespacelabelreports/status,verbs=get;update;patch
+
+// ReportWriter periodically aggregates the status of every NamespaceLabel into the cluster-scoped
+// NamespaceLabelReport, split into pages of PageSize entries: the first page is named ReportName and the
+// following ones ReportName-2, ReportName-3 and so on, owned by the first.
+type ReportWriter struct {
+	Client client.Client
+	// MapNamespace maps a NamespaceLabel's namespace to the Namespace it labels; nil maps it to itself
+	MapNamespace namespacelabel.NamespaceMapper
+	// PageSize is the number of entries per page
+	PageSize int
+	// MaxPages caps the number of pages; entries beyond them are only counted
+	MaxPages int
+	// Interval is how often the report is rewritten
+	Interval time.Duration
+}
+
+// Start implements manager.Runnable
+func (w *ReportWriter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.write(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to write NamespaceLabel report")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable; only the leader writes the report
+func (w *ReportWriter) NeedLeaderElection() bool {
+	return true
+}
+
+// write rebuilds every page of the report and deletes the pages no longer needed
+func (w *ReportWriter) write(ctx context.Context) error {
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := w.Client.List(ctx, namespaceLabels); err != nil {
+		return err
+	}
+	snapshot, err := (&SnapshotExporter{MapNamespace: w.MapNamespace}).snapshot(namespaceLabels.Items)
+	if err != nil {
+		return err
+	}
+
+	entries, summary := reportEntries(snapshot.Namespaces)
+	pages := (len(entries) + w.PageSize - 1) / w.PageSize
+	pages = max(1, min(pages, w.MaxPages))
+	omitted := max(0, len(entries)-pages*w.PageSize)
+	now := metav1.Now()
+
+	var first *danav1alpha1.NamespaceLabelReport
+	for page := 1; page <= pages; page++ {
+		start := min((page-1)*w.PageSize, len(entries))
+		end := min(page*w.PageSize, len(entries))
+		status := danav1alpha1.NamespaceLabelReportStatus{
+			Summary:     summary,
+			Page:        int32(page),
+			Pages:       int32(pages),
+			Omitted:     int32(omitted),
+			Entries:     entries[start:end],
+			GeneratedAt: &now,
+		}
+		if page < pages {
+			status.Next = reportPageName(page + 1)
+		}
+
+		report, err := w.writePage(ctx, reportPageName(page), first, status)
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			first = report
+		}
+	}
+
+	return w.deletePagesAfter(ctx, pages)
+}
+
+// writePage creates or updates one page of the report; pages after the first are owned by it
+func (w *ReportWriter) writePage(ctx context.Context, name string, first *danav1alpha1.NamespaceLabelReport,
+	status danav1alpha1.NamespaceLabelReportStatus) (*danav1alpha1.NamespaceLabelReport, error) {
+	report := &danav1alpha1.NamespaceLabelReport{}
+	err := w.Client.Get(ctx, types.NamespacedName{Name: name}, report)
+	if apierrors.IsNotFound(err) {
+		report = &danav1alpha1.NamespaceLabelReport{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if first != nil {
+			if err := controllerutil.SetOwnerReference(first, report, w.Client.Scheme()); err != nil {
+				return nil, err
+			}
+		}
+		err = w.Client.Create(ctx, report)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report.Status = status
+	if err := w.Client.Status().Update(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// deletePagesAfter deletes the pages numbered after the last page of the report
+func (w *ReportWriter) deletePagesAfter(ctx context.Context, pages int) error {
+	reports := &danav1alpha1.NamespaceLabelReportList{}
+	if err := w.Client.List(ctx, reports); err != nil {
+		return err
+	}
+	for i := range reports.Items {
+		suffix, found := strings.CutPrefix(reports.Items[i].Name, ReportName+"-")
+		if !found {
+			continue
+		}
+		if page, err := strconv.Atoi(suffix); err != nil || page <= pages {
+			continue
+		}
+		if err := w.Client.Delete(ctx, &reports.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportPageName returns the name of a page of the report
+func reportPageName(page int) string {
+	if page == 1 {
+		return ReportName
+	}
+	return fmt.Sprintf("%s-%d", ReportName, page)
+}
+
+// reportStateRank orders entries so the ones needing attention come first
+var reportStateRank = map[danav1alpha1.ReportState]int{
+	danav1alpha1.ReportFailing: 0,
+	danav1alpha1.ReportDrifted: 1,
+	danav1alpha1.ReportInSync:  2,
+}
+
+// reportEntries converts the snapshot of the managed state into report entries, failing ones first, and counts
+// every labeled Namespace by the worst state of its entries
+func reportEntries(snapshots []NamespaceSnapshot) ([]danav1alpha1.ReportEntry, danav1alpha1.ReportSummary) {
+	entries := make([]danav1alpha1.ReportEntry, 0, len(snapshots))
+	worst := make(map[string]danav1alpha1.ReportState)
+	for _, snapshot := range snapshots {
+		entry := danav1alpha1.ReportEntry{
+			Namespace:      snapshot.Namespace,
+			NamespaceLabel: snapshot.NamespaceLabel,
+			State:          danav1alpha1.ReportInSync,
+		}
+		switch {
+		case snapshot.Invalid:
+			entry.State, entry.Reason = danav1alpha1.ReportFailing, "Invalid"
+		case snapshot.Degraded:
+			entry.State, entry.Reason = danav1alpha1.ReportFailing, "Degraded"
+		case snapshot.Stale:
+			entry.State, entry.Reason = danav1alpha1.ReportFailing, "Stale"
+		case !snapshot.Compliant && !snapshot.Drifted:
+			entry.State, entry.Reason = danav1alpha1.ReportFailing, "NotApplied"
+		case snapshot.Drifted:
+			entry.State, entry.Reason = danav1alpha1.ReportDrifted, "ExternalChange"
+		}
+		entries = append(entries, entry)
+
+		if state, seen := worst[entry.Namespace]; !seen || reportStateRank[entry.State] < reportStateRank[state] {
+			worst[entry.Namespace] = entry.State
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return reportStateRank[entries[i].State] < reportStateRank[entries[j].State]
+	})
+
+	summary := danav1alpha1.ReportSummary{Namespaces: int32(len(worst))}
+	for _, state := range worst {
+		switch state {
+		case danav1alpha1.ReportInSync:
+			summary.InSync++
+		case danav1alpha1.ReportDrifted:
+			summary.Drifted++
+		case danav1alpha1.ReportFailing:
+			summary.Failing++
+		}
+	}
+	return entries, summary
+}
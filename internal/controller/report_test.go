@@ -0,0 +1,84 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+var _ = Describe("NamespaceLabel Report", func() {
+	createNamespaceLabel := func(namespace string, conditions ...metav1.Condition) *danav1alpha1.NamespaceLabel {
+		namespaceLabel := &danav1alpha1.NamespaceLabel{ObjectMeta: metav1.ObjectMeta{Name: "labels", Namespace: namespace}}
+		Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+		namespaceLabel.Status.Conditions = conditions
+		Expect(k8sClient.Status().Update(ctx, namespaceLabel)).To(Succeed())
+		return namespaceLabel
+	}
+	applied := metav1.Condition{Type: "LabelsApplied", Status: metav1.ConditionTrue, Reason: "Success"}
+
+	getReport := func(name string) *danav1alpha1.NamespaceLabelReport {
+		report := &danav1alpha1.NamespaceLabelReport{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, report)).To(Succeed())
+		return report
+	}
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		createNamespaceLabel("team-a", applied)
+		drifted := createNamespaceLabel("team-b", applied)
+		drifted.Status.DriftedLabels = map[string]string{"tier": "gold"}
+		Expect(k8sClient.Status().Update(ctx, drifted)).To(Succeed())
+		createNamespaceLabel("team-c", applied, metav1.Condition{Type: "Invalid", Status: metav1.ConditionTrue, Reason: "InvalidSpec"})
+		createNamespaceLabel("team-d")
+	})
+
+	It("should summarize every labeled namespace with the failing ones first", func() {
+		writer := &ReportWriter{Client: k8sClient, PageSize: 10, MaxPages: 1}
+		Expect(writer.write(ctx)).To(Succeed())
+
+		report := getReport(ReportName)
+		Expect(report.Status.Summary).To(Equal(danav1alpha1.ReportSummary{Namespaces: 4, InSync: 1, Drifted: 1, Failing: 2}))
+		Expect(report.Status.Page).To(Equal(int32(1)))
+		Expect(report.Status.Pages).To(Equal(int32(1)))
+		Expect(report.Status.Next).To(BeEmpty())
+		Expect(report.Status.Entries).To(Equal([]danav1alpha1.ReportEntry{
+			{Namespace: "team-c", NamespaceLabel: "team-c/labels", State: danav1alpha1.ReportFailing, Reason: "Invalid"},
+			{Namespace: "team-d", NamespaceLabel: "team-d/labels", State: danav1alpha1.ReportFailing, Reason: "NotApplied"},
+			{Namespace: "team-b", NamespaceLabel: "team-b/labels", State: danav1alpha1.ReportDrifted, Reason: "ExternalChange"},
+			{Namespace: "team-a", NamespaceLabel: "team-a/labels", State: danav1alpha1.ReportInSync},
+		}))
+		Expect(report.Status.GeneratedAt).NotTo(BeNil())
+	})
+
+	It("should split the report into linked pages and count the entries beyond the last one", func() {
+		writer := &ReportWriter{Client: k8sClient, PageSize: 1, MaxPages: 3}
+		Expect(writer.write(ctx)).To(Succeed())
+
+		first := getReport(ReportName)
+		Expect(first.Status.Pages).To(Equal(int32(3)))
+		Expect(first.Status.Omitted).To(Equal(int32(1)))
+		Expect(first.Status.Next).To(Equal(ReportName + "-2"))
+		Expect(first.Status.Entries).To(HaveLen(1))
+
+		second := getReport(ReportName + "-2")
+		Expect(second.Status.Page).To(Equal(int32(2)))
+		Expect(second.Status.Next).To(Equal(ReportName + "-3"))
+		Expect(second.Status.Summary).To(Equal(first.Status.Summary))
+		Expect(second.OwnerReferences).To(HaveLen(1))
+		Expect(second.OwnerReferences[0].Name).To(Equal(ReportName))
+
+		last := getReport(ReportName + "-3")
+		Expect(last.Status.Next).To(BeEmpty())
+
+		By("deleting the pages no longer needed")
+		writer.PageSize = 2
+		Expect(writer.write(ctx)).To(Succeed())
+		Expect(getReport(ReportName).Status.Pages).To(Equal(int32(2)))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ReportName + "-3"},
+			&danav1alpha1.NamespaceLabelReport{})).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// ResyncPath is the path the resync trigger is served on
+const ResyncPath = "/resync"
+
+// ResyncTrigger forces immediate reconciliation of the NamespaceLabels of selected namespaces, for
+// operators re-asserting labels after an out-of-band change
+type ResyncTrigger struct {
+	Client client.Reader
+	events chan event.GenericEvent
+}
+
+// NewResyncTrigger returns a ResyncTrigger reading NamespaceLabels and Namespaces through the client
+func NewResyncTrigger(c client.Reader) *ResyncTrigger {
+	return &ResyncTrigger{Client: c, events: make(chan event.GenericEvent, 1024)}
+}
+
+// source feeds triggered NamespaceLabels to the controller
+func (t *ResyncTrigger) source() source.Source {
+	return source.Channel(t.events, &handler.EnqueueRequestForObject{})
+}
+
+// ResyncResponse is the body returned by the resync trigger
+type ResyncResponse struct {
+	// Triggered is the number of NamespaceLabels queued for reconciliation
+	Triggered int `json:"triggered"`
+}
+
+// ServeHTTP triggers a resync of the namespace given by the "namespace" query parameter, or of the
+// Namespaces matching the "selector" label selector. Only POST is accepted.
+func (t *ResyncTrigger) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	namespace := req.URL.Query().Get("namespace")
+	var selector labels.Selector
+	if text := req.URL.Query().Get("selector"); text != "" {
+		var err error
+		if selector, err = labels.Parse(text); err != nil {
+			http.Error(w, "invalid selector: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if (namespace == "") == (selector == nil) {
+		http.Error(w, "exactly one of namespace or selector is required", http.StatusBadRequest)
+		return
+	}
+
+	triggered, err := t.Trigger(req.Context(), namespace, selector)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ResyncResponse{Triggered: triggered})
+}
+
+// Trigger queues the NamespaceLabels labeling the namespace, or the Namespaces matching the selector,
+// including fan-out NamespaceLabels targeting them
+func (t *ResyncTrigger) Trigger(ctx context.Context, namespace string, selector labels.Selector) (int, error) {
+	namespaces := []string{namespace}
+	if selector != nil {
+		namespaceList := &corev1.NamespaceList{}
+		if err := t.Client.List(ctx, namespaceList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return 0, err
+		}
+		namespaces = namespaces[:0]
+		for _, ns := range namespaceList.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := t.Client.List(ctx, namespaceLabels); err != nil {
+		return 0, err
+	}
+
+	triggered := 0
+	for i := range namespaceLabels.Items {
+		namespaceLabel := &namespaceLabels.Items[i]
+		selected := slices.Contains(namespaces, namespaceLabel.Namespace)
+		for _, target := range namespaceLabel.Spec.TargetNamespaces {
+			selected = selected || slices.Contains(namespaces, target)
+		}
+		if !selected {
+			continue
+		}
+
+		select {
+		case t.events <- event.GenericEvent{Object: namespaceLabel}:
+			triggered++
+		case <-ctx.Done():
+			return triggered, ctx.Err()
+		}
+	}
+
+	return triggered, nil
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+var _ = Describe("Resync Trigger", func() {
+	var trigger *ResyncTrigger
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		for name, team := range map[string]string{"team-a": "red", "team-b": "red", "team-c": "blue"} {
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"team": team}}}
+			Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+			namespaceLabel := &danav1alpha1.NamespaceLabel{ObjectMeta: metav1.ObjectMeta{Name: "labels", Namespace: name}}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+		}
+		trigger = NewResyncTrigger(k8sClient)
+	})
+
+	triggeredNamespaces := func() []string {
+		var namespaces []string
+		for len(trigger.events) > 0 {
+			evt := <-trigger.events
+			namespaces = append(namespaces, evt.Object.GetNamespace())
+		}
+		return namespaces
+	}
+
+	It("should queue the NamespaceLabels of a namespace", func() {
+		req := httptest.NewRequest(http.MethodPost, ResyncPath+"?namespace=team-a", nil)
+		recorder := httptest.NewRecorder()
+		trigger.ServeHTTP(recorder, req)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		response := ResyncResponse{}
+		Expect(json.NewDecoder(recorder.Body).Decode(&response)).To(Succeed())
+		Expect(response.Triggered).To(Equal(1))
+		Expect(triggeredNamespaces()).To(Equal([]string{"team-a"}))
+	})
+
+	It("should queue the NamespaceLabels of namespaces matching a selector", func() {
+		recorder := httptest.NewRecorder()
+		trigger.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ResyncPath+"?selector=team%3Dred", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(triggeredNamespaces()).To(ConsistOf("team-a", "team-b"))
+	})
+
+	It("should reject requests without exactly one target", func() {
+		recorder := httptest.NewRecorder()
+		trigger.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ResyncPath, nil))
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+
+		recorder = httptest.NewRecorder()
+		trigger.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ResyncPath+"?namespace=team-a", nil))
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(trigger.events).To(BeEmpty())
+	})
+
+	It("should feed triggered NamespaceLabels to the controller", func() {
+		Expect(trigger.source()).NotTo(BeNil())
+		_, err := trigger.Trigger(ctx, "team-c", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trigger.events).To(Receive(WithTransform(func(evt event.GenericEvent) string {
+			return evt.Object.GetName()
+		}, Equal("labels"))))
+	})
+})
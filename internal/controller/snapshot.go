@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// SnapshotPath is the path the managed state snapshot is served on
+const SnapshotPath = "/snapshot"
+
+// NamespaceSnapshot is the managed state of one labeled Namespace
+type NamespaceSnapshot struct {
+	Namespace string `json:"namespace"`
+	// NamespaceLabel is the namespace/name of the NamespaceLabel managing the labels
+	NamespaceLabel string `json:"namespaceLabel"`
+	// ManagedLabels is the number of labels applied by the NamespaceLabel
+	ManagedLabels int `json:"managedLabels"`
+	// LastSyncTime is the last time the labels were applied
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Compliant reports that the labels are applied and none of the flags below is raised
+	Compliant bool `json:"compliant"`
+	Drifted   bool `json:"drifted"`
+	Stale     bool `json:"stale"`
+	Invalid   bool `json:"invalid"`
+	Degraded  bool `json:"degraded"`
+}
+
+// Snapshot is the managed state of every labeled Namespace
+type Snapshot struct {
+	GeneratedAt metav1.Time         `json:"generatedAt"`
+	Namespaces  []NamespaceSnapshot `json:"namespaces"`
+}
+
+// SnapshotExporter serves a full snapshot of the managed state for inventory and chargeback systems that
+// ingest it periodically instead of scraping Prometheus
+type SnapshotExporter struct {
+	Client client.Reader
+	// MapNamespace maps a NamespaceLabel's namespace to the Namespace it labels; nil maps it to itself
+	MapNamespace namespacelabel.NamespaceMapper
+}
+
+// ServeHTTP writes the snapshot as JSON, or in the OpenMetrics text format when requested with
+// "format=openmetrics" or an OpenMetrics Accept header
+func (e *SnapshotExporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := e.Client.List(req.Context(), namespaceLabels); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	snapshot, err := e.snapshot(namespaceLabels.Items)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if req.URL.Query().Get("format") == "openmetrics" ||
+		strings.Contains(req.Header.Get("Accept"), "application/openmetrics-text") {
+		if err := writeOpenMetrics(w, snapshot); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(snapshot)
+}
+
+// snapshot builds one entry per Namespace labeled by each NamespaceLabel
+func (e *SnapshotExporter) snapshot(namespaceLabels []danav1alpha1.NamespaceLabel) (*Snapshot, error) {
+	snapshot := &Snapshot{GeneratedAt: metav1.Now(), Namespaces: []NamespaceSnapshot{}}
+	for i := range namespaceLabels {
+		namespaceLabel := &namespaceLabels[i]
+		conditions := namespaceLabel.Status.Conditions
+
+		entry := NamespaceSnapshot{
+			NamespaceLabel: namespaceLabel.Namespace + "/" + namespaceLabel.Name,
+			ManagedLabels:  len(namespaceLabel.Status.AppliedLabels),
+			LastSyncTime:   namespaceLabel.Status.LastAppliedTime,
+			Drifted:        len(namespaceLabel.Status.DriftedLabels) > 0,
+			Stale:          meta.IsStatusConditionTrue(conditions, "Stale"),
+			Invalid:        meta.IsStatusConditionTrue(conditions, "Invalid"),
+			Degraded:       meta.IsStatusConditionTrue(conditions, "Degraded"),
+		}
+		entry.Compliant = meta.IsStatusConditionTrue(conditions, "LabelsApplied") &&
+			!entry.Drifted && !entry.Stale && !entry.Invalid && !entry.Degraded
+
+		namespaces := namespaceLabel.Status.LabeledNamespaces
+		if len(namespaceLabel.Spec.TargetNamespaces) == 0 {
+			target, err := namespacelabel.TargetNamespace(e.MapNamespace, namespaceLabel.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			namespaces = []string{target}
+		}
+		for _, namespace := range namespaces {
+			entry.Namespace = namespace
+			snapshot.Namespaces = append(snapshot.Namespaces, entry)
+		}
+	}
+
+	sort.Slice(snapshot.Namespaces, func(i, j int) bool {
+		if snapshot.Namespaces[i].Namespace != snapshot.Namespaces[j].Namespace {
+			return snapshot.Namespaces[i].Namespace < snapshot.Namespaces[j].Namespace
+		}
+		return snapshot.Namespaces[i].NamespaceLabel < snapshot.Namespaces[j].NamespaceLabel
+	})
+
+	return snapshot, nil
+}
+
+// writeOpenMetrics renders the snapshot as gauges in the OpenMetrics text format
+func writeOpenMetrics(w http.ResponseWriter, snapshot *Snapshot) error {
+	entryLabels := []string{"namespace", "namespacelabel"}
+	managedLabels := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "namespacelabel_snapshot_managed_labels",
+		Help: "Number of labels managed on the namespace",
+	}, entryLabels)
+	lastSync := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "namespacelabel_snapshot_last_sync_timestamp_seconds",
+		Help: "Unix time the labels were last applied to the namespace",
+	}, entryLabels)
+	compliant := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "namespacelabel_snapshot_compliant",
+		Help: "Whether the namespace's labels are applied with no compliance flag raised (1) or not (0)",
+	}, entryLabels)
+	flags := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "namespacelabel_snapshot_flag",
+		Help: "Whether a compliance flag is raised for the namespace (1) or not (0)",
+	}, append(entryLabels, "flag"))
+
+	for _, entry := range snapshot.Namespaces {
+		managedLabels.WithLabelValues(entry.Namespace, entry.NamespaceLabel).Set(float64(entry.ManagedLabels))
+		if entry.LastSyncTime != nil {
+			lastSync.WithLabelValues(entry.Namespace, entry.NamespaceLabel).Set(float64(entry.LastSyncTime.Unix()))
+		}
+		compliant.WithLabelValues(entry.Namespace, entry.NamespaceLabel).Set(boolToFloat(entry.Compliant))
+		for flag, raised := range map[string]bool{
+			"drifted": entry.Drifted, "stale": entry.Stale, "invalid": entry.Invalid, "degraded": entry.Degraded,
+		} {
+			flags.WithLabelValues(entry.Namespace, entry.NamespaceLabel, flag).Set(boolToFloat(raised))
+		}
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(managedLabels, lastSync, compliant, flags)
+	families, err := registry.Gather()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", string(expfmt.FmtOpenMetrics_1_0_0))
+	encoder := expfmt.NewEncoder(w, expfmt.FmtOpenMetrics_1_0_0)
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return err
+		}
+	}
+	if closer, ok := encoder.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+var _ = Describe("Snapshot Exporter", func() {
+	var exporter *SnapshotExporter
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		lastApplied := metav1.Unix(1700000000, 0)
+
+		compliant := &danav1alpha1.NamespaceLabel{ObjectMeta: metav1.ObjectMeta{Name: "labels", Namespace: "team-a"}}
+		Expect(k8sClient.Create(ctx, compliant)).To(Succeed())
+		compliant.Status = danav1alpha1.NamespaceLabelStatus{
+			AppliedLabels:   map[string]string{"team": "a", "tier": "gold"},
+			LastAppliedTime: &lastApplied,
+			Conditions:      []metav1.Condition{{Type: "LabelsApplied", Status: metav1.ConditionTrue, Reason: "Success"}},
+		}
+		Expect(k8sClient.Status().Update(ctx, compliant)).To(Succeed())
+
+		drifted := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "platform"},
+			Spec:       danav1alpha1.NamespaceLabelSpec{TargetNamespaces: []string{"team-b", "team-c"}},
+		}
+		Expect(k8sClient.Create(ctx, drifted)).To(Succeed())
+		drifted.Status = danav1alpha1.NamespaceLabelStatus{
+			AppliedLabels:     map[string]string{"cost-center": "platform"},
+			DriftedLabels:     map[string]string{"cost-center": "other"},
+			LabeledNamespaces: []string{"team-b", "team-c"},
+			Conditions:        []metav1.Condition{{Type: "LabelsApplied", Status: metav1.ConditionTrue, Reason: "Success"}},
+		}
+		Expect(k8sClient.Status().Update(ctx, drifted)).To(Succeed())
+
+		exporter = &SnapshotExporter{Client: k8sClient}
+	})
+
+	It("should export the managed state of every labeled namespace as JSON", func() {
+		recorder := httptest.NewRecorder()
+		exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, SnapshotPath, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		snapshot := Snapshot{}
+		Expect(json.NewDecoder(recorder.Body).Decode(&snapshot)).To(Succeed())
+		Expect(snapshot.Namespaces).To(HaveLen(3))
+		Expect(snapshot.Namespaces[0]).To(And(
+			HaveField("Namespace", "team-a"),
+			HaveField("ManagedLabels", 2),
+			HaveField("Compliant", true),
+		))
+		Expect(snapshot.Namespaces[0].LastSyncTime.Unix()).To(Equal(int64(1700000000)))
+		Expect(snapshot.Namespaces[1]).To(And(
+			HaveField("Namespace", "team-b"),
+			HaveField("NamespaceLabel", "platform/shared"),
+			HaveField("Drifted", true),
+			HaveField("Compliant", false),
+		))
+	})
+
+	It("should export the snapshot in the OpenMetrics format", func() {
+		recorder := httptest.NewRecorder()
+		exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, SnapshotPath+"?format=openmetrics", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		body := recorder.Body.String()
+		Expect(body).To(ContainSubstring(`namespacelabel_snapshot_managed_labels{namespace="team-a",namespacelabel="team-a/labels"} 2`))
+		Expect(body).To(ContainSubstring(`namespacelabel_snapshot_last_sync_timestamp_seconds{namespace="team-a",namespacelabel="team-a/labels"} 1.7e+09`))
+		Expect(body).To(ContainSubstring(`namespacelabel_snapshot_flag{flag="drifted",namespace="team-c",namespacelabel="platform/shared"} 1`))
+		Expect(body).To(HaveSuffix("# EOF\n"))
+	})
+})
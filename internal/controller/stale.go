@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// markPending records the start of a reconciliation that has not been applied yet
+func markPending(namespaceLabel *danav1alpha1.NamespaceLabel) {
+	if namespaceLabel.Status.PendingSince == nil {
+		now := metav1.Now()
+		namespaceLabel.Status.PendingSince = &now
+	}
+}
+
+// setStaleCondition clears the pending state once the labels are applied, and otherwise marks the
+// NamespaceLabel Stale when it has been pending for longer than the configured threshold
+func (r *NamespaceLabelReconciler) setStaleCondition(namespaceLabel *danav1alpha1.NamespaceLabel, applied bool) {
+	if applied {
+		now := metav1.Now()
+		namespaceLabel.Status.LastAppliedTime = &now
+		namespaceLabel.Status.PendingSince = nil
+	}
+
+	if r.StaleThreshold <= 0 {
+		return
+	}
+
+	pendingSince := namespaceLabel.Status.PendingSince
+	if pendingSince == nil || time.Since(pendingSince.Time) <= r.StaleThreshold {
+		meta.RemoveStatusCondition(&namespaceLabel.Status.Conditions, "Stale")
+		staleNamespaceLabels.WithLabelValues(namespaceLabel.Namespace, namespaceLabel.Name).Set(0)
+		return
+	}
+
+	namespaceLabel.Status.Conditions = updateNewCondition(namespaceLabel.Status.Conditions, metav1.Condition{
+		Type:               "Stale",
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "ReconcileStuck",
+		Message: fmt.Sprintf("labels have not been applied since %s, exceeding the %s threshold",
+			pendingSince.UTC().Format(time.RFC3339), r.StaleThreshold),
+	})
+	staleNamespaceLabels.WithLabelValues(namespaceLabel.Namespace, namespaceLabel.Name).Set(1)
+}
+
+// forgetStale drops the stale series of a NamespaceLabel that is deleted or no longer reconciled, so it neither
+// keeps alerting nor lingers in the metrics
+func forgetStale(namespaceLabel *danav1alpha1.NamespaceLabel) {
+	staleNamespaceLabels.DeleteLabelValues(namespaceLabel.Namespace, namespaceLabel.Name)
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WriteBudget limits how fast an instance writes Namespaces, so a mass relabel cannot starve the API
+// server for other controllers. When partitioned, each instance spends its own budget.
+type WriteBudget struct {
+	limiter *rate.Limiter
+	now     func() time.Time
+}
+
+// NewWriteBudget returns a budget of writesPerSecond Namespace writes, allowing bursts of up to burst writes
+func NewWriteBudget(writesPerSecond float64, burst int) *WriteBudget {
+	return &WriteBudget{limiter: rate.NewLimiter(rate.Limit(writesPerSecond), burst), now: time.Now}
+}
+
+// reserve takes writes from the budget. When the budget cannot cover them now, nothing is taken and the
+// delay after which the caller should retry is returned, pushing the backpressure onto the work queue
+// instead of blocking a worker.
+func (b *WriteBudget) reserve(writes int) time.Duration {
+	if b == nil || writes == 0 {
+		return 0
+	}
+	burst := b.limiter.Burst()
+	if writes > burst {
+		writes = burst
+	}
+
+	now := b.now()
+	reservation := b.limiter.ReserveN(now, writes)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+		writeBudgetThrottled.Inc()
+	}
+
+	writeBudgetSaturation.Set(1 - b.limiter.TokensAt(now)/float64(burst))
+	return delay
+}
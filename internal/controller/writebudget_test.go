@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+var _ = Describe("Namespace Write Budget", func() {
+	var (
+		budget *WriteBudget
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		now = time.Now()
+		budget = NewWriteBudget(1, 2)
+		budget.now = func() time.Time { return now }
+	})
+
+	It("should allow bursts and delay writes over budget", func() {
+		Expect(budget.reserve(1)).To(BeZero())
+		Expect(budget.reserve(1)).To(BeZero())
+		Expect(testutil.ToFloat64(writeBudgetSaturation)).To(Equal(1.0))
+
+		throttledBefore := testutil.ToFloat64(writeBudgetThrottled)
+		Expect(budget.reserve(1)).To(Equal(time.Second))
+		Expect(testutil.ToFloat64(writeBudgetThrottled)).To(Equal(throttledBefore + 1))
+
+		By("refilling the budget over time")
+		now = now.Add(time.Second)
+		Expect(budget.reserve(1)).To(BeZero())
+	})
+
+	It("should cap large reservations at the burst", func() {
+		Expect(budget.reserve(5)).To(BeZero())
+		Expect(budget.reserve(1)).To(BeNumerically(">", 0))
+	})
+
+	It("should requeue reconciliations while the budget is exhausted", func() {
+		createNamespace("default")
+		namespacedName := types.NamespacedName{Name: "test-resource", Namespace: "default"}
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespacedName.Namespace},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"label_1": "a"}},
+		}
+		Expect(k8sClient.Create(ctx, namespaceLabel)).To(Succeed())
+		Expect(budget.reserve(2)).To(BeZero())
+
+		controllerReconciler := &NamespaceLabelReconciler{
+			Client:      k8sClient,
+			Scheme:      scheme,
+			Log:         zap.New(zap.UseDevMode(true)),
+			WriteBudget: budget,
+		}
+		result, err := controllerReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		Expect(k8sClient.Get(ctx, namespacedName, namespaceLabel)).To(Succeed())
+		Expect(namespaceLabel.Status.AppliedLabels).To(BeEmpty())
+	})
+})
@@ -0,0 +1,67 @@
+package httpauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	kScheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("HTTP auth filter", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		// The fake reviews accept the token "valid" for user "alice", who may only POST /resync
+		k8sClient := fake.NewClientBuilder().WithScheme(kScheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+				switch review := obj.(type) {
+				case *authenticationv1.TokenReview:
+					if review.Spec.Token == "valid" {
+						review.Status.Authenticated = true
+						review.Status.User = authenticationv1.UserInfo{Username: "alice"}
+					}
+				case *authorizationv1.SubjectAccessReview:
+					attributes := review.Spec.NonResourceAttributes
+					review.Status.Allowed = review.Spec.User == "alice" &&
+						attributes.Path == "/resync" && attributes.Verb == "post"
+				}
+				return nil
+			},
+		}).Build()
+		handler = Filter(k8sClient, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	serve := func(method, token string) int {
+		req := httptest.NewRequest(method, "/resync", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	It("should pass authorized requests through", func() {
+		Expect(serve(http.MethodPost, "valid")).To(Equal(http.StatusOK))
+	})
+
+	It("should reject missing and invalid tokens", func() {
+		Expect(serve(http.MethodPost, "")).To(Equal(http.StatusUnauthorized))
+		Expect(serve(http.MethodPost, "invalid")).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should reject verbs the user is not allowed", func() {
+		Expect(serve(http.MethodGet, "valid")).To(Equal(http.StatusForbidden))
+	})
+})
@@ -0,0 +1,14 @@
+package httpauth
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestHTTPAuth(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "HTTP Auth Suite")
+}
@@ -0,0 +1,315 @@
+// Package inspect reports the state of NamespaceLabels against the Namespaces they label, backing the
+// kubectl-namespacelabel plugin.
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// Row is the summary of one NamespaceLabel's status
+type Row struct {
+	Namespace   string       `json:"namespace"`
+	Name        string       `json:"name"`
+	Ready       string       `json:"ready"`
+	Reason      string       `json:"reason,omitempty"`
+	Applied     int          `json:"applied"`
+	Drifted     int          `json:"drifted"`
+	Overridden  int          `json:"overridden"`
+	LastApplied *metav1.Time `json:"lastApplied,omitempty"`
+}
+
+// DiffKind is how a Namespace label differs from the declared one
+type DiffKind string
+
+const (
+	// DiffMissing is a declared label that is not set on the Namespace
+	DiffMissing DiffKind = "Missing"
+	// DiffChanged is a declared label set on the Namespace with another value
+	DiffChanged DiffKind = "Changed"
+	// DiffStale is a label applied before and no longer declared that is still set on the Namespace
+	DiffStale DiffKind = "Stale"
+)
+
+// Diff is a label whose value on a Namespace differs from the one its owner declares
+type Diff struct {
+	Namespace string   `json:"namespace"`
+	Owner     string   `json:"owner"`
+	Key       string   `json:"key"`
+	Kind      DiffKind `json:"kind"`
+	Desired   string   `json:"desired,omitempty"`
+	Actual    string   `json:"actual,omitempty"`
+}
+
+// Orphan is a label the controller applied to a Namespace that no NamespaceLabel or ClusterNamespaceLabel
+// manages any more
+type Orphan struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+}
+
+// Status summarizes the NamespaceLabels in a namespace, or in every namespace when it is empty
+func Status(ctx context.Context, c client.Reader, namespace string) ([]Row, error) {
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := c.List(ctx, namespaceLabels, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, 0, len(namespaceLabels.Items))
+	for _, nl := range namespaceLabels.Items {
+		row := Row{
+			Namespace:   nl.Namespace,
+			Name:        nl.Name,
+			Ready:       string(metav1.ConditionUnknown),
+			Applied:     len(nl.Status.AppliedLabels),
+			Drifted:     len(nl.Status.DriftedLabels),
+			Overridden:  len(nl.Status.OverriddenLabels),
+			LastApplied: nl.Status.LastAppliedTime,
+		}
+		if ready := meta.FindStatusCondition(nl.Status.Conditions, "Ready"); ready != nil {
+			row.Ready = string(ready.Status)
+			row.Reason = ready.Reason
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Namespace != rows[j].Namespace {
+			return rows[i].Namespace < rows[j].Namespace
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows, nil
+}
+
+// Diffs compares the labels declared by the NamespaceLabels in a namespace, or in every namespace when it is
+// empty, and by the ClusterNamespaceLabels selecting those Namespaces, with the labels set on the Namespaces.
+// Declared labels are resolved like the controller does, with their labelsFrom sources, unexpired label rules
+// and rendered templates, but NamespaceLabels are assumed to label their own namespace and priorities between
+// several NamespaceLabels of one namespace are not applied. Suspended and deleted owners are skipped.
+func Diffs(ctx context.Context, c client.Reader, namespace string, now time.Time) ([]Diff, error) {
+	diffs := []Diff{}
+
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := c.List(ctx, namespaceLabels, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range namespaceLabels.Items {
+		nl := &namespaceLabels.Items[i]
+		if nl.Spec.Suspend || !nl.DeletionTimestamp.IsZero() {
+			continue
+		}
+		declared, err := namespacelabel.ResolveLabelsFrom(ctx, c, nl)
+		if err != nil {
+			return nil, fmt.Errorf("NamespaceLabel '%s/%s': %w", nl.Namespace, nl.Name, err)
+		}
+		declared = withActiveRules(declared, nl, now)
+
+		targets, err := namespacelabel.LabeledNamespaces(nil, nl)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			ns, err := getNamespace(ctx, c, target)
+			if err != nil {
+				return nil, err
+			}
+			if ns == nil {
+				continue
+			}
+			desired, err := namespacelabel.RenderLabels(declared, ns, now)
+			if err != nil {
+				return nil, fmt.Errorf("NamespaceLabel '%s/%s': %w", nl.Namespace, nl.Name, err)
+			}
+			owner := "NamespaceLabel " + nl.Namespace + "/" + nl.Name
+			diffs = append(diffs, compare(ns, owner, desired, nl.Status.AppliedLabels)...)
+		}
+	}
+
+	clusterNamespaceLabels := &danav1alpha1.ClusterNamespaceLabelList{}
+	if err := c.List(ctx, clusterNamespaceLabels); err != nil {
+		return nil, err
+	}
+	if len(clusterNamespaceLabels.Items) > 0 {
+		namespaces, err := listNamespaces(ctx, c, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for i := range clusterNamespaceLabels.Items {
+			cnl := &clusterNamespaceLabels.Items[i]
+			if cnl.Spec.Suspend || !cnl.DeletionTimestamp.IsZero() {
+				continue
+			}
+			for j := range namespaces {
+				ns := &namespaces[j]
+				if selected, err := namespacelabel.SelectsNamespace(cnl, ns); err != nil || !selected {
+					continue
+				}
+				diffs = append(diffs, compare(ns, "ClusterNamespaceLabel "+cnl.Name, cnl.Spec.Labels, nil)...)
+			}
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Namespace != diffs[j].Namespace {
+			return diffs[i].Namespace < diffs[j].Namespace
+		}
+		return diffs[i].Owner < diffs[j].Owner
+	})
+	return diffs, nil
+}
+
+// Orphans returns the labels the controller server-side applied to Namespaces that no NamespaceLabel or
+// ClusterNamespaceLabel manages, e.g. because the controller was stopped before it cleaned them up
+func Orphans(ctx context.Context, c client.Reader) ([]Orphan, error) {
+	namespaces, err := listNamespaces(ctx, c, "")
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := []Orphan{}
+	for i := range namespaces {
+		ns := &namespaces[i]
+		applied, err := appliedLabelKeys(ns)
+		if err != nil {
+			return nil, fmt.Errorf("namespace '%s': %w", ns.Name, err)
+		}
+		if len(applied) == 0 {
+			continue
+		}
+		managed, err := namespacelabel.ManagedLabelKeys(ctx, c, nil, ns, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range applied {
+			value, exists := ns.Labels[key]
+			if _, owned := managed[key]; owned || !exists {
+				continue
+			}
+			orphans = append(orphans, Orphan{Namespace: ns.Name, Key: key, Value: value})
+		}
+	}
+	return orphans, nil
+}
+
+// withActiveRules returns the labels with the value of every label rule that has not expired. Rules that
+// expire after a duration count from when the controller recorded their expiry, and scheduled rules are active
+// while the controller recorded their window open.
+func withActiveRules(labels map[string]string, namespaceLabel *danav1alpha1.NamespaceLabel,
+	now time.Time) map[string]string {
+	if len(namespaceLabel.Spec.LabelRules) == 0 {
+		return labels
+	}
+
+	merged := make(map[string]string, len(labels)+len(namespaceLabel.Spec.LabelRules))
+	for _, rule := range namespaceLabel.Spec.LabelRules {
+		if slices.Contains(namespaceLabel.Status.ExpiredLabels, rule.Key) {
+			continue
+		}
+		if rule.ExpiresAt != nil && !now.Before(rule.ExpiresAt.Time) {
+			continue
+		}
+		if rule.Schedule != nil && !namespaceLabel.Status.LabelWindows[rule.Key].Active {
+			continue
+		}
+		if expiresAt, recorded := namespaceLabel.Status.LabelExpirations[rule.Key]; recorded && !now.Before(expiresAt.Time) {
+			continue
+		}
+		merged[rule.Key] = rule.Value
+	}
+	for key, value := range labels {
+		merged[key] = value
+	}
+	return merged
+}
+
+// compare returns the differences between the desired labels of an owner and a Namespace's labels. Keys the
+// owner applied before and no longer desires are stale while the Namespace still has the applied value.
+func compare(ns *corev1.Namespace, owner string, desired, applied map[string]string) []Diff {
+	diffs := []Diff{}
+	for key, value := range desired {
+		actual, exists := ns.Labels[key]
+		switch {
+		case !exists:
+			diffs = append(diffs, Diff{Namespace: ns.Name, Owner: owner, Key: key, Kind: DiffMissing, Desired: value})
+		case actual != value:
+			diffs = append(diffs, Diff{Namespace: ns.Name, Owner: owner, Key: key, Kind: DiffChanged, Desired: value,
+				Actual: actual})
+		}
+	}
+	for key, value := range applied {
+		if _, declared := desired[key]; declared {
+			continue
+		}
+		if actual, exists := ns.Labels[key]; exists && actual == value {
+			diffs = append(diffs, Diff{Namespace: ns.Name, Owner: owner, Key: key, Kind: DiffStale, Actual: actual})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs
+}
+
+// appliedLabelKeys returns the sorted label keys of a Namespace owned by the controller's field manager
+func appliedLabelKeys(ns *corev1.Namespace) ([]string, error) {
+	var keys []string
+	for _, entry := range ns.ManagedFields {
+		if entry.Manager != namespacelabel.FieldManager || entry.Operation != metav1.ManagedFieldsOperationApply ||
+			entry.FieldsV1 == nil {
+			continue
+		}
+		fields := struct {
+			Metadata struct {
+				Labels map[string]json.RawMessage `json:"f:labels"`
+			} `json:"f:metadata"`
+		}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return nil, fmt.Errorf("unable to read managed fields: %w", err)
+		}
+		for field := range fields.Metadata.Labels {
+			if key, found := strings.CutPrefix(field, "f:"); found && !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// getNamespace returns a Namespace, or nil if it does not exist
+func getNamespace(ctx context.Context, c client.Reader, name string) (*corev1.Namespace, error) {
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, ns); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return ns, nil
+}
+
+// listNamespaces returns the Namespace of the given name, or every Namespace when it is empty
+func listNamespaces(ctx context.Context, c client.Reader, name string) ([]corev1.Namespace, error) {
+	if name != "" {
+		ns, err := getNamespace(ctx, c, name)
+		if err != nil || ns == nil {
+			return nil, err
+		}
+		return []corev1.Namespace{*ns}, nil
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := c.List(ctx, namespaces); err != nil {
+		return nil, err
+	}
+	return namespaces.Items, nil
+}
@@ -0,0 +1,112 @@
+package inspect
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+var _ = Describe("NamespaceLabel inspection", func() {
+	var (
+		ctx       context.Context
+		k8sClient client.Client
+		now       time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Now()
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(danav1alpha1.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "team-a",
+					Labels: map[string]string{"team": "a", "tier": "gold", "legacy": "yes", "cost": "old"},
+					ManagedFields: []metav1.ManagedFieldsEntry{{
+						Manager:    namespacelabel.FieldManager,
+						Operation:  metav1.ManagedFieldsOperationApply,
+						FieldsType: "FieldsV1",
+						FieldsV1: &metav1.FieldsV1{
+							Raw: []byte(`{"f:metadata":{"f:labels":{"f:team":{},"f:tier":{},"f:legacy":{}}}}`),
+						},
+					}},
+				},
+			},
+			&danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "labels"},
+				Spec: danav1alpha1.NamespaceLabelSpec{
+					Labels: map[string]string{"team": "a", "tier": "silver", "owner": "{{ .Namespace.Name }}"},
+					LabelRules: []danav1alpha1.LabelRule{
+						{Key: "freeze", Value: "true", ExpiresAt: &metav1.Time{Time: now.Add(-time.Hour)}},
+					},
+				},
+				Status: danav1alpha1.NamespaceLabelStatus{
+					AppliedLabels: map[string]string{"team": "a", "tier": "gold", "cost": "old"},
+					DriftedLabels: map[string]string{"tier": "gold"},
+					Conditions: []metav1.Condition{
+						{Type: "Ready", Status: metav1.ConditionFalse, Reason: "LabelDrift"},
+					},
+				},
+			},
+		).Build()
+	})
+
+	It("should summarize the status of NamespaceLabels", func() {
+		rows, err := Status(ctx, k8sClient, "team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rows).To(Equal([]Row{{
+			Namespace: "team-a", Name: "labels", Ready: "False", Reason: "LabelDrift", Applied: 3, Drifted: 1,
+		}}))
+
+		rows, err = Status(ctx, k8sClient, "team-b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rows).To(BeEmpty())
+	})
+
+	It("should diff the declared labels with the Namespace labels", func() {
+		diffs, err := Diffs(ctx, k8sClient, "", now)
+		Expect(err).NotTo(HaveOccurred())
+		owner := "NamespaceLabel team-a/labels"
+		Expect(diffs).To(Equal([]Diff{
+			{Namespace: "team-a", Owner: owner, Key: "cost", Kind: DiffStale, Actual: "old"},
+			{Namespace: "team-a", Owner: owner, Key: "owner", Kind: DiffMissing, Desired: "team-a"},
+			{Namespace: "team-a", Owner: owner, Key: "tier", Kind: DiffChanged, Desired: "silver", Actual: "gold"},
+		}))
+
+		By("diffing the labels of ClusterNamespaceLabels")
+		Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+			Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+				NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: []string{"team-a"}},
+				Labels:            map[string]string{"platform": "shared"},
+			},
+		})).To(Succeed())
+		diffs, err = Diffs(ctx, k8sClient, "team-a", now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diffs).To(ContainElement(Diff{
+			Namespace: "team-a", Owner: "ClusterNamespaceLabel platform", Key: "platform", Kind: DiffMissing,
+			Desired: "shared",
+		}))
+	})
+
+	It("should list the controller's labels no resource manages", func() {
+		orphans, err := Orphans(ctx, k8sClient)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(orphans).To(Equal([]Orphan{{Namespace: "team-a", Key: "legacy", Value: "yes"}}))
+	})
+})
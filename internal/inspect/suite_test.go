@@ -0,0 +1,14 @@
+package inspect
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestInspect(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Inspect Suite")
+}
@@ -0,0 +1,165 @@
+// Package lint checks NamespaceLabel manifests against the live cluster's admission rules by submitting
+// them as dry-run requests, so CI can fail a change before it is applied.
+package lint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	danav1beta1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1beta1"
+)
+
+// Result is the outcome of submitting one NamespaceLabel manifest
+type Result struct {
+	File      string `json:"file"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Allowed   bool   `json:"allowed"`
+	Message   string `json:"message,omitempty"`
+}
+
+// Failed reports whether any manifest was rejected
+func Failed(results []Result) bool {
+	for _, result := range results {
+		if !result.Allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Dir submits every NamespaceLabel found in the YAML and JSON files under dir as a dry-run create, or a
+// dry-run update when it already exists. Manifests without a namespace are checked in defaultNamespace.
+func Dir(ctx context.Context, c client.Client, dir, defaultNamespace string) ([]Result, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				files = append(files, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	results := []Result{}
+	for _, file := range files {
+		namespaceLabels, err := load(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load '%s': %w", file, err)
+		}
+		for _, namespaceLabel := range namespaceLabels {
+			if namespaceLabel.Namespace == "" {
+				namespaceLabel.Namespace = defaultNamespace
+			}
+			result, err := submit(ctx, c, namespaceLabel)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check '%s' in '%s': %w", namespaceLabel.Name, file, err)
+			}
+			result.File = file
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// load decodes the NamespaceLabels in a manifest file, skipping documents of other kinds. v1beta1 manifests are
+// converted to v1alpha1; other versions are reported as errors rather than skipped.
+func load(file string) ([]*danav1alpha1.NamespaceLabel, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var namespaceLabels []*danav1alpha1.NamespaceLabel
+	decoder := yaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		var document json.RawMessage
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				return namespaceLabels, nil
+			}
+			return nil, err
+		}
+		typeMeta := metav1.TypeMeta{}
+		if err := json.Unmarshal(document, &typeMeta); err != nil {
+			return nil, err
+		}
+		gv, err := schema.ParseGroupVersion(typeMeta.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		if typeMeta.Kind != "NamespaceLabel" || gv.Group != danav1alpha1.GroupVersion.Group {
+			continue
+		}
+
+		namespaceLabel := &danav1alpha1.NamespaceLabel{}
+		switch gv {
+		case danav1alpha1.GroupVersion:
+			if err := json.Unmarshal(document, namespaceLabel); err != nil {
+				return nil, err
+			}
+		case danav1beta1.GroupVersion:
+			spoke := &danav1beta1.NamespaceLabel{}
+			if err := json.Unmarshal(document, spoke); err != nil {
+				return nil, err
+			}
+			if err := spoke.ConvertTo(namespaceLabel); err != nil {
+				return nil, fmt.Errorf("failed to convert '%s': %w", spoke.Name, err)
+			}
+			namespaceLabel.TypeMeta = metav1.TypeMeta{Kind: "NamespaceLabel", APIVersion: danav1alpha1.GroupVersion.String()}
+		default:
+			return nil, fmt.Errorf("unsupported NamespaceLabel apiVersion '%s'", typeMeta.APIVersion)
+		}
+		namespaceLabels = append(namespaceLabels, namespaceLabel)
+	}
+}
+
+// submit sends a NamespaceLabel through admission without persisting it. Rejections by the API server are
+// reported in the result; errors reaching it are returned.
+func submit(ctx context.Context, c client.Client, namespaceLabel *danav1alpha1.NamespaceLabel) (Result, error) {
+	result := Result{Namespace: namespaceLabel.Namespace, Name: namespaceLabel.Name, Allowed: true}
+
+	existing := &danav1alpha1.NamespaceLabel{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespaceLabel.Namespace, Name: namespaceLabel.Name}, existing)
+	switch {
+	case apierrors.IsNotFound(err):
+		err = c.Create(ctx, namespaceLabel, client.DryRunAll)
+	case err == nil:
+		namespaceLabel.ResourceVersion = existing.ResourceVersion
+		err = c.Update(ctx, namespaceLabel, client.DryRunAll)
+	}
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		result.Allowed = false
+		result.Message = status.Status().Message
+		return result, nil
+	}
+
+	return result, err
+}
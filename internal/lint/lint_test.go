@@ -0,0 +1,118 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+const manifests = `apiVersion: dana.dana.io/v1alpha1
+kind: NamespaceLabel
+metadata:
+  name: allowed
+  namespace: team-a
+spec:
+  labels:
+    environment: dev
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+---
+apiVersion: dana.dana.io/v1alpha1
+kind: NamespaceLabel
+metadata:
+  name: denied
+spec:
+  labels:
+    environment: prod
+`
+
+var _ = Describe("NamespaceLabel lint", func() {
+	var (
+		dir       string
+		k8sClient client.Client
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "lint")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(dir, "labels.yaml"), []byte(manifests), 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a manifest"), 0o600)).To(Succeed())
+
+		scheme := runtime.NewScheme()
+		Expect(danav1alpha1.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if obj.(*danav1alpha1.NamespaceLabel).Spec.Labels["environment"] == "prod" {
+					return apierrors.NewForbidden(danav1alpha1.GroupVersion.WithResource("namespacelabels").GroupResource(),
+						obj.GetName(), nil)
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should report the admission result of every NamespaceLabel manifest", func() {
+		results, err := Dir(context.Background(), k8sClient, dir, "team-b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+
+		Expect(results[0]).To(Equal(Result{
+			File: filepath.Join(dir, "labels.yaml"), Namespace: "team-a", Name: "allowed", Allowed: true,
+		}))
+		Expect(results[1].Namespace).To(Equal("team-b"))
+		Expect(results[1].Allowed).To(BeFalse())
+		Expect(results[1].Message).To(ContainSubstring("forbidden"))
+		Expect(Failed(results)).To(BeTrue())
+
+		By("checking that nothing was persisted")
+		namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+		Expect(k8sClient.List(context.Background(), namespaceLabels)).To(Succeed())
+		Expect(namespaceLabels.Items).To(BeEmpty())
+	})
+
+	It("should convert v1beta1 manifests and refuse unsupported versions", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "v1beta1.yaml"), []byte(`apiVersion: dana.dana.io/v1beta1
+kind: NamespaceLabel
+metadata:
+  name: beta
+  namespace: team-a
+spec:
+  labels:
+  - key: environment
+    value: prod
+`), 0o600)).To(Succeed())
+		results, err := Dir(context.Background(), k8sClient, dir, "team-b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(3))
+		Expect(results[2].File).To(Equal(filepath.Join(dir, "v1beta1.yaml")))
+		Expect(results[2].Name).To(Equal("beta"))
+		Expect(results[2].Allowed).To(BeFalse())
+
+		Expect(os.WriteFile(filepath.Join(dir, "v2.yaml"), []byte(`apiVersion: dana.dana.io/v2
+kind: NamespaceLabel
+metadata:
+  name: future
+`), 0o600)).To(Succeed())
+		_, err = Dir(context.Background(), k8sClient, dir, "team-b")
+		Expect(err).To(MatchError(ContainSubstring("unsupported NamespaceLabel apiVersion 'dana.dana.io/v2'")))
+	})
+})
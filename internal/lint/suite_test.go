@@ -0,0 +1,14 @@
+package lint
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLint(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Lint Suite")
+}
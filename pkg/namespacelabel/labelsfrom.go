@@ -0,0 +1,72 @@
+package namespacelabel
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// ResolveLabelsFrom returns a NamespaceLabel's labels merged over the data of the ConfigMaps and Secrets
+// referenced by spec.labelsFrom. Later sources override earlier ones and spec.labels overrides them all.
+func ResolveLabelsFrom(ctx context.Context, c client.Reader,
+	namespaceLabel *danav1alpha1.NamespaceLabel) (map[string]string, error) {
+	if len(namespaceLabel.Spec.LabelsFrom) == 0 {
+		return namespaceLabel.Spec.Labels, nil
+	}
+
+	resolved := make(map[string]string)
+	for _, source := range namespaceLabel.Spec.LabelsFrom {
+		data, err := labelsSourceData(ctx, c, namespaceLabel.Namespace, source)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range data {
+			resolved[source.Prefix+key] = value
+		}
+	}
+	for key, value := range namespaceLabel.Spec.Labels {
+		resolved[key] = value
+	}
+	return resolved, nil
+}
+
+// labelsSourceData returns the data of the ConfigMap or Secret a labels source references; a missing optional
+// object has no data
+func labelsSourceData(ctx context.Context, c client.Reader, namespace string,
+	source danav1alpha1.LabelsFromSource) (map[string]string, error) {
+	switch {
+	case source.ConfigMapRef != nil:
+		configMap := &corev1.ConfigMap{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: source.ConfigMapRef.Name}, configMap)
+		if apierrors.IsNotFound(err) && source.ConfigMapRef.Optional {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read labels from ConfigMap '%s': %w", source.ConfigMapRef.Name, err)
+		}
+		return configMap.Data, nil
+
+	case source.SecretRef != nil:
+		secret := &corev1.Secret{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: source.SecretRef.Name}, secret)
+		if apierrors.IsNotFound(err) && source.SecretRef.Optional {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read labels from Secret '%s': %w", source.SecretRef.Name, err)
+		}
+		data := make(map[string]string, len(secret.Data))
+		for key, value := range secret.Data {
+			data[key] = string(value)
+		}
+		return data, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,251 @@
+// Package namespacelabel holds the primitives shared by the NamespaceLabel controller and webhooks
+package namespacelabel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// ManagementLabelPrefix is the prefix of the Kubernetes management labels tenants may not set, protected
+// unless other protected labels are configured
+const ManagementLabelPrefix = "kubernetes.io"
+
+// ControllerAnnotationPrefix is the prefix of the annotations the controller writes on Namespaces itself,
+// which NamespaceLabels may never set
+const ControllerAnnotationPrefix = "namespacelabel.dana.io/"
+
+// OwnedLabelsAnnotation records on a Namespace the NamespaceLabel owning each label the controller applied, as
+// a JSON object of label keys to "namespace/name", so other tools can tell managed labels from manual ones
+const OwnedLabelsAnnotation = ControllerAnnotationPrefix + "owned-labels"
+
+// CreatedByAnnotation records on a Namespace the ClusterNamespaceLabel that created it through
+// spec.createNamespace, which may delete it again when it is removed
+const CreatedByAnnotation = ControllerAnnotationPrefix + "created-by"
+
+// DefaultProtectedAnnotationPrefixes are the annotation key prefixes NamespaceLabels may not set unless other
+// protected annotations are configured
+var DefaultProtectedAnnotationPrefixes = []string{
+	"kubernetes.io/", "k8s.io/", "scheduler.alpha.kubernetes.io/", "node.kubernetes.io/",
+}
+
+// FieldManager is the field manager the NamespaceLabel controller server-side applies Namespace labels and
+// annotations with
+const FieldManager = "namespacelabel-controller"
+
+// ModifiedByAnnotation records the user who last created or updated a NamespaceLabel, set by the defaulting
+// webhook and written to the audit records of the label changes made for it
+const ModifiedByAnnotation = "dana.io/last-modified-by"
+
+// AdmissionBypassAnnotation records why the webhook waived its admission rules for the current spec of a
+// NamespaceLabel, so the controller applies it without re-checking them. Only the defaulting webhook sets it,
+// overwriting any value set by the request.
+const AdmissionBypassAnnotation = "dana.io/admission-bypass"
+
+const (
+	// BypassTrustedPrincipal marks a NamespaceLabel whose spec was last changed by a trusted principal
+	BypassTrustedPrincipal = "trusted-principal"
+	// BypassBreakGlass marks a NamespaceLabel whose spec was last changed with a valid break-glass annotation
+	BypassBreakGlass = "break-glass"
+)
+
+// BreakGlassAnnotation carries the justification for bypassing a webhook denial
+const BreakGlassAnnotation = "dana.io/break-glass"
+
+// BreakGlassExpiresAnnotation carries the RFC3339 time after which the break-glass annotation is ignored
+const BreakGlassExpiresAnnotation = "dana.io/break-glass-expires"
+
+// AdmissionBypassed reports whether the webhook waived its admission rules for the current spec of a
+// NamespaceLabel. A break-glass waiver lapses when its annotation expires.
+func AdmissionBypassed(namespaceLabel *danav1alpha1.NamespaceLabel, now time.Time) bool {
+	switch namespaceLabel.Annotations[AdmissionBypassAnnotation] {
+	case BypassTrustedPrincipal:
+		return true
+	case BypassBreakGlass:
+		expires, valid := BreakGlassExpiry(namespaceLabel)
+		return valid && now.Before(expires)
+	}
+	return false
+}
+
+// BreakGlassExpiry returns when the break-glass annotation of a NamespaceLabel expires, if it carries a
+// well-formed one
+func BreakGlassExpiry(namespaceLabel *danav1alpha1.NamespaceLabel) (time.Time, bool) {
+	expires, err := time.Parse(time.RFC3339, namespaceLabel.Annotations[BreakGlassExpiresAnnotation])
+	return expires, err == nil
+}
+
+// IsWatched reports whether an object carries the labels selected by an instance's watch selector;
+// a nil selector watches every object
+func IsWatched(selector labels.Selector, obj client.Object) bool {
+	return selector == nil || selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// OptedIn reports whether a namespace opted in to NamespaceLabels through the annotation, which cluster admins
+// set to "true". An empty annotation opts every namespace in.
+func OptedIn(annotation string, ns *corev1.Namespace) bool {
+	return annotation == "" || ns.Annotations[annotation] == "true"
+}
+
+// LabelOwners returns the owner of each label recorded in the OwnedLabelsAnnotation of a Namespace, or nil
+// when it carries none
+func LabelOwners(ns *corev1.Namespace) (map[string]string, error) {
+	value, exists := ns.Annotations[OwnedLabelsAnnotation]
+	if !exists {
+		return nil, nil
+	}
+	owners := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &owners); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", OwnedLabelsAnnotation, err)
+	}
+	return owners, nil
+}
+
+// FormatLabelOwners returns the value of the OwnedLabelsAnnotation recording the owners of labels
+func FormatLabelOwners(owners map[string]string) string {
+	// Marshaling a map of strings cannot fail, and sorts its keys
+	value, _ := json.Marshal(owners)
+	return string(value)
+}
+
+// NamespaceMapper maps the namespace a NamespaceLabel lives in to the name of the Namespace object that
+// receives its labels. Virtual-cluster environments use it to target the host namespace backing a
+// tenant-visible namespace.
+type NamespaceMapper func(namespace string) (string, error)
+
+// namespaceTemplateData is the data available to target namespace templates
+type namespaceTemplateData struct {
+	// Namespace is the namespace of the NamespaceLabel
+	Namespace string
+}
+
+// NewTemplateNamespaceMapper returns a NamespaceMapper rendering the target namespace from a Go
+// template, e.g. "{{ .Namespace }}-x-tenants-x-vcluster"
+func NewTemplateNamespaceMapper(text string) (NamespaceMapper, error) {
+	tmpl, err := template.New("target-namespace").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target namespace template: %w", err)
+	}
+
+	return func(namespace string) (string, error) {
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, namespaceTemplateData{Namespace: namespace}); err != nil {
+			return "", fmt.Errorf("failed to render target namespace for '%s': %w", namespace, err)
+		}
+		if rendered.Len() == 0 {
+			return "", fmt.Errorf("target namespace for '%s' rendered empty", namespace)
+		}
+		return rendered.String(), nil
+	}, nil
+}
+
+// TargetNamespace resolves the Namespace object labeled for a NamespaceLabel namespace;
+// a nil mapper labels the NamespaceLabel's own namespace
+func TargetNamespace(mapper NamespaceMapper, namespace string) (string, error) {
+	if mapper == nil {
+		return namespace, nil
+	}
+	return mapper(namespace)
+}
+
+// LabeledNamespaces resolves the Namespaces a NamespaceLabel labels: its targetNamespaces when set,
+// otherwise the target of its own namespace
+func LabeledNamespaces(mapper NamespaceMapper, namespaceLabel *danav1alpha1.NamespaceLabel) ([]string, error) {
+	if len(namespaceLabel.Spec.TargetNamespaces) > 0 {
+		return namespaceLabel.Spec.TargetNamespaces, nil
+	}
+	target, err := TargetNamespace(mapper, namespaceLabel.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return []string{target}, nil
+}
+
+// SelectsNamespace reports whether a ClusterNamespaceLabel's namespaceSelector selects a Namespace
+func SelectsNamespace(clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel, ns *corev1.Namespace) (bool, error) {
+	selector := clusterNamespaceLabel.Spec.NamespaceSelector
+	names, err := NamespaceNames(clusterNamespaceLabel)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespaceSelector: %w", err)
+	}
+	if slices.Contains(names, ns.Name) {
+		return true, nil
+	}
+	if selector.LabelSelector == nil {
+		return false, nil
+	}
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector.LabelSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespaceSelector: %w", err)
+	}
+	// An empty label selector matches everything; treat it as selecting nothing like a missing one
+	return !labelSelector.Empty() && labelSelector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// ManagedLabelKeys returns the label keys the NamespaceLabels and ClusterNamespaceLabels labeling a Namespace
+// manage on it, except those of the excluded NamespaceLabel or ClusterNamespaceLabel, if any. NamespaceLabels are
+// represented by the labels they last applied, or their spec until they applied any.
+func ManagedLabelKeys(ctx context.Context, c client.Reader, mapper NamespaceMapper, ns *corev1.Namespace,
+	exclude client.Object) (map[string]struct{}, error) {
+	keys := make(map[string]struct{})
+
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := c.List(ctx, namespaceLabels); err != nil {
+		return nil, err
+	}
+	for i := range namespaceLabels.Items {
+		nl := &namespaceLabels.Items[i]
+		if excludes(exclude, nl) || !nl.DeletionTimestamp.IsZero() {
+			continue
+		}
+		targets, err := LabeledNamespaces(mapper, nl)
+		if err != nil || !slices.Contains(targets, ns.Name) {
+			continue
+		}
+		managed := nl.Status.AppliedLabels
+		if managed == nil {
+			managed = nl.Spec.Labels
+		}
+		for key := range managed {
+			keys[key] = struct{}{}
+		}
+	}
+
+	clusterNamespaceLabels := &danav1alpha1.ClusterNamespaceLabelList{}
+	if err := c.List(ctx, clusterNamespaceLabels); err != nil {
+		return nil, err
+	}
+	for i := range clusterNamespaceLabels.Items {
+		clusterNamespaceLabel := &clusterNamespaceLabels.Items[i]
+		if excludes(exclude, clusterNamespaceLabel) || !clusterNamespaceLabel.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if selected, err := SelectsNamespace(clusterNamespaceLabel, ns); err != nil || !selected {
+			continue
+		}
+		for key := range clusterNamespaceLabel.Spec.Labels {
+			keys[key] = struct{}{}
+		}
+	}
+
+	return keys, nil
+}
+
+// excludes reports whether obj is the object excluded from a count; nil excludes nothing
+func excludes(exclude, obj client.Object) bool {
+	return exclude != nil && reflect.TypeOf(exclude) == reflect.TypeOf(obj) &&
+		exclude.GetNamespace() == obj.GetNamespace() && exclude.GetName() == obj.GetName()
+}
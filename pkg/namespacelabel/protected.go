@@ -0,0 +1,87 @@
+package namespacelabel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ProtectedLabels matches the label keys tenants may not set: the rules configured at startup plus the rules
+// loaded at runtime, which can be replaced without a restart. A nil ProtectedLabels protects
+// ManagementLabelPrefix only.
+type ProtectedLabels struct {
+	static protectedRules
+
+	mu     sync.RWMutex
+	loaded protectedRules
+}
+
+// protectedRules are label key prefixes and regular expressions
+type protectedRules struct {
+	prefixes []string
+	patterns []*regexp.Regexp
+}
+
+// NewProtectedLabels returns ProtectedLabels protecting the keys starting with one of the prefixes or matching
+// one of the regular expressions
+func NewProtectedLabels(prefixes, patterns []string) (*ProtectedLabels, error) {
+	rules, err := newProtectedRules(prefixes, patterns)
+	if err != nil {
+		return nil, err
+	}
+	return &ProtectedLabels{static: rules}, nil
+}
+
+// Load replaces the rules loaded at runtime; the rules configured at startup stay in effect
+func (p *ProtectedLabels) Load(prefixes, patterns []string) error {
+	rules, err := newProtectedRules(prefixes, patterns)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loaded = rules
+	return nil
+}
+
+// IsProtected reports whether a label key is protected
+func (p *ProtectedLabels) IsProtected(key string) bool {
+	if p == nil {
+		return strings.HasPrefix(key, ManagementLabelPrefix)
+	}
+	if p.static.matches(key) {
+		return true
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.loaded.matches(key)
+}
+
+func newProtectedRules(prefixes, patterns []string) (protectedRules, error) {
+	rules := protectedRules{prefixes: prefixes}
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return protectedRules{}, fmt.Errorf("invalid protected label pattern '%s': %w", pattern, err)
+		}
+		rules.patterns = append(rules.patterns, compiled)
+	}
+	return rules, nil
+}
+
+func (r protectedRules) matches(key string) bool {
+	for _, prefix := range r.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	for _, pattern := range r.patterns {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package namespacelabel
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultProtectedNamespaces are the namespace name patterns never labeled unless others are configured
+var DefaultProtectedNamespaces = []string{"kube-system", "kube-public", "openshift-*"}
+
+// ProtectedNamespaces matches the Namespaces the controller never labels, by name or by labels. A nil
+// ProtectedNamespaces protects DefaultProtectedNamespaces.
+type ProtectedNamespaces struct {
+	names    []string
+	selector labels.Selector
+}
+
+// NewProtectedNamespaces returns ProtectedNamespaces protecting the Namespaces whose name matches one of the
+// shell patterns, e.g. "openshift-*", or whose labels match the selector; a nil selector matches none
+func NewProtectedNamespaces(names []string, selector labels.Selector) (*ProtectedNamespaces, error) {
+	for _, name := range names {
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("invalid protected namespace pattern '%s': %w", name, err)
+		}
+	}
+	return &ProtectedNamespaces{names: names, selector: selector}, nil
+}
+
+// IsProtected reports whether a Namespace is protected
+func (p *ProtectedNamespaces) IsProtected(ns *corev1.Namespace) bool {
+	names := DefaultProtectedNamespaces
+	if p != nil {
+		names = p.names
+		if p.selector != nil && p.selector.Matches(labels.Set(ns.Labels)) {
+			return true
+		}
+	}
+	for _, name := range names {
+		if matched, _ := path.Match(name, ns.Name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package namespacelabel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// LabelWindowAt returns whether the window of a label schedule is open at a time and when it next opens, or
+// closes while it is open. The window is open when the remove schedule fires before the apply schedule does
+// again; when both fire at the same time the label is cleared.
+func LabelWindowAt(schedule danav1alpha1.LabelSchedule, now time.Time) (bool, time.Time, error) {
+	location := time.UTC
+	if schedule.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(schedule.TimeZone); err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid timeZone: %w", err)
+		}
+	}
+	apply, err := cron.ParseStandard(schedule.Apply)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid apply schedule: %w", err)
+	}
+	remove, err := cron.ParseStandard(schedule.Remove)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid remove schedule: %w", err)
+	}
+
+	// The cron library returns the zero time for schedules that never fire, e.g. on February 30th
+	nextApply, nextRemove := apply.Next(now.In(location)), remove.Next(now.In(location))
+	if nextApply.IsZero() || nextRemove.IsZero() {
+		return false, time.Time{}, fmt.Errorf("schedules must fire at least once")
+	}
+	if nextRemove.Before(nextApply) {
+		return true, nextRemove, nil
+	}
+	return false, nextApply, nil
+}
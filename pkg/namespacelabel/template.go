@@ -0,0 +1,113 @@
+package namespacelabel
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// templateNamespace is the Namespace as seen by label value templates, e.g. {{ .Namespace.Name }} or
+// {{ .Namespace.Annotations "owner" }}
+type templateNamespace struct {
+	Name        string
+	labels      map[string]string
+	annotations map[string]string
+}
+
+// Labels returns the value of a label of the Namespace, or "" if it is not set
+func (n templateNamespace) Labels(key string) string {
+	return n.labels[key]
+}
+
+// Annotations returns the value of an annotation of the Namespace, or "" if it is not set
+func (n templateNamespace) Annotations(key string) string {
+	return n.annotations[key]
+}
+
+// labelTemplateData is the data available to label value templates
+type labelTemplateData struct {
+	Namespace templateNamespace
+}
+
+// IsTemplate reports whether a label value is a template rendered against the labeled Namespace
+func IsTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// ParseTemplate parses a templated label value; now is the time formatted by the now function
+func ParseTemplate(value string, now time.Time) (*template.Template, error) {
+	return template.New("label-value").Funcs(template.FuncMap{
+		"now": func(layout string) string { return now.Format(layout) },
+	}).Parse(value)
+}
+
+// RenderLabels returns the labels with their templated values rendered against a Namespace at the given time.
+// Values using now change as time passes and are re-rendered when the labels are next reconciled.
+func RenderLabels(labels map[string]string, ns *corev1.Namespace, now time.Time) (map[string]string, error) {
+	if labels == nil {
+		return nil, nil
+	}
+
+	data := labelTemplateData{Namespace: templateNamespace{Name: ns.Name, labels: ns.Labels, annotations: ns.Annotations}}
+	rendered := make(map[string]string, len(labels))
+	for key, value := range labels {
+		if !IsTemplate(value) {
+			rendered[key] = value
+			continue
+		}
+
+		tmpl, err := ParseTemplate(value, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for label '%s': %w", key, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			return nil, fmt.Errorf("unable to render label '%s' for namespace '%s': %w", key, ns.Name, err)
+		}
+		rendered[key] = out.String()
+	}
+	return rendered, nil
+}
+
+// nameTemplateData is the data available to the templates of namespaceSelector.names, e.g.
+// {{ .ClusterNamespaceLabel.Name }}-dev or {{ .ClusterNamespaceLabel.Labels "tenant" }}
+type nameTemplateData struct {
+	ClusterNamespaceLabel templateNamespace
+}
+
+// NamespaceNames returns the Namespaces a ClusterNamespaceLabel selects by name, with templated names rendered
+// against the ClusterNamespaceLabel itself
+func NamespaceNames(clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel) ([]string, error) {
+	names := clusterNamespaceLabel.Spec.NamespaceSelector.Names
+	if !slices.ContainsFunc(names, IsTemplate) {
+		return names, nil
+	}
+
+	data := nameTemplateData{ClusterNamespaceLabel: templateNamespace{Name: clusterNamespaceLabel.Name,
+		labels: clusterNamespaceLabel.Labels, annotations: clusterNamespaceLabel.Annotations}}
+	rendered := make([]string, 0, len(names))
+	for _, name := range names {
+		if !IsTemplate(name) {
+			rendered = append(rendered, name)
+			continue
+		}
+
+		tmpl, err := template.New("namespace-name").Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for namespace name '%s': %w", name, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			return nil, fmt.Errorf("unable to render namespace name '%s': %w", name, err)
+		}
+		rendered = append(rendered, out.String())
+	}
+	return rendered, nil
+}
@@ -0,0 +1,68 @@
+package validation
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// celCostLimit bounds the cost of evaluating a single LabelPolicy rule, matching the per-call limit the
+// apiserver applies to CRD validation rules
+const celCostLimit = 1000000
+
+// celEnv declares the variables LabelPolicy rules are evaluated with
+var celEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(cel.Variable("labels", cel.MapType(cel.StringType, cel.StringType)), ext.Strings())
+})
+
+// celPrograms caches the compiled LabelPolicy rules by expression, so reconciles do not recompile them
+var celPrograms sync.Map
+
+// compileRule compiles a LabelPolicy rule, which must evaluate to a bool
+func compileRule(expression string) (cel.Program, error) {
+	if program, ok := celPrograms.Load(expression); ok {
+		return program.(cel.Program), nil
+	}
+
+	env, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("must evaluate to a bool, not %s", ast.OutputType())
+	}
+	program, err := env.Program(ast, cel.CostLimit(celCostLimit))
+	if err != nil {
+		return nil, err
+	}
+	celPrograms.Store(expression, program)
+	return program, nil
+}
+
+// celRuleViolation returns a message describing the first CEL rule of the policy the labels do not satisfy,
+// or an empty string when they satisfy all of them
+func celRuleViolation(policy *danav1alpha1.LabelPolicy, labels map[string]string) string {
+	for _, rule := range policy.Spec.Rules {
+		program, err := compileRule(rule.Expression)
+		if err != nil {
+			return fmt.Sprintf("LabelPolicy '%s' has an invalid rule '%s': %v", policy.Name, rule.Expression, err)
+		}
+		out, _, err := program.Eval(map[string]any{"labels": labels})
+		if err != nil {
+			return fmt.Sprintf("LabelPolicy '%s' failed to evaluate rule '%s': %v", policy.Name, rule.Expression, err)
+		}
+		if allowed, ok := out.Value().(bool); !ok || !allowed {
+			return withReason(fmt.Sprintf("labels do not satisfy rule '%s' of LabelPolicy '%s'",
+				rule.Expression, policy.Name), rule.Reason)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,229 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// serviceAccountUsernamePrefix prefixes the user names the apiserver assigns to service accounts
+const serviceAccountUsernamePrefix = "system:serviceaccount:"
+
+// +kubebuilder:rbac:groups=dana.dana.io,resources=labelpolicies,verbs=get;list;watch
+
+// PolicySource provides the LabelPolicies that apply to a namespace. Embedders can supply their own
+// source, e.g. to serve policies from configuration instead of the cluster.
+type PolicySource interface {
+	PoliciesFor(ctx context.Context, ns *corev1.Namespace) ([]danav1alpha1.LabelPolicy, error)
+}
+
+// ClusterPolicySource provides the LabelPolicy objects whose namespaceSelector selects the namespace
+type ClusterPolicySource struct {
+	Client client.Reader
+}
+
+// PoliciesFor implements PolicySource
+func (s *ClusterPolicySource) PoliciesFor(ctx context.Context, ns *corev1.Namespace) ([]danav1alpha1.LabelPolicy, error) {
+	policies := &danav1alpha1.LabelPolicyList{}
+	if err := s.Client.List(ctx, policies); err != nil {
+		return nil, err
+	}
+
+	var matching []danav1alpha1.LabelPolicy
+	for _, policy := range policies.Items {
+		selector := labels.Everything()
+		if policy.Spec.NamespaceSelector != nil {
+			var err error
+			selector, err = metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid namespaceSelector in LabelPolicy '%s': %w", policy.Name, err)
+			}
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			matching = append(matching, policy)
+		}
+	}
+
+	return matching, nil
+}
+
+// PolicyViolation is the error returned when labels are denied by a LabelPolicy
+type PolicyViolation struct {
+	// Policy is the name of the LabelPolicy denying the labels
+	Policy  string
+	message string
+}
+
+func (v *PolicyViolation) Error() string {
+	return v.message
+}
+
+// labelViolation returns a message describing the first label that is denied by the policy,
+// or an empty string when all labels are allowed
+func labelViolation(policy *danav1alpha1.LabelPolicy, labels map[string]string) string {
+	for _, key := range sortedKeys(labels) {
+		if slices.Contains(policy.Spec.DeniedKeys, key) {
+			return fmt.Sprintf("label '%s' is denied by LabelPolicy '%s'", key, policy.Name)
+		}
+		if prefixes := policy.Spec.AllowedKeyPrefixes; len(prefixes) > 0 &&
+			!slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(key, prefix) }) {
+			return fmt.Sprintf("label '%s' is not under a key prefix allowed by LabelPolicy '%s'", key, policy.Name)
+		}
+	}
+
+	if message := deniedValueViolation(policy, labels); message != "" {
+		return message
+	}
+	return valueConstraintViolation(policy, labels)
+}
+
+// deniedValueViolation returns a message describing the first label value that is denied by the policy,
+// or an empty string when all values are allowed
+func deniedValueViolation(policy *danav1alpha1.LabelPolicy, labels map[string]string) string {
+	for _, rule := range policy.Spec.DeniedValues {
+		value, exists := labels[rule.Key]
+		if !exists {
+			continue
+		}
+		for _, denied := range rule.Values {
+			if value != denied {
+				continue
+			}
+			return withReason(
+				fmt.Sprintf("label '%s=%s' is denied by LabelPolicy '%s'", rule.Key, value, policy.Name), rule.Reason)
+		}
+	}
+	return ""
+}
+
+// valueConstraintViolation returns a message describing the first label value that does not match its
+// constraint in the policy, or an empty string when all values match
+func valueConstraintViolation(policy *danav1alpha1.LabelPolicy, labels map[string]string) string {
+	for _, constraint := range policy.Spec.ValueConstraints {
+		value, exists := labels[constraint.Key]
+		if !exists {
+			continue
+		}
+		pattern, err := regexp.Compile("^(?:" + constraint.Pattern + ")$")
+		if err != nil {
+			return fmt.Sprintf("LabelPolicy '%s' has an invalid pattern for label '%s': %v",
+				policy.Name, constraint.Key, err)
+		}
+		if !pattern.MatchString(value) {
+			return withReason(fmt.Sprintf("label '%s=%s' does not match pattern '%s' of LabelPolicy '%s'",
+				constraint.Key, value, constraint.Pattern, policy.Name), constraint.Reason)
+		}
+	}
+	return ""
+}
+
+// withReason appends the reason configured on a policy rule to a violation message
+func withReason(message, reason string) string {
+	if reason == "" {
+		return message
+	}
+	return fmt.Sprintf("%s: %s", message, reason)
+}
+
+// sortedKeys returns the keys of labels in order, so the reported violation is stable
+func sortedKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// principalViolation returns a message when the requesting principal is not in the policy's allowlist,
+// or an empty string when the policy has no allowlist or the principal is allowed
+func principalViolation(policy *danav1alpha1.LabelPolicy, userInfo authenticationv1.UserInfo) string {
+	allowed := policy.Spec.AllowedPrincipals
+	if allowed == nil {
+		return ""
+	}
+
+	if MatchesPrincipal(allowed, userInfo) {
+		return ""
+	}
+
+	return fmt.Sprintf("user '%s' is not allowed to change NamespaceLabels in this namespace by LabelPolicy '%s'",
+		userInfo.Username, policy.Name)
+}
+
+// MatchesPrincipal reports whether a principal is one of the users, in one of the groups or one of the service
+// accounts of a list; a nil list matches no one
+func MatchesPrincipal(principals *danav1alpha1.PrincipalList, userInfo authenticationv1.UserInfo) bool {
+	if principals == nil {
+		return false
+	}
+	if slices.Contains(principals.Users, userInfo.Username) {
+		return true
+	}
+	for _, group := range userInfo.Groups {
+		if slices.Contains(principals.Groups, group) {
+			return true
+		}
+	}
+	serviceAccount, ok := serviceAccountName(userInfo.Username)
+	return ok && slices.Contains(principals.ServiceAccounts, serviceAccount)
+}
+
+// serviceAccountName converts a service account user name ("system:serviceaccount:ns:name")
+// into "ns/name", reporting false for users that are not service accounts
+func serviceAccountName(username string) (string, bool) {
+	if !strings.HasPrefix(username, serviceAccountUsernamePrefix) {
+		return "", false
+	}
+	namespace, name, found := strings.Cut(strings.TrimPrefix(username, serviceAccountUsernamePrefix), ":")
+	if !found || namespace == "" || name == "" {
+		return "", false
+	}
+	return namespace + "/" + name, true
+}
+
+// ValidatePolicies checks labels against the key and value rules of the LabelPolicies selecting a labeled
+// namespace, returning a *PolicyViolation for the first label denied
+func ValidatePolicies(policies []danav1alpha1.LabelPolicy, labels map[string]string) error {
+	for i := range policies {
+		if violation := labelViolation(&policies[i], labels); violation != "" {
+			return &PolicyViolation{Policy: policies[i].Name, message: violation}
+		}
+	}
+	return nil
+}
+
+// ValidateRules checks the complete set of labels of a NamespaceLabel against the CEL rules of the LabelPolicies
+// selecting a labeled namespace, returning a *PolicyViolation for the first rule not satisfied. Rules may span
+// several keys, so unlike the key and value rules they are never evaluated against the changed labels alone.
+func ValidateRules(policies []danav1alpha1.LabelPolicy, labels map[string]string) error {
+	for i := range policies {
+		if violation := celRuleViolation(&policies[i], labels); violation != "" {
+			return &PolicyViolation{Policy: policies[i].Name, message: violation}
+		}
+	}
+	return nil
+}
+
+// ValidatePrincipal checks the requesting principal against the allowlists of the LabelPolicies selecting a
+// labeled namespace. Only the webhook knows the principal, so the controller cannot re-run this rule.
+func ValidatePrincipal(policies []danav1alpha1.LabelPolicy, userInfo authenticationv1.UserInfo) error {
+	for i := range policies {
+		if violation := principalViolation(&policies[i], userInfo); violation != "" {
+			return errors.New(violation)
+		}
+	}
+	return nil
+}
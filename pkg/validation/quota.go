@@ -0,0 +1,57 @@
+package validation
+
+import (
+	"fmt"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+)
+
+// LabelQuota caps the labels managed through NamespaceLabels; zero limits are unlimited
+type LabelQuota struct {
+	// MaxLabels caps how many labels a NamespaceLabel may declare
+	MaxLabels int
+	// MaxManagedLabels caps how many labels may be managed on a namespace in total
+	MaxManagedLabels int
+}
+
+// QuotaExceeded is the error returned when labels exceed a LabelQuota
+type QuotaExceeded struct {
+	message string
+}
+
+func (e *QuotaExceeded) Error() string {
+	return e.message
+}
+
+// EffectiveQuota returns the lowest limits of the configured quota and the LabelPolicies selecting a namespace
+func EffectiveQuota(quota LabelQuota, policies []danav1alpha1.LabelPolicy) LabelQuota {
+	for _, policy := range policies {
+		quota.MaxLabels = lowerLimit(quota.MaxLabels, policy.Spec.MaxLabels)
+		quota.MaxManagedLabels = lowerLimit(quota.MaxManagedLabels, policy.Spec.MaxManagedLabels)
+	}
+	return quota
+}
+
+// lowerLimit returns the lower of two limits, where zero and nil are unlimited
+func lowerLimit(limit int, policyLimit *int32) int {
+	if policyLimit == nil || *policyLimit <= 0 || (limit > 0 && limit <= int(*policyLimit)) {
+		return limit
+	}
+	return int(*policyLimit)
+}
+
+// Validate checks the number of labels a NamespaceLabel declares, and the number of labels managed on a
+// namespace once they are applied, returning a *QuotaExceeded when either is over its limit
+func (q LabelQuota) Validate(namespace string, declared, managed int) error {
+	if q.MaxLabels > 0 && declared > q.MaxLabels {
+		return &QuotaExceeded{message: fmt.Sprintf(
+			"NamespaceLabel declares %d labels, exceeding the quota of %d labels per NamespaceLabel",
+			declared, q.MaxLabels)}
+	}
+	if q.MaxManagedLabels > 0 && managed > q.MaxManagedLabels {
+		return &QuotaExceeded{message: fmt.Sprintf(
+			"namespace '%s' would carry %d managed labels, exceeding the quota of %d managed labels per namespace",
+			namespace, managed, q.MaxManagedLabels)}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Package validation holds the NamespaceLabel rules enforced by the admission webhook. The controller runs
+// the same rules before applying a spec, so they still hold when the webhook is disabled or unavailable.
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// MinResyncInterval is the shortest resync interval a NamespaceLabel may request
+const MinResyncInterval = 30 * time.Second
+
+// ValidateLabelSyntax returns an error for every label key and value that is not valid Kubernetes label syntax,
+// so all of them are reported at once instead of the API server rejecting the Namespace write later
+func ValidateLabelSyntax(labels map[string]string, path *field.Path) field.ErrorList {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs field.ErrorList
+	for _, key := range keys {
+		for _, message := range k8svalidation.IsQualifiedName(key) {
+			errs = append(errs, field.Invalid(path, key, message))
+		}
+		// Templated values are checked once rendered against the labeled Namespace
+		if namespacelabel.IsTemplate(labels[key]) {
+			if _, err := namespacelabel.ParseTemplate(labels[key], time.Now()); err != nil {
+				errs = append(errs, field.Invalid(path.Key(key), labels[key], err.Error()))
+			}
+			continue
+		}
+		for _, message := range k8svalidation.IsValidLabelValue(labels[key]) {
+			errs = append(errs, field.Invalid(path.Key(key), labels[key], message))
+		}
+	}
+	return errs
+}
+
+// ValidateLabels ensures every label is valid label syntax and none is protected
+func ValidateLabels(protected *namespacelabel.ProtectedLabels, labels map[string]string) error {
+	if errs := ValidateLabelSyntax(labels, field.NewPath("spec", "labels")); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	for key := range labels {
+		if protected.IsProtected(key) {
+			return fmt.Errorf("cannot add protected or management label '%s'", key)
+		}
+	}
+	return nil
+}
+
+// defaultProtectedAnnotations protects the annotations when no protected annotations are configured
+var defaultProtectedAnnotations, _ = namespacelabel.NewProtectedLabels(
+	namespacelabel.DefaultProtectedAnnotationPrefixes, nil)
+
+// ValidateAnnotations ensures every annotation key is valid syntax and none is protected or written by the
+// controller itself. A nil protected protects namespacelabel.DefaultProtectedAnnotationPrefixes.
+func ValidateAnnotations(protected *namespacelabel.ProtectedLabels, annotations map[string]string) error {
+	if errs := apivalidation.ValidateAnnotations(annotations, field.NewPath("spec", "annotations")); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	if protected == nil {
+		protected = defaultProtectedAnnotations
+	}
+	for key := range annotations {
+		if strings.HasPrefix(key, namespacelabel.ControllerAnnotationPrefix) || protected.IsProtected(key) {
+			return fmt.Errorf("cannot add protected annotation '%s'", key)
+		}
+	}
+	return nil
+}
+
+// ValidateSpec checks the rules that depend only on the NamespaceLabel and the instance's configuration
+func ValidateSpec(namespaceLabel *danav1alpha1.NamespaceLabel, adminNamespaces []string,
+	protected, protectedAnnotations *namespacelabel.ProtectedLabels) error {
+	// Label rules are validated along with the labels, and may not redeclare them
+	declared := namespaceLabel.Spec.Labels
+	if len(namespaceLabel.Spec.LabelRules) > 0 {
+		declared = make(map[string]string, len(namespaceLabel.Spec.Labels)+len(namespaceLabel.Spec.LabelRules))
+		for key, value := range namespaceLabel.Spec.Labels {
+			declared[key] = value
+		}
+		for _, rule := range namespaceLabel.Spec.LabelRules {
+			if _, exists := declared[rule.Key]; exists {
+				return fmt.Errorf("label '%s' is declared in both labels and labelRules", rule.Key)
+			}
+			if rule.ExpiresAfter != nil && rule.ExpiresAt != nil {
+				return fmt.Errorf("labelRules '%s' may set only one of expiresAfter and expiresAt", rule.Key)
+			}
+			if rule.Schedule != nil {
+				if rule.ExpiresAfter != nil || rule.ExpiresAt != nil {
+					return fmt.Errorf("labelRules '%s' may not set a schedule along with expiresAfter or expiresAt",
+						rule.Key)
+				}
+				if _, _, err := namespacelabel.LabelWindowAt(*rule.Schedule, time.Now()); err != nil {
+					return fmt.Errorf("labelRules '%s' has an invalid schedule: %w", rule.Key, err)
+				}
+			}
+			declared[rule.Key] = rule.Value
+		}
+	}
+	if err := ValidateLabels(protected, declared); err != nil {
+		return err
+	}
+
+	if err := ValidateAnnotations(protectedAnnotations, namespaceLabel.Spec.Annotations); err != nil {
+		return err
+	}
+
+	// A label is either enforced or left alone once applied
+	for _, key := range namespaceLabel.Spec.EnforceKeys {
+		if slices.Contains(namespaceLabel.Spec.IgnoreDriftKeys, key) {
+			return fmt.Errorf("label '%s' is listed in both ignoreDriftKeys and enforceKeys", key)
+		}
+	}
+
+	// A NamespaceLabel cannot wait for itself
+	for _, dependency := range namespaceLabel.Spec.DependsOn {
+		if dependency.Name == namespaceLabel.Name &&
+			(dependency.Namespace == "" || dependency.Namespace == namespaceLabel.Namespace) {
+			return fmt.Errorf("dependsOn may not reference the NamespaceLabel itself")
+		}
+	}
+
+	// Ensure the resync interval does not hammer the API server
+	if interval := namespaceLabel.Spec.ResyncInterval; interval != nil && interval.Duration < MinResyncInterval {
+		return fmt.Errorf("resyncInterval must be at least %s", MinResyncInterval)
+	}
+
+	// Ensure every labels source references exactly one object
+	for i, source := range namespaceLabel.Spec.LabelsFrom {
+		if (source.ConfigMapRef == nil) == (source.SecretRef == nil) {
+			return fmt.Errorf("labelsFrom[%d] must set exactly one of configMapRef and secretRef", i)
+		}
+	}
+
+	// Ensure only admin namespaces fan labels out to other Namespaces
+	if len(namespaceLabel.Spec.TargetNamespaces) > 0 && !slices.Contains(adminNamespaces, namespaceLabel.Namespace) {
+		return fmt.Errorf("targetNamespaces may only be set on NamespaceLabels in admin namespaces")
+	}
+
+	// Ensure member clusters are only reached through Secrets the NamespaceLabel's namespace holds
+	if len(namespaceLabel.Spec.Clusters) > 0 && len(namespaceLabel.Spec.TargetNamespaces) > 0 {
+		return fmt.Errorf("clusters is not supported with targetNamespaces")
+	}
+	if err := ValidateMemberClusters(namespaceLabel.Spec.Clusters, namespaceLabel.Namespace); err != nil {
+		return err
+	}
+
+	// Ensure labels are only propagated to workloads of the NamespaceLabel's own Namespace
+	if propagate := namespaceLabel.Spec.Propagate; propagate != nil {
+		if len(namespaceLabel.Spec.TargetNamespaces) > 0 {
+			return fmt.Errorf("propagate is not supported with targetNamespaces")
+		}
+		if _, err := metav1.LabelSelectorAsSelector(propagate.Selector); err != nil {
+			return fmt.Errorf("invalid propagate selector: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateMemberClusters ensures every member cluster references a kubeconfig Secret it may read and has a
+// valid namespace selector. namespace is the namespace of a NamespaceLabel, which may only reference its own
+// Secrets; it is empty for ClusterNamespaceLabels, which must name the Secret's namespace.
+func ValidateMemberClusters(clusters []danav1alpha1.MemberCluster, namespace string) error {
+	for _, cluster := range clusters {
+		ref := cluster.KubeconfigSecretRef
+		switch {
+		case namespace == "" && ref.Namespace == "":
+			return fmt.Errorf("clusters '%s' must set kubeconfigSecretRef.namespace", cluster.Name)
+		case namespace != "" && ref.Namespace != "" && ref.Namespace != namespace:
+			return fmt.Errorf("clusters '%s' may only reference Secrets in namespace '%s'", cluster.Name, namespace)
+		}
+		if _, err := metav1.LabelSelectorAsSelector(cluster.NamespaceSelector); err != nil {
+			return fmt.Errorf("invalid namespaceSelector of clusters '%s': %w", cluster.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+const (
+	breakGlassAnnotation        = namespacelabel.BreakGlassAnnotation
+	breakGlassExpiresAnnotation = namespacelabel.BreakGlassExpiresAnnotation
+	// breakGlassMaxTTL bounds how far in the future a break-glass annotation may expire
+	breakGlassMaxTTL = time.Hour
+)
+
+// auditLog records privileged admission decisions
+var auditLog = ctrl.Log.WithName("audit")
+
+// breakGlass allows a denied request when it carries a valid break-glass annotation set by an admin,
+// and returns the original denial otherwise. The annotation is honored only by the request that sets it,
+// so every bypass is a deliberate, audited action; the defaulting webhook marks the spec so the controller
+// applies it until the annotation expires.
+func (v *NamespaceLabelValidator) breakGlass(ctx context.Context, req admission.Request,
+	namespaceLabel *danav1alpha1.NamespaceLabel, denial admission.Response) admission.Response {
+	log := log.FromContext(ctx)
+
+	message, requested := namespaceLabel.Annotations[breakGlassAnnotation]
+	if !requested {
+		return denial
+	}
+
+	var oldNamespaceLabel *danav1alpha1.NamespaceLabel
+	if len(req.OldObject.Raw) > 0 {
+		oldNamespaceLabel = &danav1alpha1.NamespaceLabel{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldNamespaceLabel); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+	if reason := breakGlassRejection(v.BreakGlassGroups, req, namespaceLabel, oldNamespaceLabel); reason != "" {
+		log.Info("Ignoring break-glass annotation", "Reason", reason)
+		return admission.Denied(fmt.Sprintf("%s (break-glass ignored: %s)", denial.Result.Message, reason))
+	}
+
+	auditLog.Info("break-glass bypass used",
+		"user", req.UserInfo.Username,
+		"groups", req.UserInfo.Groups,
+		"operation", req.Operation,
+		"namespace", req.Namespace,
+		"name", req.Name,
+		"message", message,
+		"denial", denial.Result.Message)
+	if v.Recorder != nil {
+		v.Recorder.Eventf(namespaceLabel, corev1.EventTypeWarning, "BreakGlass",
+			"%s bypassed denial %q: %s", req.UserInfo.Username, denial.Result.Message, message)
+	}
+
+	return admission.Allowed("break-glass").WithWarnings(
+		fmt.Sprintf("break-glass bypass of denial: %s", denial.Result.Message))
+}
+
+// breakGlassRejection explains why the break-glass annotation of a request cannot be honored for members of
+// the break-glass groups, or returns "" if it can. oldNamespaceLabel is nil on CREATE.
+func breakGlassRejection(groups []string, req admission.Request,
+	namespaceLabel, oldNamespaceLabel *danav1alpha1.NamespaceLabel) string {
+	if namespaceLabel.Annotations[breakGlassAnnotation] == "" {
+		return "a justification message is required"
+	}
+
+	isAdmin := false
+	for _, group := range req.UserInfo.Groups {
+		if slices.Contains(groups, group) {
+			isAdmin = true
+			break
+		}
+	}
+	if !isAdmin {
+		return fmt.Sprintf("user '%s' is not a break-glass administrator", req.UserInfo.Username)
+	}
+
+	expires, err := time.Parse(time.RFC3339, namespaceLabel.Annotations[breakGlassExpiresAnnotation])
+	if err != nil {
+		return fmt.Sprintf("annotation '%s' must be an RFC3339 time", breakGlassExpiresAnnotation)
+	}
+	now := time.Now()
+	if !expires.After(now) {
+		return "the break-glass annotation has expired"
+	}
+	if expires.Sub(now) > breakGlassMaxTTL {
+		return fmt.Sprintf("the break-glass annotation may not be valid for more than %s", breakGlassMaxTTL)
+	}
+
+	// Only the request that sets the annotation may use it
+	if oldNamespaceLabel != nil && !breakGlassChanged(oldNamespaceLabel, namespaceLabel) {
+		return "the break-glass annotation has already been used"
+	}
+
+	return ""
+}
+
+// breakGlassChanged reports whether the break-glass annotations differ between two versions of a NamespaceLabel
+func breakGlassChanged(oldNamespaceLabel, namespaceLabel *danav1alpha1.NamespaceLabel) bool {
+	return oldNamespaceLabel.Annotations[breakGlassAnnotation] != namespaceLabel.Annotations[breakGlassAnnotation] ||
+		oldNamespaceLabel.Annotations[breakGlassExpiresAnnotation] != namespaceLabel.Annotations[breakGlassExpiresAnnotation]
+}
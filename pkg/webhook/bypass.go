@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
+)
+
+// bypass admits a denied request from a trusted principal with the denial as a warning, and returns the
+// response unchanged otherwise. Errors and malformed requests are never bypassed.
+func (v *NamespaceLabelValidator) bypass(req admission.Request, response admission.Response) admission.Response {
+	if response.Allowed || response.Result == nil || response.Result.Code != http.StatusForbidden ||
+		!validation.MatchesPrincipal(v.Bypass, req.UserInfo) {
+		return response
+	}
+
+	auditLog.Info("trusted principal bypass used",
+		"user", req.UserInfo.Username,
+		"groups", req.UserInfo.Groups,
+		"operation", req.Operation,
+		"namespace", req.Namespace,
+		"name", req.Name,
+		"denial", response.Result.Message)
+
+	return admission.Allowed("trusted principal").WithWarnings(
+		fmt.Sprintf("trusted principal '%s' bypassed denial: %s", req.UserInfo.Username, response.Result.Message))
+}
+
+// stampBypass sets namespacelabel.AdmissionBypassAnnotation to the reason the admission rules are waived for
+// the spec of a request, or removes it. Changes that leave the spec and the break-glass annotations alone, such
+// as the controller adding its finalizer, keep the previous marker; so do the controller's own changes, which
+// only drop labels.
+func (d *NamespaceLabelDefaulter) stampBypass(
+	req admission.Request, namespaceLabel *danav1alpha1.NamespaceLabel) error {
+	var oldNamespaceLabel *danav1alpha1.NamespaceLabel
+	if len(req.OldObject.Raw) > 0 {
+		oldNamespaceLabel = &danav1alpha1.NamespaceLabel{}
+		if err := d.decoder.DecodeRaw(req.OldObject, oldNamespaceLabel); err != nil {
+			return err
+		}
+	}
+
+	marker := ""
+	switch {
+	case validation.MatchesPrincipal(d.Bypass, req.UserInfo):
+		marker = namespacelabel.BypassTrustedPrincipal
+	case namespaceLabel.Annotations[breakGlassAnnotation] != "" &&
+		breakGlassRejection(d.BreakGlassGroups, req, namespaceLabel, oldNamespaceLabel) == "":
+		marker = namespacelabel.BypassBreakGlass
+	case oldNamespaceLabel == nil:
+	case d.ControllerUsername != "" && req.UserInfo.Username == d.ControllerUsername,
+		equality.Semantic.DeepEqual(oldNamespaceLabel.Spec, namespaceLabel.Spec) &&
+			!breakGlassChanged(oldNamespaceLabel, namespaceLabel):
+		marker = oldNamespaceLabel.Annotations[namespacelabel.AdmissionBypassAnnotation]
+	}
+
+	if marker == "" {
+		delete(namespaceLabel.Annotations, namespacelabel.AdmissionBypassAnnotation)
+		return nil
+	}
+	if namespaceLabel.Annotations == nil {
+		namespaceLabel.Annotations = make(map[string]string)
+	}
+	namespaceLabel.Annotations[namespacelabel.AdmissionBypassAnnotation] = marker
+	return nil
+}
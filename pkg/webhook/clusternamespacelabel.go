@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
+)
+
+// ClusterNamespaceLabelValidator admits ClusterNamespaceLabels whose labels, rendered for each Namespace they
+// select, satisfy the protection rules, the LabelPolicies and the managed label quota of that Namespace.
+// Namespaces created for the ClusterNamespaceLabel do not exist yet and are checked by the controller once created.
+type ClusterNamespaceLabelValidator struct {
+	Client client.Client
+	// PolicySource provides the LabelPolicies that apply to a namespace
+	PolicySource validation.PolicySource
+	// Quota caps the labels managed on a namespace, on top of the LabelPolicy limits; MaxLabels limits single
+	// NamespaceLabels and does not apply
+	Quota validation.LabelQuota
+	// MapNamespace maps a NamespaceLabel's namespace to the Namespace it labels, for counting the labels it
+	// manages; nil maps it to itself
+	MapNamespace namespacelabel.NamespaceMapper
+	// ProtectedLabels are the label keys ClusterNamespaceLabels may not set; nil protects the Kubernetes
+	// management labels
+	ProtectedLabels *namespacelabel.ProtectedLabels
+	// ProtectedNamespaces are the Namespaces never labeled; nil protects namespacelabel.DefaultProtectedNamespaces
+	ProtectedNamespaces *namespacelabel.ProtectedNamespaces
+	decoder             admission.Decoder
+}
+
+func (v *ClusterNamespaceLabelValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := log.FromContext(ctx)
+	clusterNamespaceLabel := &danav1alpha1.ClusterNamespaceLabel{}
+
+	if err := v.decoder.Decode(req, clusterNamespaceLabel); err != nil {
+		log.Error(err, "Error decoding request: %v\n")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if err := validation.ValidateLabels(v.ProtectedLabels, clusterNamespaceLabel.Spec.Labels); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := validation.ValidateMemberClusters(clusterNamespaceLabel.Spec.Clusters, ""); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	// Like for NamespaceLabels, only the added and changed labels are checked against the key and value rules of
+	// LabelPolicies on UPDATE; CEL rules always see every label
+	changedKeys := clusterNamespaceLabel.Spec.Labels
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		oldClusterNamespaceLabel := &danav1alpha1.ClusterNamespaceLabel{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldClusterNamespaceLabel); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		changedKeys = labelDiff(oldClusterNamespaceLabel.Spec.Labels, clusterNamespaceLabel.Spec.Labels)
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := v.Client.List(ctx, namespaces); err != nil {
+		log.Error(err, "Error listing namespaces: %v\n")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	now := time.Now()
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if !ns.DeletionTimestamp.IsZero() || v.ProtectedNamespaces.IsProtected(ns) {
+			continue
+		}
+		selected, err := namespacelabel.SelectsNamespace(clusterNamespaceLabel, ns)
+		if err != nil {
+			return admission.Denied(err.Error())
+		}
+		if !selected {
+			continue
+		}
+
+		// Templated values are rendered and checked for each Namespace
+		rendered, err := namespacelabel.RenderLabels(clusterNamespaceLabel.Spec.Labels, ns, now)
+		if err == nil {
+			err = validation.ValidateLabels(v.ProtectedLabels, rendered)
+		}
+		if err != nil {
+			return admission.Denied(fmt.Sprintf("namespace '%s': %v", ns.Name, err))
+		}
+		changedLabels := make(map[string]string, len(changedKeys))
+		for key := range changedKeys {
+			changedLabels[key] = rendered[key]
+		}
+
+		policies, err := v.PolicySource.PoliciesFor(ctx, ns)
+		if err != nil {
+			log.Error(err, "Error listing label policies: %v\n")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if err := validation.ValidatePrincipal(policies, req.UserInfo); err != nil {
+			return admission.Denied(fmt.Sprintf("namespace '%s': %v", ns.Name, err))
+		}
+		if err := validation.ValidatePolicies(policies, changedLabels); err != nil {
+			return admission.Denied(fmt.Sprintf("namespace '%s': %v", ns.Name, err))
+		}
+		if err := validation.ValidateRules(policies, rendered); err != nil {
+			return admission.Denied(fmt.Sprintf("namespace '%s': %v", ns.Name, err))
+		}
+		if err := v.validateQuota(ctx, clusterNamespaceLabel, ns, policies, rendered); err != nil {
+			var exceeded *validation.QuotaExceeded
+			if errors.As(err, &exceeded) {
+				return admission.Denied(err.Error())
+			}
+			log.Error(err, "Error counting managed labels: %v\n")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// validateQuota checks the labels of a ClusterNamespaceLabel, rendered for a selected namespace, against the
+// quota of labels managed on it
+func (v *ClusterNamespaceLabelValidator) validateQuota(ctx context.Context,
+	clusterNamespaceLabel *danav1alpha1.ClusterNamespaceLabel, ns *corev1.Namespace,
+	policies []danav1alpha1.LabelPolicy, rendered map[string]string) error {
+	quota := validation.EffectiveQuota(v.Quota, policies)
+	if quota.MaxManagedLabels == 0 {
+		return nil
+	}
+
+	managed, err := namespacelabel.ManagedLabelKeys(ctx, v.Client, v.MapNamespace, ns, clusterNamespaceLabel)
+	if err != nil {
+		return err
+	}
+	for key := range rendered {
+		managed[key] = struct{}{}
+	}
+	return quota.Validate(ns.Name, 0, len(managed))
+}
+
+func (v *ClusterNamespaceLabelValidator) InjectDecoder(d admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
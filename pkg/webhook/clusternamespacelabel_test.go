@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
+)
+
+var _ = Describe("ClusterNamespaceLabel Webhook", func() {
+	var validator *ClusterNamespaceLabelValidator
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		policy := &danav1alpha1.LabelPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-prod-backups"},
+			Spec: danav1alpha1.LabelPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "prod"}},
+				DeniedValues:      []danav1alpha1.DeniedValueRule{{Key: "backup", Values: []string{"never"}}},
+			},
+		}
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev", Labels: map[string]string{"environment": "dev"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"environment": "prod"}}},
+		).Build()
+		validator = &ClusterNamespaceLabelValidator{
+			Client:       k8sClient,
+			PolicySource: &validation.ClusterPolicySource{Client: k8sClient},
+			decoder:      admission.NewDecoder(scheme),
+		}
+	})
+
+	newRequest := func(operation admissionv1.Operation, names []string, labels map[string]string,
+		oldLabels map[string]string) admission.Request {
+		encode := func(labels map[string]string) runtime.RawExtension {
+			raw, err := json.Marshal(&danav1alpha1.ClusterNamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+				Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+					Labels:            labels,
+					NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: names},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return runtime.RawExtension{Raw: raw}
+		}
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation, Name: "platform", Object: encode(labels),
+		}}
+		if oldLabels != nil {
+			req.OldObject = encode(oldLabels)
+		}
+		return req
+	}
+
+	It("should deny labels a LabelPolicy of a selected Namespace denies", func() {
+		resp := validator.Handle(ctx, newRequest(admissionv1.Create, []string{"dev", "prod"},
+			map[string]string{"backup": "never"}, nil))
+		Expect(resp.Allowed).To(BeFalse())
+		Expect(resp.Result.Message).To(ContainSubstring("namespace 'prod'"))
+		Expect(resp.Result.Message).To(ContainSubstring("denied by LabelPolicy 'no-prod-backups'"))
+	})
+
+	It("should admit labels denied only for Namespaces it does not select", func() {
+		resp := validator.Handle(ctx, newRequest(admissionv1.Create, []string{"dev"},
+			map[string]string{"backup": "never"}, nil))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	It("should only check the added and changed labels against value rules on update", func() {
+		resp := validator.Handle(ctx, newRequest(admissionv1.Update, []string{"prod"},
+			map[string]string{"backup": "never", "team": "platform"}, map[string]string{"backup": "never"}))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	It("should deny labels exceeding the managed label quota of a selected Namespace", func() {
+		Expect(k8sClient.Create(ctx, &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "dev"},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"owner": "a"}},
+		})).To(Succeed())
+		validator.Quota = validation.LabelQuota{MaxManagedLabels: 2}
+
+		resp := validator.Handle(ctx, newRequest(admissionv1.Create, []string{"dev"},
+			map[string]string{"backup": "daily", "team": "platform"}, nil))
+		Expect(resp.Allowed).To(BeFalse())
+		Expect(resp.Result.Message).To(ContainSubstring("exceeding the quota of 2 managed labels"))
+
+		By("not counting the labels the ClusterNamespaceLabel replaces")
+		Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+			Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+				Labels:            map[string]string{"backup": "daily"},
+				NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: []string{"dev"}},
+			},
+		})).To(Succeed())
+		resp = validator.Handle(ctx, newRequest(admissionv1.Update, []string{"dev"},
+			map[string]string{"team": "platform"}, map[string]string{"backup": "daily"}))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	It("should deny protected labels", func() {
+		resp := validator.Handle(ctx, newRequest(admissionv1.Create, []string{"dev"},
+			map[string]string{"kubernetes.io/metadata.name": "dev"}, nil))
+		Expect(resp.Allowed).To(BeFalse())
+	})
+})
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	danav1beta1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1beta1"
+)
+
+var _ = Describe("NamespaceLabel Conversion", func() {
+	BeforeEach(func() {
+		initTestEnvironment()
+	})
+
+	It("should serve every NamespaceLabel version through the v1alpha1 hub", func() {
+		Expect(conversion.IsConvertible(scheme, &danav1beta1.NamespaceLabel{})).To(BeTrue())
+	})
+
+	It("should round-trip label entries through v1alpha1", func() {
+		enforce, enforced := false, true
+		namespaceLabel := &danav1beta1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "entries", Namespace: "tenant"},
+			Spec: danav1beta1.NamespaceLabelSpec{
+				Labels: []danav1beta1.LabelEntry{
+					{Key: "cost-center", Value: "1234", Enforce: &enforce},
+					{Key: "freeze", Value: "true", ExpireAfter: &metav1.Duration{Duration: 72 * time.Hour}},
+					{Key: "maintenance", Value: "true", Schedule: &danav1beta1.LabelSchedule{
+						Apply: "0 22 * * 6", Remove: "0 6 * * 0", TimeZone: "Europe/Berlin",
+					}},
+					{Key: "owner", Value: "alice"},
+					{Key: "team", Value: "platform", Enforce: &enforced},
+				},
+				Propagate: &danav1beta1.PropagateSpec{Kinds: []danav1beta1.PropagateKind{danav1beta1.PropagateDeployment}},
+			},
+		}
+
+		hub := &danav1alpha1.NamespaceLabel{}
+		Expect(namespaceLabel.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Labels).To(Equal(map[string]string{"cost-center": "1234", "owner": "alice", "team": "platform"}))
+		Expect(hub.Spec.LabelRules).To(Equal([]danav1alpha1.LabelRule{
+			{Key: "freeze", Value: "true", ExpiresAfter: &metav1.Duration{Duration: 72 * time.Hour}},
+			{Key: "maintenance", Value: "true", Schedule: &danav1alpha1.LabelSchedule{
+				Apply: "0 22 * * 6", Remove: "0 6 * * 0", TimeZone: "Europe/Berlin",
+			}},
+		}))
+		Expect(hub.Spec.IgnoreDriftKeys).To(Equal([]string{"cost-center"}))
+		Expect(hub.Spec.EnforceKeys).To(Equal([]string{"team"}))
+		Expect(hub.Spec.Propagate.Kinds).To(Equal([]danav1alpha1.PropagateKind{danav1alpha1.PropagateDeployment}))
+
+		converted := &danav1beta1.NamespaceLabel{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted).To(Equal(namespaceLabel))
+	})
+
+	It("should round-trip drift keys naming no label entry through v1beta1", func() {
+		hub := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "sourced", Namespace: "tenant", Annotations: map[string]string{"owner": "alice"},
+			},
+			Spec: danav1alpha1.NamespaceLabelSpec{
+				Labels: map[string]string{"team": "platform"},
+				LabelsFrom: []danav1alpha1.LabelsFromSource{
+					{ConfigMapRef: &danav1alpha1.LabelsSourceReference{Name: "labels"}},
+				},
+				EnforceKeys:     []string{"team", "cost-center"},
+				IgnoreDriftKeys: []string{"region"},
+			},
+		}
+		original := hub.DeepCopy()
+
+		spoke := &danav1beta1.NamespaceLabel{}
+		Expect(spoke.ConvertFrom(hub)).To(Succeed())
+		Expect(spoke.Annotations).To(HaveKeyWithValue(danav1beta1.DriftKeysAnnotation,
+			`{"enforceKeys":["cost-center"],"ignoreDriftKeys":["region"]}`))
+		Expect(hub).To(Equal(original))
+
+		converted := &danav1alpha1.NamespaceLabel{}
+		Expect(spoke.ConvertTo(converted)).To(Succeed())
+		Expect(converted).To(Equal(original))
+	})
+})
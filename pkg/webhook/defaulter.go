@@ -0,0 +1,186 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// NamespaceLabelDefaulter fills in default labels for NamespaceLabels from the manager configuration, the
+// requesting user's groups and their Namespace's metadata, and optionally normalizes label keys to lowercase.
+// It also records the requesting user in namespacelabel.ModifiedByAnnotation, and in
+// namespacelabel.AdmissionBypassAnnotation whether the admission rules are waived for the spec.
+type NamespaceLabelDefaulter struct {
+	Client client.Client
+	// Labels are default labels set on every NamespaceLabel
+	Labels map[string]string
+	// GroupLabels maps user group prefixes to the label keys the rest of the group name provides defaults for
+	GroupLabels map[string]string
+	// AnnotationLabels maps Namespace annotation keys to the label keys they provide defaults for
+	AnnotationLabels map[string]string
+	// LowercaseKeys rewrites label keys to lowercase before defaults are applied
+	LowercaseKeys bool
+	// WatchSelector restricts defaulting to NamespaceLabels whose labels match it; nil matches all
+	WatchSelector labels.Selector
+	// MapNamespace maps a NamespaceLabel's namespace to the Namespace it labels; nil maps it to itself
+	MapNamespace namespacelabel.NamespaceMapper
+	// Bypass are the trusted principals whose NamespaceLabels the controller applies without re-checking the
+	// admission rules
+	Bypass *danav1alpha1.PrincipalList
+	// BreakGlassGroups are the groups whose break-glass annotations waive the admission rules until they expire
+	BreakGlassGroups []string
+	// ControllerUsername is the user the controller writes NamespaceLabels as, whose changes keep the marker
+	ControllerUsername string
+	decoder            admission.Decoder
+}
+
+func (d *NamespaceLabelDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := log.FromContext(ctx)
+	namespaceLabel := &danav1alpha1.NamespaceLabel{}
+
+	if err := d.decoder.Decode(req, namespaceLabel); err != nil {
+		log.Error(err, "Error decoding request: %v\n")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !namespacelabel.IsWatched(d.WatchSelector, namespaceLabel) {
+		return admission.Allowed("")
+	}
+
+	// Record who made the change for the audit records of the labels applied for it
+	if username := req.UserInfo.Username; username != "" {
+		if namespaceLabel.Annotations == nil {
+			namespaceLabel.Annotations = make(map[string]string)
+		}
+		namespaceLabel.Annotations[namespacelabel.ModifiedByAnnotation] = username
+	}
+
+	if d.LowercaseKeys {
+		namespaceLabel.Spec.Labels = lowercaseKeys(namespaceLabel.Spec.Labels)
+	}
+
+	// Namespace annotations take precedence over the user's groups, which take precedence over the configuration
+	defaults := make(map[string]string)
+	for key, value := range d.Labels {
+		defaults[key] = value
+	}
+	for key, value := range d.groupLabels(ctx, req.UserInfo.Groups) {
+		defaults[key] = value
+	}
+
+	// Fan-out NamespaceLabels label several Namespaces, so there is no single source of annotations
+	if len(namespaceLabel.Spec.TargetNamespaces) == 0 && len(d.AnnotationLabels) > 0 {
+		annotationLabels, err := d.annotationLabels(ctx, req.Namespace)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		for key, value := range annotationLabels {
+			defaults[key] = value
+		}
+	}
+
+	// Only fill in labels the tenant did not set
+	for key, value := range defaults {
+		if _, set := namespaceLabel.Spec.Labels[key]; set {
+			continue
+		}
+		if namespaceLabel.Spec.Labels == nil {
+			namespaceLabel.Spec.Labels = make(map[string]string)
+		}
+		namespaceLabel.Spec.Labels[key] = value
+	}
+
+	if err := d.stampBypass(req, namespaceLabel); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	marshaled, err := json.Marshal(namespaceLabel)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// annotationLabels returns the default labels copied from the ownership annotations of the labeled Namespace
+func (d *NamespaceLabelDefaulter) annotationLabels(ctx context.Context, namespace string) (map[string]string, error) {
+	log := log.FromContext(ctx)
+
+	ns := &corev1.Namespace{}
+	target, err := namespacelabel.TargetNamespace(d.MapNamespace, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Client.Get(ctx, types.NamespacedName{Name: target}, ns); err != nil {
+		log.Error(err, "Error fetching namespace: %v\n")
+		return nil, err
+	}
+
+	defaults := make(map[string]string)
+	for annotation, label := range d.AnnotationLabels {
+		value, exists := ns.Annotations[annotation]
+		if !exists || value == "" {
+			continue
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			log.Info("Skipping default label with invalid value", "Annotation", annotation, "Errors", errs)
+			continue
+		}
+		defaults[label] = value
+	}
+	return defaults, nil
+}
+
+// groupLabels returns the default labels provided by the user's groups; the first group with a prefix provides
+// its label
+func (d *NamespaceLabelDefaulter) groupLabels(ctx context.Context, groups []string) map[string]string {
+	defaults := make(map[string]string)
+	for prefix, label := range d.GroupLabels {
+		for _, group := range groups {
+			value, found := strings.CutPrefix(group, prefix)
+			if !found || value == "" {
+				continue
+			}
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				log.FromContext(ctx).Info("Skipping default label with invalid value", "Group", group, "Errors", errs)
+				continue
+			}
+			defaults[label] = value
+			break
+		}
+	}
+	return defaults
+}
+
+// lowercaseKeys returns the labels with lowercase keys. Of keys differing only in case the last in sorted order
+// wins, which is the lowercase one when present.
+func lowercaseKeys(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lowered := make(map[string]string, len(labels))
+	for _, key := range keys {
+		lowered[strings.ToLower(key)] = labels[key]
+	}
+	return lowered
+}
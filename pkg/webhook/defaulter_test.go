@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+var _ = Describe("NamespaceLabel Defaulting Webhook", func() {
+	const namespaceName = "tenant"
+
+	var defaulter *NamespaceLabelDefaulter
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        namespaceName,
+				Annotations: map[string]string{"owner": "alice", "team": "payments"},
+			},
+		}
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace).Build()
+		defaulter = &NamespaceLabelDefaulter{
+			Client:           k8sClient,
+			AnnotationLabels: map[string]string{"owner": "owner", "team": "dana.io/team"},
+			decoder:          admission.NewDecoder(scheme),
+		}
+	})
+
+	It("should inject ownership labels from namespace annotations when absent", func() {
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "minimal", Namespace: namespaceName},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"owner": "bob"}},
+		}
+		resp := defaulter.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(resp.Patches).To(HaveLen(1))
+		Expect(resp.Patches[0].Operation).To(Equal("add"))
+		Expect(resp.Patches[0].Path).To(Equal("/spec/labels/dana.io~1team"))
+		Expect(resp.Patches[0].Value).To(Equal("payments"))
+	})
+
+	It("should leave the object untouched when no defaults are configured", func() {
+		defaulter.AnnotationLabels = nil
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "minimal", Namespace: namespaceName},
+		}
+		resp := defaulter.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(resp.Patches).To(BeEmpty())
+	})
+
+	It("should inject configured and group-derived labels below the namespace annotations", func() {
+		defaulter.Labels = map[string]string{"managed-by": "platform", "dana.io/team": "unknown"}
+		defaulter.GroupLabels = map[string]string{"oidc:env-": "environment", "oidc:team-": "dana.io/team"}
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "minimal", Namespace: namespaceName},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"owner": "bob"}},
+		}
+		req := newAdmissionRequest(admissionv1.Create, namespaceLabel)
+		req.UserInfo = authenticationv1.UserInfo{Username: "bob", Groups: []string{"oidc:team-billing", "oidc:env-dev"}}
+		resp := defaulter.Handle(ctx, req)
+		Expect(resp.Allowed).To(BeTrue())
+
+		added := make(map[string]interface{})
+		for _, patch := range resp.Patches {
+			added[patch.Path] = patch.Value
+		}
+		Expect(added).To(Equal(map[string]interface{}{
+			"/spec/labels/managed-by":    "platform",
+			"/spec/labels/environment":   "dev",
+			"/spec/labels/dana.io~1team": "payments",
+			"/metadata/annotations":      map[string]interface{}{namespacelabel.ModifiedByAnnotation: "bob"},
+		}))
+	})
+
+	It("should lowercase label keys when configured to", func() {
+		defaulter.AnnotationLabels = nil
+		defaulter.LowercaseKeys = true
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "minimal", Namespace: namespaceName},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"Team": "a", "Owner": "b", "owner": "c"}},
+		}
+		resp := defaulter.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+		Expect(resp.Allowed).To(BeTrue())
+
+		operations := make(map[string]string)
+		for _, patch := range resp.Patches {
+			operations[patch.Path] = patch.Operation
+		}
+		Expect(operations).To(Equal(map[string]string{
+			"/spec/labels/Team":  "remove",
+			"/spec/labels/Owner": "remove",
+			"/spec/labels/team":  "add",
+		}))
+	})
+
+	It("should mark specs changed by trusted principals and drop markers set by anyone else", func() {
+		defaulter.AnnotationLabels = nil
+		defaulter.Bypass = &danav1alpha1.PrincipalList{Groups: []string{"system:masters"}}
+		defaulter.ControllerUsername = "system:serviceaccount:system:controller"
+		trusted := func() map[string]string {
+			return map[string]string{namespacelabel.AdmissionBypassAnnotation: namespacelabel.BypassTrustedPrincipal}
+		}
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "minimal", Namespace: namespaceName},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"kubernetes.io/team": "a"}},
+		}
+		req := newAdmissionRequest(admissionv1.Create, namespaceLabel)
+		req.UserInfo = authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}}
+		resp := defaulter.Handle(ctx, req)
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(resp.Patches).To(ConsistOf(HaveField("Value", map[string]interface{}{
+			namespacelabel.ModifiedByAnnotation:      "admin",
+			namespacelabel.AdmissionBypassAnnotation: namespacelabel.BypassTrustedPrincipal,
+		})))
+
+		By("dropping a marker set by the request itself")
+		forged := namespaceLabel.DeepCopy()
+		forged.Annotations = trusted()
+		req = newAdmissionRequest(admissionv1.Create, forged)
+		req.UserInfo = authenticationv1.UserInfo{Username: "tenant"}
+		Expect(defaulter.stampBypass(req, forged)).To(Succeed())
+		Expect(forged.Annotations).NotTo(HaveKey(namespacelabel.AdmissionBypassAnnotation))
+
+		By("keeping the marker while the spec is unchanged or the controller changes it")
+		marked := namespaceLabel.DeepCopy()
+		marked.Annotations = trusted()
+		update := func(username string, changed *danav1alpha1.NamespaceLabel) *danav1alpha1.NamespaceLabel {
+			req := newAdmissionRequest(admissionv1.Update, changed)
+			req.OldObject.Raw = newAdmissionRequest(admissionv1.Update, marked).Object.Raw
+			req.UserInfo = authenticationv1.UserInfo{Username: username}
+			Expect(defaulter.stampBypass(req, changed)).To(Succeed())
+			return changed
+		}
+		Expect(update("tenant", marked.DeepCopy()).Annotations).To(
+			HaveKeyWithValue(namespacelabel.AdmissionBypassAnnotation, namespacelabel.BypassTrustedPrincipal))
+		changed := marked.DeepCopy()
+		changed.Spec.Labels = nil
+		Expect(update(defaulter.ControllerUsername, changed.DeepCopy()).Annotations).To(
+			HaveKey(namespacelabel.AdmissionBypassAnnotation))
+		Expect(update("tenant", changed.DeepCopy()).Annotations).NotTo(HaveKey(namespacelabel.AdmissionBypassAnnotation))
+	})
+
+	It("should mark specs changed with a valid break-glass annotation until the annotation changes", func() {
+		defaulter.AnnotationLabels = nil
+		defaulter.BreakGlassGroups = []string{"system:masters"}
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "minimal",
+				Namespace: namespaceName,
+				Annotations: map[string]string{
+					namespacelabel.BreakGlassAnnotation:        "incident 42",
+					namespacelabel.BreakGlassExpiresAnnotation: time.Now().Add(10 * time.Minute).Format(time.RFC3339),
+				},
+			},
+			Spec: danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"kubernetes.io/team": "a"}},
+		}
+		req := newAdmissionRequest(admissionv1.Create, namespaceLabel)
+		req.UserInfo = authenticationv1.UserInfo{Username: "tenant"}
+		Expect(defaulter.stampBypass(req, namespaceLabel)).To(Succeed())
+		Expect(namespaceLabel.Annotations).NotTo(HaveKey(namespacelabel.AdmissionBypassAnnotation))
+
+		req.UserInfo = authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}}
+		Expect(defaulter.stampBypass(req, namespaceLabel)).To(Succeed())
+		Expect(namespaceLabel.Annotations).To(
+			HaveKeyWithValue(namespacelabel.AdmissionBypassAnnotation, namespacelabel.BypassBreakGlass))
+
+		By("dropping the marker when someone else extends the annotation")
+		extended := namespaceLabel.DeepCopy()
+		extended.Annotations[namespacelabel.BreakGlassExpiresAnnotation] = time.Now().Add(time.Hour).Format(time.RFC3339)
+		req = newAdmissionRequest(admissionv1.Update, extended)
+		req.OldObject.Raw = newAdmissionRequest(admissionv1.Update, namespaceLabel).Object.Raw
+		req.UserInfo = authenticationv1.UserInfo{Username: "tenant"}
+		Expect(defaulter.stampBypass(req, extended)).To(Succeed())
+		Expect(extended.Annotations).NotTo(HaveKey(namespacelabel.AdmissionBypassAnnotation))
+	})
+})
@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// DecodersReadyCheck is the name of the readiness check SetupWithManager adds for the webhook decoders
+const DecodersReadyCheck = "webhook-decoders"
+
+// decodingHandler is an admission handler that needs a decoder to read requests
+type decodingHandler interface {
+	hasDecoder() bool
+}
+
+func (v *NamespaceLabelValidator) hasDecoder() bool { return v.decoder != nil }
+
+func (d *NamespaceLabelDefaulter) hasDecoder() bool { return d.decoder != nil }
+
+func (v *NamespaceValidator) hasDecoder() bool { return v.decoder != nil }
+
+func (v *ClusterNamespaceLabelValidator) hasDecoder() bool { return v.decoder != nil }
+
+// decodersReady returns a readiness check failing while a handler, keyed by the path it serves, has no decoder
+// and would reject every request it receives
+func decodersReady(handlers map[string]decodingHandler) healthz.Checker {
+	return func(*http.Request) error {
+		for path, handler := range handlers {
+			if !handler.hasDecoder() {
+				return fmt.Errorf("webhook %s has no decoder", path)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+// NamespaceValidator rejects Namespace updates that remove or change labels owned by a NamespaceLabel, so
+// managed labels are enforced at admission instead of being re-applied after the fact. The annotation recording
+// the owner of each managed label, like the one recording who created a Namespace, may only be changed by the
+// controller.
+type NamespaceValidator struct {
+	Client client.Client
+	// ControllerUsername is the user the controller writes Namespaces as; its requests are always allowed
+	ControllerUsername string
+	// WarnOnly admits offending requests with a warning instead of rejecting them
+	WarnOnly bool
+	// WatchSelector restricts protection to NamespaceLabels whose labels match it; nil matches all
+	WatchSelector labels.Selector
+	// MapNamespace maps a NamespaceLabel's namespace to the Namespace it labels; nil maps it to itself
+	MapNamespace namespacelabel.NamespaceMapper
+	decoder      admission.Decoder
+}
+
+func (v *NamespaceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := log.FromContext(ctx)
+
+	if req.Operation != admissionv1.Update || req.UserInfo.Username == v.ControllerUsername {
+		return admission.Allowed("")
+	}
+
+	ns, oldNamespace := &corev1.Namespace{}, &corev1.Namespace{}
+	if err := v.decoder.Decode(req, ns); err != nil {
+		log.Error(err, "Error decoding request: %v\n")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if err := v.decoder.DecodeRaw(req.OldObject, oldNamespace); err != nil {
+		log.Error(err, "Error decoding old object: %v\n")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	violations, err := v.managedLabelViolations(ctx, oldNamespace, ns)
+	if err != nil {
+		log.Error(err, "Error listing NamespaceLabels: %v\n")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	// The ownership markers are only trustworthy as long as the controller alone writes them
+	for _, key := range []string{namespacelabel.OwnedLabelsAnnotation, namespacelabel.CreatedByAnnotation} {
+		if ns.Annotations[key] != oldNamespace.Annotations[key] {
+			violations = append(violations, fmt.Sprintf(
+				"annotation '%s' is maintained by the NamespaceLabel controller and cannot be changed", key))
+		}
+	}
+	if len(violations) == 0 {
+		return admission.Allowed("")
+	}
+
+	if v.WarnOnly {
+		return admission.Allowed("").WithWarnings(violations...)
+	}
+	return admission.Denied(violations[0])
+}
+
+// managedLabelViolations describes each label applied by a NamespaceLabel that the update removes or changes
+func (v *NamespaceValidator) managedLabelViolations(
+	ctx context.Context, oldNamespace, ns *corev1.Namespace) ([]string, error) {
+	namespaceLabels := &danav1alpha1.NamespaceLabelList{}
+	if err := v.Client.List(ctx, namespaceLabels); err != nil {
+		return nil, err
+	}
+
+	var violations []string
+	for i := range namespaceLabels.Items {
+		nl := &namespaceLabels.Items[i]
+		if !namespacelabel.IsWatched(v.WatchSelector, nl) {
+			continue
+		}
+		targets, err := namespacelabel.LabeledNamespaces(v.MapNamespace, nl)
+		if err != nil || !slices.Contains(targets, ns.Name) {
+			continue
+		}
+
+		for key := range nl.Status.AppliedLabels {
+			previous, existed := oldNamespace.Labels[key]
+			current, exists := ns.Labels[key]
+			if !existed || (exists && current == previous) {
+				continue
+			}
+			action := "removed"
+			if exists {
+				action = "changed"
+			}
+			violations = append(violations, fmt.Sprintf("label '%s' is managed by NamespaceLabel %s/%s and cannot be %s",
+				key, nl.Namespace, nl.Name, action))
+		}
+	}
+
+	sort.Strings(violations)
+	return violations, nil
+}
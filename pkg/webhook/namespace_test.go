@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+)
+
+var _ = Describe("Namespace Webhook", func() {
+	const (
+		namespaceName      = "tenant"
+		controllerUsername = "system:serviceaccount:system:controller-manager"
+	)
+
+	var validator *NamespaceValidator
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		namespaceLabel := &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-resource", Namespace: namespaceName},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: map[string]string{"team": "a"}},
+			Status:     danav1alpha1.NamespaceLabelStatus{AppliedLabels: map[string]string{"team": "a"}},
+		}
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespaceLabel).Build()
+		validator = &NamespaceValidator{
+			Client:             k8sClient,
+			ControllerUsername: controllerUsername,
+			decoder:            admission.NewDecoder(scheme),
+		}
+	})
+
+	newUpdateRequest := func(username string, oldLabels, newLabels map[string]string) admission.Request {
+		raw := func(labels map[string]string) runtime.RawExtension {
+			data, err := json.Marshal(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName, Labels: labels}})
+			Expect(err).NotTo(HaveOccurred())
+			return runtime.RawExtension{Raw: data}
+		}
+		return admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Update,
+				Name:      namespaceName,
+				UserInfo:  authenticationv1.UserInfo{Username: username},
+				Object:    raw(newLabels),
+				OldObject: raw(oldLabels),
+			},
+		}
+	}
+
+	It("should deny removing or changing a managed label", func() {
+		response := validator.Handle(ctx, newUpdateRequest("alice", map[string]string{"team": "a"}, nil))
+		Expect(response.Allowed).To(BeFalse())
+		Expect(response.Result.Message).To(ContainSubstring("cannot be removed"))
+
+		response = validator.Handle(ctx, newUpdateRequest("alice",
+			map[string]string{"team": "a"}, map[string]string{"team": "b"}))
+		Expect(response.Allowed).To(BeFalse())
+		Expect(response.Result.Message).To(ContainSubstring("cannot be changed"))
+	})
+
+	It("should allow changes to unmanaged labels and changes by the controller", func() {
+		response := validator.Handle(ctx, newUpdateRequest("alice",
+			map[string]string{"team": "a", "owner": "x"}, map[string]string{"team": "a"}))
+		Expect(response.Allowed).To(BeTrue())
+
+		response = validator.Handle(ctx, newUpdateRequest(controllerUsername, map[string]string{"team": "a"}, nil))
+		Expect(response.Allowed).To(BeTrue())
+	})
+
+	It("should deny changing the ownership annotation", func() {
+		request := newUpdateRequest("alice", map[string]string{"team": "a"}, map[string]string{"team": "a"})
+		data, err := json.Marshal(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: namespaceName, Labels: map[string]string{"team": "a"},
+			Annotations: map[string]string{namespacelabel.OwnedLabelsAnnotation: `{"team":"tenant/test-resource"}`},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		request.OldObject = runtime.RawExtension{Raw: data}
+
+		response := validator.Handle(ctx, request)
+		Expect(response.Allowed).To(BeFalse())
+		Expect(response.Result.Message).To(ContainSubstring("is maintained by the NamespaceLabel controller"))
+
+		request.UserInfo.Username = controllerUsername
+		Expect(validator.Handle(ctx, request).Allowed).To(BeTrue())
+	})
+
+	It("should only warn when configured to", func() {
+		validator.WarnOnly = true
+		response := validator.Handle(ctx, newUpdateRequest("alice", map[string]string{"team": "a"}, nil))
+		Expect(response.Allowed).To(BeTrue())
+		Expect(response.Warnings).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,332 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
+)
+
+// protectLabelsAnnotation on a Namespace set to "true" denies deleting the NamespaceLabels labeling it
+const protectLabelsAnnotation = "dana.io/protect-labels"
+
+// NamespaceLabelValidator admits NamespaceLabels that satisfy the uniqueness, protection and policy rules
+type NamespaceLabelValidator struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+	// PolicySource provides the LabelPolicies that apply to a namespace
+	PolicySource validation.PolicySource
+	// Quota caps the labels NamespaceLabels may declare and manage on a namespace, on top of the LabelPolicy limits
+	Quota validation.LabelQuota
+	// BreakGlassGroups are the groups allowed to bypass a denial with the break-glass annotation
+	BreakGlassGroups []string
+	// Bypass are the trusted principals, e.g. cluster admins and GitOps controllers, whose requests are admitted
+	// with a warning instead of denied. The defaulting webhook marks their NamespaceLabels so the controller does
+	// not re-check the waived rules either.
+	Bypass *danav1alpha1.PrincipalList
+	// WatchSelector restricts validation to NamespaceLabels whose labels match it; nil matches all
+	WatchSelector labels.Selector
+	// MapNamespace maps a NamespaceLabel's namespace to the Namespace it labels; nil maps it to itself
+	MapNamespace namespacelabel.NamespaceMapper
+	// AdminNamespaces are the namespaces whose NamespaceLabels may set targetNamespaces
+	AdminNamespaces []string
+	// AllowMultiple admits several NamespaceLabels per namespace
+	AllowMultiple bool
+	// ProtectedLabels are the label keys NamespaceLabels may not set; nil protects the Kubernetes management labels
+	ProtectedLabels *namespacelabel.ProtectedLabels
+	// ProtectedAnnotations are the annotation keys NamespaceLabels may not set; nil protects
+	// namespacelabel.DefaultProtectedAnnotationPrefixes
+	ProtectedAnnotations *namespacelabel.ProtectedLabels
+	// ProtectedNamespaces are the Namespaces never labeled; nil protects namespacelabel.DefaultProtectedNamespaces
+	ProtectedNamespaces *namespacelabel.ProtectedNamespaces
+	// OptInAnnotation is the annotation a namespace must carry set to "true" for NamespaceLabels to be created
+	// in it; empty admits them in every namespace
+	OptInAnnotation string
+	decoder         admission.Decoder
+}
+
+func (v *NamespaceLabelValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := log.FromContext(ctx)
+	log.Info("Started calling webhook: %s\n", "Namespace", req.Namespace, "Name", req.Name)
+	namespaceLabel := &danav1alpha1.NamespaceLabel{}
+
+	if req.Operation == admissionv1.Delete {
+		return v.bypass(req, v.validateDelete(ctx, req))
+	}
+
+	err := v.decoder.Decode(req, namespaceLabel)
+	if err != nil {
+		log.Error(err, "Error decoding request: %v\n")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !namespacelabel.IsWatched(v.WatchSelector, namespaceLabel) {
+		return admission.Allowed("not watched by this instance")
+	}
+	if namespaceLabel.Namespace == "" {
+		namespaceLabel.Namespace = req.Namespace
+	}
+
+	// Only namespaces cluster admins opted in may create NamespaceLabels; existing ones can still be changed
+	// and deleted
+	if req.Operation == admissionv1.Create {
+		if message, err := v.notOptedIn(ctx, namespaceLabel); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		} else if message != "" {
+			return admission.Denied(message)
+		}
+	}
+
+	// Protected namespaces are never labeled, so break-glass does not apply
+	if message, err := v.protectedNamespace(ctx, namespaceLabel); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	} else if message != "" {
+		return admission.Denied(message)
+	}
+
+	response := v.bypass(req, v.validate(ctx, req, namespaceLabel))
+	if !response.Allowed && response.Result != nil && response.Result.Code == http.StatusForbidden {
+		return v.breakGlass(ctx, req, namespaceLabel, response)
+	}
+
+	return response
+}
+
+// validate runs the admission checks for a decoded NamespaceLabel
+func (v *NamespaceLabelValidator) validate(
+	ctx context.Context, req admission.Request, namespaceLabel *danav1alpha1.NamespaceLabel) admission.Response {
+	log := log.FromContext(ctx)
+
+	// Ensure only one NamespaceLabel per namespace unless several are allowed
+	if !v.AllowMultiple {
+		existingNamespaceLabels := &danav1alpha1.NamespaceLabelList{}
+		if err := v.Client.List(ctx, existingNamespaceLabels, client.InNamespace(req.Namespace)); err != nil {
+			log.Error(err, "Error listing existing labels: %v\n")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+
+		for _, existing := range existingNamespaceLabels.Items {
+			if existing.Name != namespaceLabel.Name {
+				return admission.Denied("only one NamespaceLabel allowed per namespace")
+			}
+		}
+	}
+
+	// On UPDATE only the added and changed labels are checked against the key and value rules of LabelPolicies,
+	// so labels admitted earlier do not block unrelated edits; CEL rules always see every label
+	labels := declaredLabels(namespaceLabel)
+	changedLabels := labels
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		oldNamespaceLabel := &danav1alpha1.NamespaceLabel{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldNamespaceLabel); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		changedLabels = labelDiff(declaredLabels(oldNamespaceLabel), labels)
+	}
+
+	// Report every malformed key and value as a structured cause
+	if errs := validation.ValidateLabelSyntax(namespaceLabel.Spec.Labels, field.NewPath("spec", "labels")); len(errs) > 0 {
+		status := apierrors.NewInvalid(danav1alpha1.GroupVersion.WithKind("NamespaceLabel").GroupKind(),
+			namespaceLabel.Name, errs).ErrStatus
+		return admission.Response{AdmissionResponse: admissionv1.AdmissionResponse{Allowed: false, Result: &status}}
+	}
+	if err := validation.ValidateSpec(namespaceLabel, v.AdminNamespaces, v.ProtectedLabels,
+		v.ProtectedAnnotations); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	targets, err := namespacelabel.LabeledNamespaces(v.MapNamespace, namespaceLabel)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	// Ensure labels are not denied by a LabelPolicy selecting the labeled namespaces
+	for _, target := range targets {
+		ns := &corev1.Namespace{}
+		if err := v.Client.Get(ctx, types.NamespacedName{Name: target}, ns); err != nil {
+			log.Error(err, "Error fetching namespace: %v\n")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+
+		policies, err := v.PolicySource.PoliciesFor(ctx, ns)
+		if err != nil {
+			log.Error(err, "Error listing label policies: %v\n")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+
+		if err := validation.ValidatePrincipal(policies, req.UserInfo); err != nil {
+			return admission.Denied(err.Error())
+		}
+		if err := validation.ValidatePolicies(policies, changedLabels); err != nil {
+			return admission.Denied(err.Error())
+		}
+		if err := validation.ValidateRules(policies, labels); err != nil {
+			return admission.Denied(err.Error())
+		}
+		if err := v.validateQuota(ctx, namespaceLabel, ns, policies); err != nil {
+			var exceeded *validation.QuotaExceeded
+			if errors.As(err, &exceeded) {
+				return admission.Denied(err.Error())
+			}
+			log.Error(err, "Error counting managed labels: %v\n")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// validateQuota checks the labels a NamespaceLabel declares, including its label rules, against the label quota
+// of a labeled namespace. Labels from labelsFrom sources are only known when reconciled and are not counted.
+func (v *NamespaceLabelValidator) validateQuota(ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel,
+	ns *corev1.Namespace, policies []danav1alpha1.LabelPolicy) error {
+	quota := validation.EffectiveQuota(v.Quota, policies)
+	if quota.MaxLabels == 0 && quota.MaxManagedLabels == 0 {
+		return nil
+	}
+
+	managed := map[string]struct{}{}
+	if quota.MaxManagedLabels > 0 {
+		var err error
+		if managed, err = namespacelabel.ManagedLabelKeys(ctx, v.Client, v.MapNamespace, ns, namespaceLabel); err != nil {
+			return err
+		}
+	}
+	for key := range namespaceLabel.Spec.Labels {
+		managed[key] = struct{}{}
+	}
+	for _, rule := range namespaceLabel.Spec.LabelRules {
+		managed[rule.Key] = struct{}{}
+	}
+	return quota.Validate(ns.Name, len(namespaceLabel.Spec.Labels)+len(namespaceLabel.Spec.LabelRules), len(managed))
+}
+
+// protectedNamespace describes why a NamespaceLabel may not be admitted if it lives in or labels a protected
+// namespace, or returns "" if it does not
+func (v *NamespaceLabelValidator) protectedNamespace(
+	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel) (string, error) {
+	targets, err := namespacelabel.LabeledNamespaces(v.MapNamespace, namespaceLabel)
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range append([]string{namespaceLabel.Namespace}, targets...) {
+		ns := &corev1.Namespace{}
+		if err := v.Client.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return "", err
+			}
+			ns.Name = name
+		}
+		if v.ProtectedNamespaces.IsProtected(ns) {
+			return fmt.Sprintf("namespace '%s' is protected and may not be labeled by NamespaceLabels", name), nil
+		}
+	}
+	return "", nil
+}
+
+// notOptedIn returns why a NamespaceLabel may not be created in its namespace when opt-in is required, or an
+// empty string if it may
+func (v *NamespaceLabelValidator) notOptedIn(
+	ctx context.Context, namespaceLabel *danav1alpha1.NamespaceLabel) (string, error) {
+	if v.OptInAnnotation == "" {
+		return "", nil
+	}
+	ns := &corev1.Namespace{}
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: namespaceLabel.Namespace}, ns); err != nil {
+		return "", err
+	}
+	if namespacelabel.OptedIn(v.OptInAnnotation, ns) {
+		return "", nil
+	}
+	return fmt.Sprintf("namespace '%s' is not opted in to NamespaceLabels; a cluster admin must annotate it with %s=true",
+		ns.Name, v.OptInAnnotation), nil
+}
+
+// validateDelete denies deleting a NamespaceLabel whose labeled Namespaces protect their labels. Namespaces
+// being deleted are not protected, so their NamespaceLabels can be cleaned up.
+func (v *NamespaceLabelValidator) validateDelete(ctx context.Context, req admission.Request) admission.Response {
+	log := log.FromContext(ctx)
+	namespaceLabel := &danav1alpha1.NamespaceLabel{}
+
+	if err := v.decoder.DecodeRaw(req.OldObject, namespaceLabel); err != nil {
+		log.Error(err, "Error decoding request: %v\n")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !namespacelabel.IsWatched(v.WatchSelector, namespaceLabel) {
+		return admission.Allowed("not watched by this instance")
+	}
+	if namespaceLabel.Namespace == "" {
+		namespaceLabel.Namespace = req.Namespace
+	}
+
+	targets, err := namespacelabel.LabeledNamespaces(v.MapNamespace, namespaceLabel)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	for _, target := range targets {
+		ns := &corev1.Namespace{}
+		if err := v.Client.Get(ctx, types.NamespacedName{Name: target}, ns); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			log.Error(err, "Error fetching namespace: %v\n")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+
+		if ns.DeletionTimestamp.IsZero() && ns.Annotations[protectLabelsAnnotation] == "true" {
+			return admission.Denied(fmt.Sprintf("namespace '%s' protects its labels with annotation '%s'",
+				target, protectLabelsAnnotation))
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// declaredLabels returns the labels of a NamespaceLabel merged over the values of its label rules, like the
+// controller resolves them. Every rule is included whatever its expiry or schedule, since each may be applied.
+func declaredLabels(namespaceLabel *danav1alpha1.NamespaceLabel) map[string]string {
+	if len(namespaceLabel.Spec.LabelRules) == 0 {
+		return namespaceLabel.Spec.Labels
+	}
+	labels := make(map[string]string, len(namespaceLabel.Spec.Labels)+len(namespaceLabel.Spec.LabelRules))
+	for _, rule := range namespaceLabel.Spec.LabelRules {
+		labels[rule.Key] = rule.Value
+	}
+	for key, value := range namespaceLabel.Spec.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// labelDiff returns the labels that are added or changed from old to new
+func labelDiff(old, updated map[string]string) map[string]string {
+	changed := make(map[string]string)
+	for key, value := range updated {
+		if oldValue, exists := old[key]; !exists || oldValue != value {
+			changed[key] = value
+		}
+	}
+	return changed
+}
+
+func (v *NamespaceLabelValidator) InjectDecoder(d admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
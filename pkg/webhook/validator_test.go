@@ -0,0 +1,614 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
+)
+
+// newAdmissionRequest builds an admission request carrying the given NamespaceLabel
+func newAdmissionRequest(operation admissionv1.Operation, namespaceLabel *danav1alpha1.NamespaceLabel) admission.Request {
+	raw, err := json.Marshal(namespaceLabel)
+	Expect(err).NotTo(HaveOccurred())
+
+	return admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation,
+			Namespace: namespaceLabel.Namespace,
+			Name:      namespaceLabel.Name,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+// staticPolicySource serves a fixed set of LabelPolicies to every namespace
+type staticPolicySource []danav1alpha1.LabelPolicy
+
+func (s staticPolicySource) PoliciesFor(_ context.Context, _ *corev1.Namespace) ([]danav1alpha1.LabelPolicy, error) {
+	return s, nil
+}
+
+var _ = Describe("NamespaceLabel Webhook", func() {
+	const namespaceName = "tenant"
+
+	var validator *NamespaceLabelValidator
+
+	BeforeEach(func() {
+		initTestEnvironment()
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: namespaceName, Labels: map[string]string{"tenant": "dev"}},
+		}
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace).Build()
+		validator = &NamespaceLabelValidator{
+			Client:       k8sClient,
+			PolicySource: &validation.ClusterPolicySource{Client: k8sClient},
+			decoder:      admission.NewDecoder(scheme),
+		}
+	})
+
+	newNamespaceLabel := func(labels map[string]string) *danav1alpha1.NamespaceLabel {
+		return &danav1alpha1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-resource", Namespace: namespaceName},
+			Spec:       danav1alpha1.NamespaceLabelSpec{Labels: labels},
+		}
+	}
+
+	Context("When validating a NamespaceLabel against LabelPolicies", func() {
+		BeforeEach(func() {
+			policy := &danav1alpha1.LabelPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod-only"},
+				Spec: danav1alpha1.LabelPolicySpec{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "tenant", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"prod"}},
+						},
+					},
+					DeniedValues: []danav1alpha1.DeniedValueRule{
+						{Key: "environment", Values: []string{"prod"}, Reason: "only the prod tenant may set environment=prod"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+		})
+
+		It("should deny a value forbidden for the namespace", func() {
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"environment": "prod"}))
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("denied by LabelPolicy 'prod-only'"))
+			Expect(resp.Result.Message).To(ContainSubstring("only the prod tenant may set environment=prod"))
+		})
+
+		It("should deny a label rule value forbidden for the namespace", func() {
+			namespaceLabel := newNamespaceLabel(map[string]string{"team": "a"})
+			namespaceLabel.Spec.LabelRules = []danav1alpha1.LabelRule{{
+				Key: "environment", Value: "prod", ExpiresAfter: &metav1.Duration{Duration: time.Hour},
+			}}
+			resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("denied by LabelPolicy 'prod-only'"))
+
+			By("adding the rule to an existing NamespaceLabel")
+			oldNamespaceLabel := newNamespaceLabel(map[string]string{"team": "a"})
+			raw, err := json.Marshal(oldNamespaceLabel)
+			Expect(err).NotTo(HaveOccurred())
+			req := newAdmissionRequest(admissionv1.Update, namespaceLabel)
+			req.OldObject = runtime.RawExtension{Raw: raw}
+			Expect(validator.Handle(ctx, req).Allowed).To(BeFalse())
+		})
+
+		It("should not validate NamespaceLabels not watched by this instance", func() {
+			validator.WatchSelector = labels.SelectorFromSet(labels.Set{"dana.io/instance": "blue"})
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"environment": "prod"}))
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+		})
+
+		It("should deny resync intervals that are too short", func() {
+			namespaceLabel := newNamespaceLabel(map[string]string{"environment": "dev"})
+			namespaceLabel.Spec.ResyncInterval = &metav1.Duration{Duration: time.Second}
+			resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("resyncInterval"))
+		})
+
+		It("should deny protected and controller annotations", func() {
+			namespaceLabel := newNamespaceLabel(map[string]string{"environment": "dev"})
+			namespaceLabel.Spec.Annotations = map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "pool=a"}
+			resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("protected annotation"))
+
+			namespaceLabel.Spec.Annotations = map[string]string{"namespacelabel.dana.io/status": "forged"}
+			Expect(validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel)).Allowed).To(BeFalse())
+
+			namespaceLabel.Spec.Annotations = map[string]string{"contact": "team-a@example.com"}
+			Expect(validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel)).Allowed).To(BeTrue())
+		})
+
+		It("should enforce policies from an injected policy source", func() {
+			validator.PolicySource = staticPolicySource{{
+				ObjectMeta: metav1.ObjectMeta{Name: "static"},
+				Spec: danav1alpha1.LabelPolicySpec{
+					DeniedValues: []danav1alpha1.DeniedValueRule{{Key: "environment", Values: []string{"dev"}}},
+				},
+			}}
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"environment": "dev"}))
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("denied by LabelPolicy 'static'"))
+		})
+
+		It("should enforce denied keys, allowed key prefixes and value patterns", func() {
+			validator.PolicySource = staticPolicySource{{
+				ObjectMeta: metav1.ObjectMeta{Name: "security"},
+				Spec: danav1alpha1.LabelPolicySpec{
+					AllowedKeyPrefixes: []string{"dana.io/", "environment"},
+					DeniedKeys:         []string{"dana.io/billing"},
+					ValueConstraints: []danav1alpha1.ValueConstraint{
+						{Key: "environment", Pattern: "dev|staging", Reason: "tenants run non-prod environments"},
+					},
+				},
+			}}
+
+			for key, message := range map[string]string{
+				"dana.io/billing": "label 'dana.io/billing' is denied by LabelPolicy 'security'",
+				"owner":           "not under a key prefix allowed by LabelPolicy 'security'",
+				"environment":     "does not match pattern 'dev|staging' of LabelPolicy 'security': tenants run",
+			} {
+				value := "x"
+				if key == "environment" {
+					value = "devel"
+				}
+				resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create,
+					newNamespaceLabel(map[string]string{key: value})))
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(resp.Result.Message).To(ContainSubstring(message))
+			}
+
+			req := newAdmissionRequest(admissionv1.Create,
+				newNamespaceLabel(map[string]string{"dana.io/team": "a", "environment": "staging"}))
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+		})
+
+		It("should enforce CEL rules", func() {
+			validator.PolicySource = staticPolicySource{{
+				ObjectMeta: metav1.ObjectMeta{Name: "environments"},
+				Spec: danav1alpha1.LabelPolicySpec{
+					Rules: []danav1alpha1.CELRule{{
+						Expression: "!('environment' in labels) || labels.environment in ['dev', 'staging', 'prod']",
+						Reason:     "environment must be one of dev, staging or prod",
+					}},
+				},
+			}}
+
+			resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create,
+				newNamespaceLabel(map[string]string{"environment": "qa"})))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring(
+				"of LabelPolicy 'environments': environment must be one of dev, staging or prod"))
+
+			for _, labels := range []map[string]string{{"environment": "prod"}, {"team": "a"}} {
+				req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(labels))
+				Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+			}
+
+			By("denying labels when a rule does not compile")
+			validator.PolicySource = staticPolicySource{{
+				ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+				Spec:       danav1alpha1.LabelPolicySpec{Rules: []danav1alpha1.CELRule{{Expression: "size(labels)"}}},
+			}}
+			resp = validator.Handle(ctx, newAdmissionRequest(admissionv1.Create,
+				newNamespaceLabel(map[string]string{"team": "a"})))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("LabelPolicy 'broken' has an invalid rule"))
+		})
+
+		It("should evaluate CEL rules against every label on UPDATE", func() {
+			validator.PolicySource = staticPolicySource{{
+				ObjectMeta: metav1.ObjectMeta{Name: "owned"},
+				Spec: danav1alpha1.LabelPolicySpec{
+					Rules: []danav1alpha1.CELRule{
+						{Expression: "'team' in labels"},
+						{Expression: "!('tier' in labels) || labels.tier != 'gold' || labels.team == 'platform'"},
+					},
+				},
+			}}
+			update := func(old, updated map[string]string) admission.Response {
+				req := newAdmissionRequest(admissionv1.Update, newNamespaceLabel(updated))
+				raw, err := json.Marshal(newNamespaceLabel(old))
+				Expect(err).NotTo(HaveOccurred())
+				req.OldObject = runtime.RawExtension{Raw: raw}
+				return validator.Handle(ctx, req)
+			}
+
+			By("allowing edits that leave a required label unchanged")
+			Expect(update(map[string]string{"team": "a"}, map[string]string{"team": "a", "app": "web"}).Allowed).
+				To(BeTrue())
+
+			By("denying a change to one key that breaks a rule spanning several")
+			resp := update(map[string]string{"team": "platform", "tier": "gold"},
+				map[string]string{"team": "a", "tier": "gold"})
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("of LabelPolicy 'owned'"))
+		})
+
+		It("should only allow targetNamespaces in admin namespaces", func() {
+			namespaceLabel := newNamespaceLabel(map[string]string{"environment": "dev"})
+			namespaceLabel.Spec.TargetNamespaces = []string{namespaceName}
+			resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("admin namespaces"))
+
+			validator.AdminNamespaces = []string{namespaceName}
+			Expect(validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel)).Allowed).To(BeTrue())
+		})
+
+		It("should evaluate LabelPolicies against every target namespace", func() {
+			validator.AdminNamespaces = []string{namespaceName}
+			namespaceLabel := newNamespaceLabel(map[string]string{"environment": "prod"})
+			namespaceLabel.Spec.TargetNamespaces = []string{namespaceName}
+			resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("denied by LabelPolicy 'prod-only'"))
+		})
+
+		It("should allow values that are not denied", func() {
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"environment": "dev"}))
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+		})
+
+		It("should allow the value in namespaces the policy does not select", func() {
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"tenant": "prod"}}}
+			Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+
+			namespaceLabel := newNamespaceLabel(map[string]string{"environment": "prod"})
+			namespaceLabel.Namespace = "prod"
+			Expect(validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel)).Allowed).To(BeTrue())
+		})
+	})
+
+	Context("When a LabelPolicy restricts the allowed principals", func() {
+		BeforeEach(func() {
+			policy := &danav1alpha1.LabelPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenant-ci"},
+				Spec: danav1alpha1.LabelPolicySpec{
+					AllowedPrincipals: &danav1alpha1.PrincipalList{
+						Groups:          []string{"platform-admins"},
+						ServiceAccounts: []string{"tenant/ci"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+		})
+
+		withUser := func(req admission.Request, userInfo authenticationv1.UserInfo) admission.Request {
+			req.UserInfo = userInfo
+			return req
+		}
+
+		It("should allow the tenant's CI service account", func() {
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"team": "a"}))
+			req = withUser(req, authenticationv1.UserInfo{Username: "system:serviceaccount:tenant:ci"})
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+		})
+
+		It("should allow members of an allowed group", func() {
+			req := newAdmissionRequest(admissionv1.Update, newNamespaceLabel(map[string]string{"team": "a"}))
+			req = withUser(req, authenticationv1.UserInfo{Username: "alice", Groups: []string{"platform-admins"}})
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+		})
+
+		It("should deny principals outside the allowlist", func() {
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"team": "a"}))
+			req = withUser(req, authenticationv1.UserInfo{Username: "system:serviceaccount:tenant:default"})
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("not allowed to change NamespaceLabels"))
+		})
+	})
+
+	Context("When a break-glass annotation is set on a denied NamespaceLabel", func() {
+		var recorder *record.FakeRecorder
+
+		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(10)
+			validator.Recorder = recorder
+			validator.BreakGlassGroups = []string{"system:masters"}
+			policy := &danav1alpha1.LabelPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "no-prod"},
+				Spec: danav1alpha1.LabelPolicySpec{
+					DeniedValues: []danav1alpha1.DeniedValueRule{{Key: "environment", Values: []string{"prod"}}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+		})
+
+		breakGlassNamespaceLabel := func(expires time.Time) *danav1alpha1.NamespaceLabel {
+			namespaceLabel := newNamespaceLabel(map[string]string{"environment": "prod"})
+			namespaceLabel.Annotations = map[string]string{
+				breakGlassAnnotation:        "incident 42",
+				breakGlassExpiresAnnotation: expires.Format(time.RFC3339),
+			}
+			return namespaceLabel
+		}
+
+		admin := authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}}
+
+		It("should allow the change once and record who used it", func() {
+			req := newAdmissionRequest(admissionv1.Create, breakGlassNamespaceLabel(time.Now().Add(10*time.Minute)))
+			req.UserInfo = admin
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Warnings).NotTo(BeEmpty())
+			Expect(recorder.Events).To(Receive(And(ContainSubstring("BreakGlass"), ContainSubstring("admin"),
+				ContainSubstring("incident 42"))))
+		})
+
+		It("should not honor an annotation carried over from a previous request", func() {
+			namespaceLabel := breakGlassNamespaceLabel(time.Now().Add(10 * time.Minute))
+			req := newAdmissionRequest(admissionv1.Update, namespaceLabel)
+			previous := namespaceLabel.DeepCopy()
+			previous.Spec.Labels = map[string]string{"environment": "staging"}
+			old, err := json.Marshal(previous)
+			Expect(err).NotTo(HaveOccurred())
+			req.OldObject = runtime.RawExtension{Raw: old}
+			req.UserInfo = admin
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("already been used"))
+		})
+
+		It("should not honor the annotation from non-admins", func() {
+			req := newAdmissionRequest(admissionv1.Create, breakGlassNamespaceLabel(time.Now().Add(10*time.Minute)))
+			req.UserInfo = authenticationv1.UserInfo{Username: "tenant"}
+			Expect(validator.Handle(ctx, req).Allowed).To(BeFalse())
+			Expect(recorder.Events).NotTo(Receive())
+		})
+
+		It("should not honor expired or long-lived annotations", func() {
+			req := newAdmissionRequest(admissionv1.Create, breakGlassNamespaceLabel(time.Now().Add(-time.Minute)))
+			req.UserInfo = admin
+			Expect(validator.Handle(ctx, req).Allowed).To(BeFalse())
+
+			req = newAdmissionRequest(admissionv1.Create, breakGlassNamespaceLabel(time.Now().Add(24*time.Hour)))
+			req.UserInfo = admin
+			Expect(validator.Handle(ctx, req).Allowed).To(BeFalse())
+		})
+	})
+
+	Context("When a NamespaceLabel carries malformed labels", func() {
+		It("should report every invalid key and value", func() {
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{
+				"team":          "a b",
+				"-owner":        "billing",
+				"dana.io/valid": "yes",
+			}))
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Code).To(BeEquivalentTo(http.StatusUnprocessableEntity))
+			Expect(resp.Result.Details.Causes).To(HaveLen(2))
+			Expect(resp.Result.Details.Causes[0].Field).To(Equal("spec.labels"))
+			Expect(resp.Result.Details.Causes[1].Field).To(Equal("spec.labels[team]"))
+		})
+
+		It("should admit templated values and reject templates that do not parse", func() {
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{
+				"tenant": "{{ .Namespace.Name }}",
+			}))
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+
+			req = newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{
+				"tenant": "{{ .Namespace.Name",
+			}))
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Details.Causes).To(HaveLen(1))
+		})
+	})
+
+	Context("When a namespace already holds a NamespaceLabel", func() {
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &danav1alpha1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "networking", Namespace: namespaceName},
+			})).To(Succeed())
+		})
+
+		It("should deny another one unless several are allowed", func() {
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"owner": "billing"}))
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("only one NamespaceLabel allowed per namespace"))
+
+			validator.AllowMultiple = true
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+		})
+	})
+
+	Context("When updating or deleting a NamespaceLabel", func() {
+		var existing *danav1alpha1.NamespaceLabel
+
+		BeforeEach(func() {
+			existing = newNamespaceLabel(map[string]string{"environment": "prod"})
+			Expect(k8sClient.Create(ctx, existing)).To(Succeed())
+			Expect(k8sClient.Create(ctx, &danav1alpha1.LabelPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "no-prod"},
+				Spec: danav1alpha1.LabelPolicySpec{
+					DeniedValues: []danav1alpha1.DeniedValueRule{{Key: "environment", Values: []string{"prod"}}},
+				},
+			})).To(Succeed())
+		})
+
+		withOldObject := func(req admission.Request, old *danav1alpha1.NamespaceLabel) admission.Request {
+			raw, err := json.Marshal(old)
+			Expect(err).NotTo(HaveOccurred())
+			req.OldObject = runtime.RawExtension{Raw: raw}
+			return req
+		}
+
+		It("should not count the object itself and only check the changed labels", func() {
+			updated := newNamespaceLabel(map[string]string{"environment": "prod", "owner": "billing"})
+			resp := validator.Handle(ctx, withOldObject(newAdmissionRequest(admissionv1.Update, updated), existing))
+			Expect(resp.Allowed).To(BeTrue())
+
+			updated.Spec.Labels = map[string]string{"environment": "prod", "owner": "a b"}
+			resp = validator.Handle(ctx, withOldObject(newAdmissionRequest(admissionv1.Update, updated), existing))
+			Expect(resp.Allowed).To(BeFalse())
+		})
+
+		It("should deny deleting the NamespaceLabel of a namespace protecting its labels", func() {
+			req := withOldObject(admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Delete,
+				Namespace: namespaceName,
+				Name:      existing.Name,
+			}}, existing)
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			namespace.Annotations = map[string]string{protectLabelsAnnotation: "true"}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring(protectLabelsAnnotation))
+		})
+	})
+
+	Context("When a NamespaceLabel lives in or labels a protected namespace", func() {
+		It("should deny it by name pattern and by selector", func() {
+			namespaceLabel := newNamespaceLabel(map[string]string{"owner": "billing"})
+			namespaceLabel.Namespace = "openshift-monitoring"
+			resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("namespace 'openshift-monitoring' is protected"))
+
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"owner": "billing"}))
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+
+			var err error
+			validator.ProtectedNamespaces, err = namespacelabel.NewProtectedNamespaces(nil,
+				labels.SelectorFromSet(labels.Set{"tenant": "dev"}))
+			Expect(err).NotTo(HaveOccurred())
+			resp = validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("namespace 'tenant' is protected"))
+		})
+	})
+
+	Context("When namespaces must opt in", func() {
+		It("should deny creation outside opted-in namespaces and keep admitting updates", func() {
+			validator.OptInAnnotation = "dana.io/labels-enabled"
+			namespaceLabel := newNamespaceLabel(map[string]string{"owner": "billing"})
+			resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("namespace 'tenant' is not opted in"))
+			Expect(validator.Handle(ctx, newAdmissionRequest(admissionv1.Update, namespaceLabel)).Allowed).To(BeTrue())
+
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			namespace.Annotations = map[string]string{"dana.io/labels-enabled": "true"}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+			Expect(validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel)).Allowed).To(BeTrue())
+		})
+	})
+
+	Context("When a label quota applies", func() {
+		It("should deny NamespaceLabels declaring too many labels", func() {
+			validator.Quota = validation.LabelQuota{MaxLabels: 2}
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"a": "1", "b": "2"}))
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+
+			namespaceLabel := newNamespaceLabel(map[string]string{"a": "1", "b": "2"})
+			namespaceLabel.Spec.LabelRules = []danav1alpha1.LabelRule{{Key: "c", Value: "3"}}
+			resp := validator.Handle(ctx, newAdmissionRequest(admissionv1.Create, namespaceLabel))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("exceeding the quota of 2 labels per NamespaceLabel"))
+		})
+
+		It("should deny labels taking a namespace over the managed labels of a LabelPolicy", func() {
+			maxManagedLabels := int32(2)
+			Expect(k8sClient.Create(ctx, &danav1alpha1.LabelPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "small-namespaces"},
+				Spec:       danav1alpha1.LabelPolicySpec{MaxManagedLabels: &maxManagedLabels},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &danav1alpha1.ClusterNamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+				Spec: danav1alpha1.ClusterNamespaceLabelSpec{
+					NamespaceSelector: danav1alpha1.ClusterNamespaceSelector{Names: []string{namespaceName}},
+					Labels:            map[string]string{"tier": "gold"},
+				},
+			})).To(Succeed())
+
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"tier": "gold", "a": "1"}))
+			Expect(validator.Handle(ctx, req).Allowed).To(BeTrue())
+
+			req = newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"a": "1", "b": "2"}))
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("namespace 'tenant' would carry 3 managed labels"))
+		})
+	})
+
+	Context("When a trusted principal makes a denied change", func() {
+		BeforeEach(func() {
+			validator.Bypass = &danav1alpha1.PrincipalList{
+				Groups:          []string{"system:masters"},
+				ServiceAccounts: []string{"argocd/argocd-application-controller"},
+			}
+		})
+
+		It("should admit protected labels with a warning", func() {
+			req := newAdmissionRequest(admissionv1.Create,
+				newNamespaceLabel(map[string]string{"kubernetes.io/metadata.name": "other"}))
+			req.UserInfo = authenticationv1.UserInfo{Username: "system:serviceaccount:argocd:argocd-application-controller"}
+			resp := validator.Handle(ctx, req)
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Warnings).To(ConsistOf(ContainSubstring("bypassed denial")))
+		})
+
+		It("should keep denying everyone else", func() {
+			req := newAdmissionRequest(admissionv1.Create,
+				newNamespaceLabel(map[string]string{"kubernetes.io/metadata.name": "other"}))
+			req.UserInfo = authenticationv1.UserInfo{Username: "tenant", Groups: []string{"system:authenticated"}}
+			Expect(validator.Handle(ctx, req).Allowed).To(BeFalse())
+		})
+
+		It("should not bypass malformed labels", func() {
+			req := newAdmissionRequest(admissionv1.Create, newNamespaceLabel(map[string]string{"team": "a b"}))
+			req.UserInfo = authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}}
+			Expect(validator.Handle(ctx, req).Allowed).To(BeFalse())
+		})
+	})
+
+	It("should not be ready while a webhook has no decoder", func() {
+		check := decodersReady(map[string]decodingHandler{
+			ValidatePath: &NamespaceLabelValidator{decoder: admission.NewDecoder(runtime.NewScheme())},
+			MutatePath:   &NamespaceLabelDefaulter{},
+		})
+		Expect(check(nil)).To(MatchError("webhook " + MutatePath + " has no decoder"))
+
+		check = decodersReady(map[string]decodingHandler{
+			ValidatePath: &NamespaceLabelValidator{decoder: admission.NewDecoder(runtime.NewScheme())},
+		})
+		Expect(check(nil)).To(Succeed())
+	})
+})
@@ -0,0 +1,164 @@
+// Package webhook provides the NamespaceLabel, ClusterNamespaceLabel and Namespace admission webhooks. Managers
+// embedding several webhooks in one binary can register them with SetupWithManager instead of running a separate
+// deployment.
+package webhook
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	danav1alpha1 "github.com/TalDebi/namespacelabel-assignment.git/api/v1alpha1"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/namespacelabel"
+	"github.com/TalDebi/namespacelabel-assignment.git/pkg/validation"
+)
+
+const (
+	// MutatePath is the path the defaulting webhook is served on
+	MutatePath = "/mutate-namespacelabel"
+	// ValidatePath is the path the validating webhook is served on
+	ValidatePath = "/validate-namespacelabel"
+	// NamespaceValidatePath is the path the Namespace validating webhook is served on
+	NamespaceValidatePath = "/validate-namespace"
+	// ClusterValidatePath is the path the ClusterNamespaceLabel validating webhook is served on
+	ClusterValidatePath = "/validate-clusternamespacelabel"
+	// ConvertPath is the path the NamespaceLabel conversion webhook is served on
+	ConvertPath = "/convert"
+)
+
+// Options configures the NamespaceLabel admission webhooks
+type Options struct {
+	// BreakGlassGroups are the groups allowed to bypass a denial with the break-glass annotation
+	BreakGlassGroups []string
+	// BypassPrincipals are the trusted users, groups and service accounts whose NamespaceLabel changes are admitted
+	// with a warning instead of denied
+	BypassPrincipals *danav1alpha1.PrincipalList
+	// DefaultLabels are default labels set on every NamespaceLabel
+	DefaultLabels map[string]string
+	// DefaultGroupLabels maps user group prefixes to the labels the rest of the group name provides defaults for
+	DefaultGroupLabels map[string]string
+	// LowercaseLabelKeys rewrites NamespaceLabel label keys to lowercase
+	LowercaseLabelKeys bool
+	// DefaultLabelAnnotations maps Namespace annotation keys to the labels they default when absent
+	DefaultLabelAnnotations map[string]string
+	// WatchSelector restricts the webhooks to NamespaceLabels whose labels match it; nil matches all
+	WatchSelector labels.Selector
+	// MapNamespace maps a NamespaceLabel's namespace to the Namespace it labels; nil maps it to itself
+	MapNamespace namespacelabel.NamespaceMapper
+	// AdminNamespaces are the namespaces whose NamespaceLabels may label other Namespaces through targetNamespaces
+	AdminNamespaces []string
+	// ProtectedLabels are the label keys NamespaceLabels may not set; nil protects the Kubernetes management labels
+	ProtectedLabels *namespacelabel.ProtectedLabels
+	// ProtectedAnnotations are the annotation keys NamespaceLabels may not set; nil protects
+	// namespacelabel.DefaultProtectedAnnotationPrefixes
+	ProtectedAnnotations *namespacelabel.ProtectedLabels
+	// ProtectedNamespaces are the Namespaces NamespaceLabels may not live in or label; nil protects
+	// namespacelabel.DefaultProtectedNamespaces
+	ProtectedNamespaces *namespacelabel.ProtectedNamespaces
+	// OptInAnnotation is the annotation a namespace must carry set to "true" for NamespaceLabels to be created
+	// in it; empty admits them in every namespace
+	OptInAnnotation string
+	// AllowMultiple admits several NamespaceLabels per namespace
+	AllowMultiple bool
+	// PolicySource provides the LabelPolicies enforced by the validating webhook; defaults to the
+	// LabelPolicy objects in the cluster
+	PolicySource validation.PolicySource
+	// Quota caps the labels NamespaceLabels may declare, and NamespaceLabels and ClusterNamespaceLabels manage, on a
+	// namespace, on top of the LabelPolicy limits
+	Quota validation.LabelQuota
+	// ControllerUsername is the user the controller writes Namespaces as, allowed to change managed labels
+	ControllerUsername string
+	// WarnOnManagedLabelChanges admits Namespace updates changing managed labels with a warning instead of
+	// rejecting them
+	WarnOnManagedLabelChanges bool
+}
+
+// +kubebuilder:webhook:path=/mutate-namespacelabel,mutating=true,failurePolicy=fail,sideEffects=None,groups=dana.dana.io,resources=namespacelabels,verbs=create;update,versions=v1alpha1,name=mnamespacelabel.kb.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-namespacelabel,mutating=false,failurePolicy=fail,sideEffects=None,groups=dana.dana.io,resources=namespacelabels,verbs=create;update;delete,versions=v1alpha1,name=vnamespacelabel.kb.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-clusternamespacelabel,mutating=false,failurePolicy=fail,sideEffects=None,groups=dana.dana.io,resources=clusternamespacelabels,verbs=create;update,versions=v1alpha1,name=vclusternamespacelabel.kb.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-namespace,mutating=false,failurePolicy=ignore,sideEffects=None,groups="",resources=namespaces,verbs=update,versions=v1,name=vnamespace.kb.io,admissionReviewVersions=v1
+
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// SetupWithManager registers the NamespaceLabel defaulting, validating and conversion webhooks and the Namespace
+// and ClusterNamespaceLabel validating webhooks on the manager's webhook server, and a readiness check that their decoders are set. The
+// manager's scheme must include every served NamespaceLabel version.
+func SetupWithManager(mgr ctrl.Manager, options Options) error {
+	policySource := options.PolicySource
+	if policySource == nil {
+		policySource = &validation.ClusterPolicySource{Client: mgr.GetClient()}
+	}
+
+	validator := &NamespaceLabelValidator{
+		Client:               mgr.GetClient(),
+		Recorder:             mgr.GetEventRecorderFor("namespacelabel-webhook"),
+		PolicySource:         policySource,
+		Quota:                options.Quota,
+		BreakGlassGroups:     options.BreakGlassGroups,
+		Bypass:               options.BypassPrincipals,
+		WatchSelector:        options.WatchSelector,
+		MapNamespace:         options.MapNamespace,
+		AdminNamespaces:      options.AdminNamespaces,
+		ProtectedLabels:      options.ProtectedLabels,
+		ProtectedAnnotations: options.ProtectedAnnotations,
+		OptInAnnotation:      options.OptInAnnotation,
+		ProtectedNamespaces:  options.ProtectedNamespaces,
+		AllowMultiple:        options.AllowMultiple,
+		decoder:              admission.NewDecoder(mgr.GetScheme()),
+	}
+
+	defaulter := &NamespaceLabelDefaulter{
+		Client:             mgr.GetClient(),
+		Labels:             options.DefaultLabels,
+		GroupLabels:        options.DefaultGroupLabels,
+		AnnotationLabels:   options.DefaultLabelAnnotations,
+		LowercaseKeys:      options.LowercaseLabelKeys,
+		WatchSelector:      options.WatchSelector,
+		MapNamespace:       options.MapNamespace,
+		Bypass:             options.BypassPrincipals,
+		BreakGlassGroups:   options.BreakGlassGroups,
+		ControllerUsername: options.ControllerUsername,
+		decoder:            admission.NewDecoder(mgr.GetScheme()),
+	}
+
+	namespaceValidator := &NamespaceValidator{
+		Client:             mgr.GetClient(),
+		ControllerUsername: options.ControllerUsername,
+		WarnOnly:           options.WarnOnManagedLabelChanges,
+		WatchSelector:      options.WatchSelector,
+		MapNamespace:       options.MapNamespace,
+		decoder:            admission.NewDecoder(mgr.GetScheme()),
+	}
+
+	clusterValidator := &ClusterNamespaceLabelValidator{
+		Client:              mgr.GetClient(),
+		PolicySource:        policySource,
+		Quota:               options.Quota,
+		MapNamespace:        options.MapNamespace,
+		ProtectedLabels:     options.ProtectedLabels,
+		ProtectedNamespaces: options.ProtectedNamespaces,
+		decoder:             admission.NewDecoder(mgr.GetScheme()),
+	}
+
+	mgr.GetWebhookServer().Register(MutatePath, &admission.Webhook{
+		Handler: defaulter,
+	})
+	mgr.GetWebhookServer().Register(ValidatePath, &admission.Webhook{
+		Handler: validator,
+	})
+	mgr.GetWebhookServer().Register(NamespaceValidatePath, &admission.Webhook{
+		Handler: namespaceValidator,
+	})
+	mgr.GetWebhookServer().Register(ClusterValidatePath, &admission.Webhook{
+		Handler: clusterValidator,
+	})
+	mgr.GetWebhookServer().Register(ConvertPath, conversion.NewWebhookHandler(mgr.GetScheme()))
+
+	return mgr.AddReadyzCheck(DecodersReadyCheck, decodersReady(map[string]decodingHandler{
+		MutatePath:            defaulter,
+		ValidatePath:          validator,
+		NamespaceValidatePath: namespaceValidator,
+		ClusterValidatePath:   clusterValidator,
+	}))
+}